@@ -7,11 +7,16 @@ require (
 	github.com/bww/go-router/v2 v2.4.3
 	github.com/bww/go-util v1.43.1
 	github.com/getsentry/sentry-go v0.28.0
+	google.golang.org/grpc v1.64.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/bww/go-xid v0.2.0 // indirect
 	github.com/lib/pq v1.10.9 // indirect
+	golang.org/x/net v0.22.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
 )
@@ -0,0 +1,78 @@
+package alert
+
+import (
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// AggregateMode describes how aggregate errors, like those produced by
+// errors.Join, are represented on an event.
+type AggregateMode int
+
+const (
+	// AggregateExceptions represents an aggregate error as a single entry in
+	// the exception chain.
+	AggregateExceptions AggregateMode = iota
+	// AggregateThreads additionally represents each branch of an aggregate
+	// error, along with its stacktrace, as a separate thread.
+	AggregateThreads
+	// AggregateGroups represents each branch of an aggregate error, and its
+	// causes, as exceptions of their own, which are linked to the aggregate
+	// as a Sentry exception group. Each branch is described to the same
+	// depth as a chain; see Config.MaxErrorDepth.
+	AggregateGroups
+)
+
+// threadsFromAggregate produces a thread for each branch of the error, if it
+// is an aggregate. The stacktrace of each thread is the first one found in
+// the chain of its branch.
+func threadsFromAggregate(err error) []sentry.Thread {
+	agg, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil
+	}
+	var threads []sentry.Thread
+	for i, e := range agg.Unwrap() {
+		if e == nil {
+			continue
+		}
+		var stack *sentry.Stacktrace
+		walkChain(e, func(c error) bool {
+			_, stack = extractStacktrace(c)
+			return stack == nil
+		})
+		threads = append(threads, sentry.Thread{
+			ID:         strconv.Itoa(i),
+			Name:       e.Error(),
+			Stacktrace: stack,
+		})
+	}
+	return threads
+}
+
+// chainMechanism describes how an exception is linked to the exception it
+// was unwrapped from, identified by its index, within an exception group.
+// The outermost exception has no parent, which is indicated by -1, and the
+// branches of an aggregate are identified by their source.
+func chainMechanism(id, parent int, source string) *sentry.Mechanism {
+	m := &sentry.Mechanism{Type: "chained", Source: source, ExceptionID: id}
+	if parent >= 0 {
+		m.ParentID = sentry.Pointer(parent)
+	}
+	return m
+}
+
+// groupMechanism produces the mechanism of the outermost exception of an
+// event, which is that described by mech, linked into the exception group
+// described by group, if any.
+func groupMechanism(mech, group *sentry.Mechanism) *sentry.Mechanism {
+	if group == nil {
+		return mech
+	}
+	m := *mech
+	m.ExceptionID = group.ExceptionID
+	m.ParentID = group.ParentID
+	m.IsExceptionGroup = group.IsExceptionGroup
+	return &m
+}
@@ -0,0 +1,61 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	errutil "github.com/bww/go-util/v1/errors"
+)
+
+func TestAggregateThreads(t *testing.T) {
+	err := fmt.Errorf("Batch failed: %w", errors.Join(
+		errutil.Stacktrace(errors.New("first")),
+		errors.New("second"),
+	))
+
+	t.Run("threads", func(t *testing.T) {
+		a, tr := newAlerter(t, Config{Aggregate: AggregateThreads})
+		a.Error(err)
+
+		threads := tr.Event(t).Threads
+		if len(threads) != 2 {
+			t.Fatalf("Expected a thread for each branch; got %d", len(threads))
+		}
+		if !strings.HasPrefix(threads[0].Name, "first") || threads[1].Name != "second" {
+			t.Errorf("Expected the threads to be named for their branches; got %q and %q", threads[0].Name, threads[1].Name)
+		}
+		if threads[0].Stacktrace == nil || len(threads[0].Stacktrace.Frames) == 0 {
+			t.Error("Expected the stack of the first branch to be attached to its thread")
+		}
+	})
+
+	t.Run("exceptions", func(t *testing.T) {
+		a, tr := newAlerter(t, Config{})
+		a.Error(err)
+
+		if threads := tr.Event(t).Threads; len(threads) != 0 {
+			t.Errorf("Expected no threads by default; got %d", len(threads))
+		}
+	})
+
+	t.Run("groups", func(t *testing.T) {
+		a, tr := newAlerter(t, Config{Aggregate: AggregateGroups})
+		a.Error(err)
+
+		event := tr.Event(t)
+		var branches int
+		for _, e := range event.Exception {
+			if e.Mechanism == nil {
+				t.Fatalf("Expected every exception of a group to have a mechanism: %+v", e)
+			}
+			if e.Mechanism.Source != "" {
+				branches++
+			}
+		}
+		if branches != 2 {
+			t.Errorf("Expected an exception for each branch; got %d", branches)
+		}
+	})
+}
@@ -1,20 +1,26 @@
 package alert
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
 	"reflect"
+	rdebug "runtime/debug"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bww/go-ident/v1"
 	"github.com/bww/go-util/v1/debug"
-	errutil "github.com/bww/go-util/v1/errors"
 	"github.com/getsentry/sentry-go"
 )
 
-var shared *Alerter
+// shared is the alerter used by the package-level functions. It is read
+// without the lock, which serializes only changes to it.
+var shared atomic.Pointer[Alerter]
 var lock sync.Mutex
 
 var (
@@ -22,165 +28,645 @@ var (
 	ErrUnavailable   = errors.New("Unavailable")
 )
 
+// The default maximum number of errors in a chain described by an event.
 const maxErrorDepth = 3
 
+// Route parameters matched for an attached request are tagged with this
+// prefix, e.g., the route variable "id" becomes the tag "param.id".
+const paramTagPrefix = "param."
+
 type Tags map[string]interface{}
 
 type Config struct {
-	Sentry    *sentry.Client
-	Logger    *slog.Logger
-	Channel   ident.Ident
-	Component string
-	Hostname  string
-	Verbose   bool
+	Sentry      *sentry.Client
+	Logger      *slog.Logger
+	Channel     ident.Ident
+	Component   string
+	Hostname    string
+	Environment string
+	// Tags are applied to every alert the alerter raises, e.g., the region
+	// it is deployed to. Tags provided when an alert is raised take precedence
+	// over them.
+	Tags Tags
+	// Release identifies the version of the program alerts are raised by,
+	// e.g., "v1.4.2". It is set on every event, as is the environment, and
+	// both are logged with every alert. It defaults to the version of the
+	// main module, or else the revision it was built from, as reported by
+	// BuildInfo, which also describes the build in the "build" context of
+	// events. BuildInfo defaults to debug.ReadBuildInfo and is invoked once,
+	// when the alerter is created.
+	Release   string
+	BuildInfo func() (*rdebug.BuildInfo, bool)
+	// Tee lists additional clients that receive a copy of every event
+	// reported to Sentry, e.g., while migrating between Sentry projects.
+	Tee []Client
+	// MinLevel is the least severe level reported to the Sentry client. Tee
+	// clients may declare their own thresholds via MinLevel.
+	MinLevel sentry.Level
+
+	// SummarizeCause appends the message of the root cause of an error to the
+	// message of the event reported for it.
+	SummarizeCause bool
+	// LogReplaceAttr is applied to each attribute the alerter adds to the log
+	// records it produces, in the manner of slog.HandlerOptions.ReplaceAttr.
+	LogReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+	// OnError is invoked when the alerter itself encounters an error, e.g.,
+	// when an alert cannot be delivered.
+	OnError func(error)
+	// ResponseHeaders lists the headers of outbound HTTP responses, carried
+	// by errors implementing ResponseError, that are tagged on the event. When
+	// nil, DefaultResponseHeaders is used.
+	ResponseHeaders []string
+	// Aggregate determines how aggregate errors, which implement
+	// Unwrap() []error, are represented on the event.
+	Aggregate AggregateMode
+	// MaxErrorDepth is the maximum number of errors in a chain, from the
+	// error reported to its innermost cause, which are described by the
+	// exceptions of an event; by default, 3. Deeper causes are omitted.
+	MaxErrorDepth int
+	// Backoff suppresses repeated reports of the same error to Sentry; see
+	// Backoff for details. Suppressed errors are still logged.
+	Backoff Backoff
+	// FirstOnly reports only the first occurrence of each error to Sentry.
+	// Further occurrences are counted and summarized by Rollup instead, which
+	// is useful for errors that are noisy but nonetheless important. Every
+	// alert is tagged with the number of occurrences of its error so far, as
+	// "occurrences". FirstOnly takes precedence over Backoff.
+	//
+	// Errors are tracked in memory, for as long as the bounded number of
+	// errors tracked allows: when it is reached the error which was least
+	// recently seen is forgotten, and is reported again should it recur. The
+	// occurrences of a forgotten error which were not reported are still
+	// accounted for by the next rollup.
+	//
+	// A warning which summarizes the occurrences which were not reported is
+	// reported every RollupInterval, which defaults to an hour, in the manner
+	// of Dedup.Summary. A negative interval disables it.
+	FirstOnly      bool
+	RollupInterval time.Duration
+	// Dedup collapses bursts of the same error reported to Sentry; see Dedup
+	// for details. Backoff takes precedence over Dedup. Suppressed errors are
+	// still logged.
+	Dedup Dedup
+	// Clock produces the current time. It defaults to time.Now and is
+	// intended to be replaced in tests.
+	Clock func() time.Time
+	// CallerTransaction sets the transaction of events which are not related
+	// to a request to the name of the function that raised the alert. This is
+	// useful for background work, which otherwise has no transaction.
+	CallerTransaction bool
+	// Metrics collects metrics describing the alerts that are raised.
+	Metrics Metrics
+	// RunbookResolver produces the URL of the runbook which describes how to
+	// remediate an error, if there is one. A runbook provided explicitly via
+	// WithRunbook takes precedence.
+	RunbookResolver func(err error) string
+	// Components configures the policy for alerts raised by each component,
+	// keyed by component name. The component of an alert is that of the
+	// alerter unless it is overridden via WithComponent.
+	Components map[string]ComponentPolicy
+	// Sentinels describe how errors matching well-known error values are
+	// reported. The first matching sentinel applies, so overrides should be
+	// listed before the sentinels they override. When nil, DefaultSentinels
+	// is used; to extend the defaults, append to DefaultSentinels.
+	Sentinels []Sentinel
+	// Ignore discards errors which any of the rules matches; they are neither
+	// reported nor logged. See IgnoreIs, IgnoreType, and IgnoreMessage.
+	Ignore []Ignore
+	// ProcessInfo attaches the time the alerter was created and the time
+	// elapsed since to every alert; see WithProcessInfo.
+	ProcessInfo bool
+	// WarningExceptions reports alerts less severe than errors as exception
+	// events, as errors are. By default they are reported as message events,
+	// which describe the error but have no exception.
+	WarningExceptions bool
+	// ConsistentLevels derives the level each alert is logged at from the
+	// level it is reported to Sentry at, so the two never diverge. A log level
+	// set via WithLogLevel is disregarded.
+	ConsistentLevels bool
+	// FlagsProvider produces a snapshot of the feature flags in effect when
+	// an alert is raised. Flags provided via WithFlags take precedence.
+	FlagsProvider func(context.Context) map[string]bool
+	// MinStackFrames omits stacks with fewer frames than this from events,
+	// since very shallow stacks are rarely useful. By default every stack is
+	// attached.
+	MinStackFrames int
+	// CaptureCallerStack attaches the stack where each alert is reported to
+	// the outermost exception of its event when none of its exceptions carry
+	// a stack, because the error neither provides frames nor is one Sentry
+	// can extract them from, so that every event has a usable location. The
+	// frames of this package are omitted. See WithStack.
+	CaptureCallerStack bool
+	// MessageRedactor is applied to the messages of events, and of their
+	// exceptions, before they are reported and to the messages of the log
+	// records produced for them, which prevents secrets embedded in the text
+	// of errors from leaking. When nil, DefaultMessageRedactor is used.
+	MessageRedactor func(string) string
+	// Scrubber is applied to the tags and extra of every alert before it is
+	// reported or logged; see Scrubber. When nil, DefaultScrubber is used.
+	Scrubber Scrubber
+	// ScrubHeaders applies the scrubber to the headers of requests attached
+	// to alerts as well. Sentry omits well-known sensitive headers regardless.
+	ScrubHeaders bool
+	// RequestCapture selects the headers, and the portion of the body, of
+	// requests attached to alerts which are captured; see RequestCapture.
+	RequestCapture RequestCapture
+	// Slack, if set, posts every alert which is reported, as well as those
+	// which are only logged because Sentry is not configured, to Slack. The
+	// channel of the alerter selects the destination; see SlackWebhook.
+	// Posting is best effort and never delays the caller.
+	//
+	// Deprecated: Configure a slack.Backend instead, delivered asynchronously
+	// (see Async), which formats alerts in full, may post via incoming
+	// webhooks per channel or via the Slack API, and whose failures are
+	// retried and dead-lettered like those of any other backend.
+	Slack Slack
+	// SampleRate is the proportion of alerts, between 0 and 1, which are
+	// reported to Sentry, which limits the quota consumed during a storm of
+	// errors. Alerts which are not sampled are still logged. Fatal alerts
+	// are always reported, regardless of this rate or that of any component
+	// policy. A rate of 0 or of 1 or more reports every alert.
+	SampleRate float64
+	// SampleRates are the proportions of alerts at each level which are
+	// reported, in addition to SampleRate, e.g., to report 1% of
+	// informational alerts and every error. Sampler, if set, selects a rate
+	// for each alert, which also applies. Alerts which are reported under a
+	// rate of less than 1 are tagged with it as "sample_rate", so that
+	// counts can be extrapolated.
+	SampleRates map[Level]float64
+	Sampler     Sampler
+	// Async delivers events in the background, so reporting an alert never
+	// waits on a client; see Async. The identifier of an event delivered
+	// asynchronously is assigned before it is delivered.
+	Async Async
+	// Backends receive every alert reported, in addition to the Sentry and
+	// tee clients; see Backend.
+	Backends []Backend
+	// Escalations escalate errors which recur frequently; see Escalation.
+	Escalations []Escalation
+	// Digest collects less severe alerts into periodic digests rather than
+	// reporting each of them; see Digest.
+	Digest Digest
+	// OnDeliveryFailure is invoked with each alert a backend fails to
+	// deliver, once any retries are exhausted (see Async.Retries), or when an
+	// asynchronous alert is dropped because the queue is full, so that alerts
+	// raised during an outage can be kept, e.g., spooled to disk, and
+	// delivered again later:
+	//
+	//	OnDeliveryFailure: func(e *alert.Event, err *alert.DeliveryError) {
+	//		spool.Write(e, err.Backend)
+	//	}
+	//
+	// The error identifies the backend, which is nil for alerts dropped from
+	// the queue. It is invoked on the goroutine delivering the alert.
+	OnDeliveryFailure func(e *Event, err *DeliveryError)
+	// BeforeSend is invoked with every alert before it is delivered to
+	// Sentry, the tee clients, and backends, and may modify it or return nil
+	// to drop it, e.g., to scrub personal information or to discard a noisy
+	// error. The message of an alert with exceptions is derived from them,
+	// so it is changed by changing the exceptions. Alerts which are dropped
+	// are still logged.
+	BeforeSend func(e *Event) *Event
+	// Tracer links alerts to the spans of a tracing system other than
+	// Sentry, and records the errors reported on them; see Tracer.
+	Tracer Tracer
+	// Routes deliver alerts for particular channels to backends in addition
+	// to Backends; see Route.
+	Routes []Route
+	// Crashloop detects bursts of fatal alerts, such as those raised for
+	// recovered panics, and reports them as a distinct alert; see Crashloop.
+	Crashloop Crashloop
+	// RequestLogs attaches the log records retained while handling the
+	// request an alert is raised for to the alert, as extra. Records are
+	// only retained for requests whose context was produced by WithLogBuffer
+	// and which are logged via a LogHandler.
+	RequestLogs bool
+	// Verbose enables or disables logging of alerts, e.g., via Bool(false).
+	// When it is nil, logging is enabled by default in development
+	// environments (see Environment) and disabled otherwise.
+	Verbose *bool
+}
+
+// Bool produces a pointer to the value, for optional settings such as
+// Config.Verbose.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// verbose determines whether alerts are logged: as configured, if set, and
+// otherwise as is the default for the environment.
+func (c Config) verbose() bool {
+	if c.Verbose != nil {
+		return *c.Verbose
+	}
+	return verboseEnvironment(c.Environment)
+}
+
+// verboseEnvironment determines whether alerts are logged by default in the
+// specified environment; they are for local development and otherwise not.
+func verboseEnvironment(env string) bool {
+	switch strings.ToLower(env) {
+	case "development", "dev", "local":
+		return true
+	default:
+		return false
+	}
 }
 
+// Init initializes the shared alerter and panics if it cannot be, including
+// if it has already been initialized. See TryInit.
 func Init(conf Config) {
+	if err := TryInit(conf); err != nil {
+		panic(err)
+	}
+}
+
+// TryInit initializes the shared alerter. If it has already been initialized
+// ErrReinitialized is returned and the shared alerter is unaffected.
+func TryInit(conf Config) error {
 	lock.Lock()
 	defer lock.Unlock()
-	var err error
-	if shared != nil {
-		panic(ErrReinitialized)
+	if shared.Load() != nil {
+		return ErrReinitialized
 	}
-	shared, err = New(conf)
+	a, err := New(conf)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	shared.Store(a)
+	return nil
 }
 
-func Default() *Alerter {
-	return shared
+// InitOrReuse initializes the shared alerter unless it has already been
+// initialized, in which case the existing alerter is retained and the
+// configuration is ignored.
+func InitOrReuse(conf Config) error {
+	err := TryInit(conf)
+	if errors.Is(err, ErrReinitialized) {
+		return nil
+	}
+	return err
 }
 
-func Errorf(f string, args ...interface{}) {
+// Set replaces the shared alerter, which may be nil. Unlike Init, it may be
+// used at any time, e.g., when the configuration is reloaded. The previous
+// alerter is not closed and is returned, so the caller may close it once
+// any alerts it is delivering have been flushed.
+func Set(a *Alerter) *Alerter {
 	lock.Lock()
 	defer lock.Unlock()
-	if shared != nil {
-		shared.Errorf(f, args...)
+	return shared.Swap(a)
+}
+
+// Reset discards the shared alerter without closing it, so that it may be
+// initialized again. See also Close, which closes it first.
+func Reset() {
+	Set(nil)
+}
+
+// IsInitialized determines whether the shared alerter has been initialized.
+func IsInitialized() bool {
+	return shared.Load() != nil
+}
+
+func Default() *Alerter {
+	return shared.Load()
+}
+
+func Errorf(f string, args ...interface{}) {
+	if n := notifier(); n != nil {
+		n.Errorf(f, args...)
 	}
 }
 
-func Error(err error, opts ...Option) {
-	lock.Lock()
-	defer lock.Unlock()
-	if shared != nil {
-		shared.Error(err, opts...)
+func Error(err error, opts ...Option) *sentry.EventID {
+	if n := notifier(); n != nil {
+		return n.Error(err, opts...)
 	}
+	return nil
 }
 
-type Alerter struct {
-	sentry    *sentry.Client
-	log       *slog.Logger
-	channel   ident.Ident
-	component string
-	hostname  string
-	verbose   bool
+func Warningf(f string, args ...interface{}) {
+	if n := notifier(); n != nil {
+		n.Warningf(f, args...)
+	}
 }
 
-func New(conf Config) (*Alerter, error) {
-	if conf.Sentry != nil {
-		hub := sentry.CurrentHub()
-		hub.BindClient(conf.Sentry)
-		scope := hub.Scope()
-		if conf.Component != "" {
-			scope.SetTag("component", conf.Component)
-		}
-		if conf.Hostname != "" {
-			scope.SetTag("host", conf.Hostname)
-		}
+func Warning(err error, opts ...Option) *sentry.EventID {
+	if n := notifier(); n != nil {
+		return n.Warning(err, opts...)
 	}
+	return nil
+}
 
-	if conf.Logger != nil {
-		if conf.Component != "" {
-			conf.Logger = conf.Logger.With("component", conf.Component)
-		}
-		if conf.Hostname != "" {
-			conf.Logger = conf.Logger.With("host", conf.Hostname)
-		}
+func Infof(f string, args ...interface{}) {
+	if n := notifier(); n != nil {
+		n.Infof(f, args...)
 	}
+}
 
-	return &Alerter{
-		sentry:    conf.Sentry,
-		log:       conf.Logger,
-		channel:   conf.Channel,
-		component: conf.Component,
-		hostname:  conf.Hostname,
-		verbose:   conf.Verbose,
-	}, nil
+func Info(err error, opts ...Option) *sentry.EventID {
+	if n := notifier(); n != nil {
+		return n.Info(err, opts...)
+	}
+	return nil
 }
 
-func (a *Alerter) Errorf(f string, args ...interface{}) {
-	a.Error(fmt.Errorf(f, args...))
+func Message(msg string, opts ...Option) *sentry.EventID {
+	if n := notifier(); n != nil {
+		return n.Message(msg, opts...)
+	}
+	return nil
 }
 
-func (a *Alerter) Error(err error, opts ...Option) {
-	ref := errutil.Refstr(err)
+func CaptureSync(lvl sentry.Level, err error, opts ...Option) (*sentry.EventID, error) {
+	if a := shared.Load(); a != nil {
+		return a.CaptureSync(lvl, err, opts...)
+	}
+	return nil, ErrUnavailable
+}
 
-	var h *sentry.Hub
-	if a.sentry != nil {
-		h = sentry.CurrentHub().Clone()
+func Timeout(op string, elapsed time.Duration, opts ...Option) {
+	if n := notifier(); n != nil {
+		n.Timeout(op, elapsed, opts...)
 	}
-	var log *slog.Logger
-	if a.verbose && a.log != nil {
-		log = a.log.With("alert", "error")
-		if ref != "" {
-			log = log.With("ref", ref)
-		}
+}
+
+type Alerter struct {
+	sentry            *sentry.Client
+	hub               *sentry.Hub // the alerter's own, so no scope is shared between alerters
+	tee               []Client
+	minLevel          sentry.Level
+	log               *slog.Logger
+	channel           ident.Ident
+	component         string
+	hostname          string
+	environment       string
+	build             build
+	tags              Tags
+	verbose           bool
+	summarize         bool
+	replaceAttr       func(groups []string, a slog.Attr) slog.Attr
+	onError           func(error)
+	onDeliveryFailure func(*Event, *DeliveryError)
+	responseHeaders   []string
+	aggregate         AggregateMode
+	maxErrorDepth     int
+	backoff           Backoff
+	firstOnly         bool
+	dedup             Dedup
+	digest            Digest
+	digests           *digests
+	callerTransaction bool
+	metrics           Metrics
+	runbooks          func(err error) string
+	components        map[string]ComponentPolicy
+	sentinels         []Sentinel
+	ignore            []Ignore
+	processInfo       bool
+	stats             *stats
+	warningExceptions bool
+	consistentLevels  bool
+	flags             func(context.Context) map[string]bool
+	requestLogs       bool
+	minStackFrames    int
+	callerStack       bool
+	redact            func(string) string
+	scrubber          Scrubber
+	scrubHeaders      bool
+	requestCapture    RequestCapture
+	slack             Slack
+	slackPosts        chan struct{}
+	sampleRate        float64
+	sampleRates       map[Level]float64
+	sampler           Sampler
+	async             *asyncQueue
+	backends          []Backend
+	beforeSend        func(e *Event) *Event
+	routes            []Route
+	escalations       []Escalation
+	tracer            Tracer
+	crashloop         Crashloop
+	breadcrumbs       *breadcrumbs
+	started           time.Time
+	recent            *recent
+	now               func() time.Time
+	options           []Option // applied to every alert; see Named
+
+	// the lifecycle is shared by an alerter and those derived from it
+	*lifecycle
+}
+
+type lifecycle struct {
+	closeLock  sync.Mutex
+	onClose    []func()
+	afterClose []func()
+	closed     atomic.Bool
+}
+
+func New(conf Config) (*Alerter, error) {
+	tags := make(Tags, len(conf.Tags)+1)
+	merge(tags, conf.Tags)
+	if conf.Hostname != "" {
+		tags["host"] = conf.Hostname
 	}
 
-	var cxt Context
-	for _, o := range opts {
-		cxt = o(cxt)
+	if conf.Metrics == nil {
+		conf.Metrics = nopMetrics{}
+	}
+	if conf.Sentinels == nil {
+		conf.Sentinels = DefaultSentinels
+	}
+	if conf.Clock == nil {
+		conf.Clock = time.Now
+	}
+	if conf.BuildInfo == nil {
+		conf.BuildInfo = rdebug.ReadBuildInfo
+	}
+	if conf.ResponseHeaders == nil {
+		conf.ResponseHeaders = DefaultResponseHeaders
+	}
+	if conf.MessageRedactor == nil {
+		conf.MessageRedactor = DefaultMessageRedactor
+	}
+	if conf.Scrubber == nil {
+		conf.Scrubber = DefaultScrubber
 	}
 
-	if req := cxt.Request; req != nil {
-		if h != nil {
-			h.Scope().SetRequest((*http.Request)(req))
-			h.Scope().SetUser(sentry.User{IPAddress: req.OriginAddr()})
+	a := &Alerter{
+		sentry:            conf.Sentry,
+		hub:               sentry.NewHub(conf.Sentry, sentry.NewScope()),
+		tee:               conf.Tee,
+		minLevel:          conf.MinLevel,
+		log:               conf.Logger,
+		channel:           conf.Channel,
+		component:         conf.Component,
+		hostname:          conf.Hostname,
+		environment:       conf.Environment,
+		build:             newBuild(conf.Release, conf.BuildInfo),
+		tags:              tags,
+		verbose:           conf.verbose(),
+		summarize:         conf.SummarizeCause,
+		replaceAttr:       conf.LogReplaceAttr,
+		onError:           conf.OnError,
+		onDeliveryFailure: conf.OnDeliveryFailure,
+		responseHeaders:   conf.ResponseHeaders,
+		aggregate:         conf.Aggregate,
+		maxErrorDepth:     conf.MaxErrorDepth,
+		backoff:           conf.Backoff,
+		firstOnly:         conf.FirstOnly,
+		dedup:             conf.Dedup,
+		digest:            conf.Digest,
+		digests:           &digests{},
+		callerTransaction: conf.CallerTransaction,
+		metrics:           conf.Metrics,
+		runbooks:          conf.RunbookResolver,
+		components:        conf.Components,
+		sentinels:         conf.Sentinels,
+		ignore:            conf.Ignore,
+		processInfo:       conf.ProcessInfo,
+		stats:             newStats(),
+		warningExceptions: conf.WarningExceptions,
+		consistentLevels:  conf.ConsistentLevels,
+		flags:             conf.FlagsProvider,
+		requestLogs:       conf.RequestLogs,
+		minStackFrames:    conf.MinStackFrames,
+		callerStack:       conf.CaptureCallerStack,
+		redact:            conf.MessageRedactor,
+		scrubber:          conf.Scrubber,
+		scrubHeaders:      conf.ScrubHeaders,
+		requestCapture:    conf.RequestCapture,
+		slack:             conf.Slack,
+		slackPosts:        make(chan struct{}, maxSlackPosts),
+		sampleRate:        conf.SampleRate,
+		sampleRates:       conf.SampleRates,
+		sampler:           conf.Sampler,
+		backends:          conf.Backends,
+		beforeSend:        conf.BeforeSend,
+		routes:            conf.Routes,
+		escalations:       conf.Escalations,
+		tracer:            conf.Tracer,
+		crashloop:         conf.Crashloop,
+		breadcrumbs:       &breadcrumbs{},
+		started:           conf.Clock(),
+
+		recent:    newRecent(defaultRecentLimit),
+		now:       conf.Clock,
+		lifecycle: &lifecycle{},
+	}
+	if a.maxErrorDepth <= 0 {
+		a.maxErrorDepth = maxErrorDepth
+	}
+	if conf.Async.enabled() {
+		a.async = newAsyncQueue(conf.Async)
+		a.async.start(a)
+	}
+	if conf.FirstOnly {
+		if conf.RollupInterval == 0 {
+			conf.RollupInterval = defaultRollupInterval
 		}
-		if log != nil {
-			log = log.With(
-				"alert", "error",
-				"request", fmt.Sprintf("%s %s", req.Method, req.URL.String()),
-			)
+		if conf.RollupInterval > 0 {
+			a.summarizeEvery(conf.RollupInterval)
 		}
+	} else if conf.Dedup.enabled() && conf.Dedup.Summary > 0 {
+		a.summarizeEvery(conf.Dedup.Summary)
 	}
+	if conf.Digest.enabled() {
+		a.digestEvery(conf.Digest.Interval)
+	}
+	return a, nil
+}
 
-	if tags := cxt.Tags; len(tags) > 0 {
-		if h != nil {
-			s := h.Scope()
-			for k, v := range tags {
-				s.SetTag(k, fmt.Sprint(v))
-			}
-			if ref != "" {
-				s.SetTag("ref", ref)
-			}
-		}
-		if log != nil {
-			for k, v := range tags {
-				log = log.With(k, v)
-			}
-		}
+func (a *Alerter) Errorf(f string, args ...interface{}) {
+	a.Error(fmt.Errorf(f, args...))
+}
+
+// Error reports an error and produces the identifier of the event that was
+// captured, or nil if none was, e.g., because no client is configured or the
+// error was suppressed.
+func (a *Alerter) Error(err error, opts ...Option) *sentry.EventID {
+	return a.report(err, opts...)
+}
+
+func (a *Alerter) Warningf(f string, args ...interface{}) {
+	a.Warning(fmt.Errorf(f, args...))
+}
+
+// Warning reports an error at the warning level, e.g., a degraded condition
+// which is expected to recover. Levels set via the options take precedence.
+// Like Error, it produces the identifier of the event that was captured, if
+// any.
+func (a *Alerter) Warning(err error, opts ...Option) *sentry.EventID {
+	return a.report(err, append([]Option{WithLevel(sentry.LevelWarning)}, opts...)...)
+}
+
+func (a *Alerter) Infof(f string, args ...interface{}) {
+	a.Info(fmt.Errorf(f, args...))
+}
+
+// Info reports an error at the info level. Levels set via the options take
+// precedence. Like Error, it produces the identifier of the event that was
+// captured, if any.
+func (a *Alerter) Info(err error, opts ...Option) *sentry.EventID {
+	return a.report(err, append([]Option{WithLevel(sentry.LevelInfo)}, opts...)...)
+}
+
+// merge copies every entry in src to dst, replacing existing entries.
+func merge[M ~map[string]interface{}](dst, src M) {
+	for k, v := range src {
+		dst[k] = v
 	}
+}
 
-	if extra := cxt.Extra; len(extra) > 0 {
-		if log != nil {
-			for k, v := range extra {
-				log = log.With(k, v)
-			}
-		}
+// attrsFromMap produces log attributes for the entries of a map, ordered by
+// key so that log output is stable.
+func attrsFromMap(m map[string]interface{}) []slog.Attr {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]slog.Attr, len(keys))
+	for i, k := range keys {
+		attrs[i] = slog.Any(k, m[k])
+	}
+	return attrs
+}
+
+// notify reports an error that occurred in the alerter itself to the
+// configured handler, if any.
+func (a *Alerter) notify(err error) {
+	if a.onError != nil {
+		a.onError(err)
 	}
+}
 
-	if h != nil {
-		a.captureError(h, err, cxt.Extra)
+// replaceAttrs applies the configured attribute replacement function, if
+// any, to the attributes the alerter adds to a log record. As with
+// slog.HandlerOptions.ReplaceAttr, an attribute replaced by the zero value is
+// discarded.
+func (a *Alerter) replaceAttrs(attrs []slog.Attr) []slog.Attr {
+	if a.replaceAttr == nil {
+		return attrs
 	}
-	if log != nil && a.verbose {
-		log.Error(err.Error())
+	res := attrs[:0]
+	for _, e := range attrs {
+		if r := a.replaceAttr(nil, e); !r.Equal(slog.Attr{}) {
+			res = append(res, r)
+		}
 	}
+	return res
 }
 
-func (a *Alerter) captureError(hub *sentry.Hub, err error, extra map[string]interface{}) {
-	hub.CaptureEvent(a.eventFromError(err, sentry.LevelError, extra))
+// defaultMechanism describes how errors reported through the alerter are
+// produced absent any more specific information from the caller: they are
+// generic errors which have been handled.
+func defaultMechanism() *sentry.Mechanism {
+	handled := true
+	return &sentry.Mechanism{
+		Type:    "generic",
+		Handled: &handled,
+	}
 }
 
 func (a *Alerter) eventFromError(err error, lvl sentry.Level, extra map[string]interface{}) *sentry.Event {
@@ -191,22 +677,46 @@ func (a *Alerter) eventFromError(err error, lvl sentry.Level, extra map[string]i
 	if c, ok := err.(interface{ Title() string }); ok {
 		event.Message = c.Title()
 	}
+	if a.summarize {
+		event.Message = summarizeCause(event.Message, err, a.maxErrorDepth)
+	}
 
-	var stack *sentry.Stacktrace
-	for i := 0; i < maxErrorDepth && err != nil; i++ {
-		err, stack = extractStacktrace(err)
-		event.Exception = append(event.Exception, sentry.Exception{
-			Value:      err.Error(),
-			Type:       reflect.TypeOf(err).String(),
-			Stacktrace: stack,
-		})
-		switch prev := err.(type) {
-		case interface{ Unwrap() error }:
-			err = prev.Unwrap()
-		case interface{ Cause() error }:
-			err = prev.Cause()
-		default:
-			err = nil
+	var groups bool
+	var mechs []*sentry.Mechanism
+	seen := make(visited)
+	var walk func(err error, depth, parent int, source string)
+	walk = func(err error, depth, parent int, source string) {
+		var stack *sentry.Stacktrace
+		for ; depth < a.maxErrorDepth && err != nil && len(event.Exception) < maxChainLength && seen.Visit(err); depth++ {
+			err, stack = extractStacktrace(err)
+			if stack != nil && len(stack.Frames) < a.minStackFrames {
+				stack = nil
+			}
+			event.Exception = append(event.Exception, sentry.Exception{
+				Value:      err.Error(),
+				Type:       reflect.TypeOf(err).String(),
+				Stacktrace: stack,
+			})
+			mech := chainMechanism(len(event.Exception)-1, parent, source)
+			mechs = append(mechs, mech)
+			parent, source = mech.ExceptionID, ""
+			if a.aggregate == AggregateThreads && event.Threads == nil {
+				event.Threads = threadsFromAggregate(err)
+			}
+			if agg, ok := err.(interface{ Unwrap() []error }); ok && a.aggregate == AggregateGroups {
+				groups, mech.IsExceptionGroup = true, true
+				for i, e := range agg.Unwrap() {
+					walk(e, depth+1, parent, fmt.Sprintf("errors[%d]", i))
+				}
+				return
+			}
+			err = unwrap(err)
+		}
+	}
+	walk(err, 0, -1, "")
+	if groups {
+		for i := range event.Exception {
+			event.Exception[i].Mechanism = mechs[i]
 		}
 	}
 
@@ -223,16 +733,19 @@ func extractStacktrace(err error) (error, *sentry.Stacktrace) {
 	}
 }
 
+// maybeUnwrap unwraps the error if it wraps another. Errors which claim to
+// wrap another, but actually wrap nothing, are returned as-is.
 func maybeUnwrap(err error) error {
-	switch c := err.(type) {
-	case interface{ Unwrap() error }:
-		return c.Unwrap()
-	default:
-		return err
+	if u := unwrap(err); u != nil {
+		return u
 	}
+	return err
 }
 
 func convertStacktrace(frames []debug.Frame) *sentry.Stacktrace {
+	if len(frames) == 0 {
+		return nil
+	}
 	conv := make([]sentry.Frame, len(frames))
 	for i, e := range frames {
 		conv[len(frames)-i-1] = sentry.Frame{
@@ -247,6 +760,34 @@ func convertStacktrace(frames []debug.Frame) *sentry.Stacktrace {
 	}
 }
 
+// summarizeCause appends the message of the root cause of err to the
+// provided title, so that both ends of the chain are visible at a glance. If
+// the title is empty the message of err itself is used in its place. When err
+// has no cause or the title already ends with the root cause, the title is
+// returned unmodified.
+func summarizeCause(title string, err error, maxDepth int) string {
+	var (
+		root  error
+		depth int
+	)
+	walkChain(err, func(e error) bool {
+		root = e
+		depth++
+		return depth < maxDepth
+	})
+	if title == "" {
+		title = err.Error()
+	}
+	if depth < 2 {
+		return title
+	}
+	cause := root.Error()
+	if strings.HasSuffix(title, cause) {
+		return title
+	}
+	return title + ": " + cause
+}
+
 // reverse reverses the slice a in place.
 func reverse(a []sentry.Exception) {
 	for i := len(a)/2 - 1; i >= 0; i-- {
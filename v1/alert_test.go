@@ -0,0 +1,384 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/bww/go-util/v1/debug"
+)
+
+// fuzzLink is a link in an error chain produced from fuzzer input.
+type fuzzLink struct {
+	name   string
+	frames []debug.Frame
+	next   error
+}
+
+func (e *fuzzLink) Error() string { return e.name }
+func (e *fuzzLink) Unwrap() error { return e.next }
+
+// framedFuzzLink is a fuzzLink which carries a stack, as errors produced by
+// errutil.Stacktrace do.
+type framedFuzzLink struct{ *fuzzLink }
+
+func (e framedFuzzLink) Frames() []debug.Frame { return e.frames }
+
+// fuzzFrames produces frames from fuzzer input.
+func fuzzFrames(data []byte) []debug.Frame {
+	frames := make([]debug.Frame, len(data))
+	for i, b := range data {
+		frames[i] = debug.Frame{Name: fmt.Sprintf("f%d", i), File: "file.go", Line: int(b)}
+	}
+	return frames
+}
+
+// fuzzChain produces an error chain from fuzzer input, each byte of which
+// describes a link: whether it carries frames and how many, whether it is an
+// aggregate, and whether it points back into the chain. The chain is linear,
+// so its order can be checked, only if it has no aggregates or cycles.
+func fuzzChain(data []byte) (error, bool) {
+	if len(data) > 16 {
+		data = data[:16]
+	}
+	var root error = errors.New("root")
+	links := make([]*fuzzLink, len(data))
+	errs := make([]error, len(data))
+	for i := range data {
+		links[i] = &fuzzLink{name: fmt.Sprintf("e%d", i)}
+		errs[i] = links[i]
+	}
+	linear := true
+	for i, b := range data {
+		l := links[i]
+		l.frames = fuzzFrames(make([]byte, int(b>>3)%4))
+		if b&1 != 0 {
+			errs[i] = framedFuzzLink{l}
+		}
+		if i+1 < len(data) {
+			l.next = errs[i+1]
+		} else {
+			l.next = root
+		}
+		switch (b >> 1) & 3 {
+		case 2:
+			l.next = errors.Join(l.next, errors.New("branch"))
+			linear = false
+		case 3:
+			if b&0x40 != 0 {
+				l.next = errs[0] // a cycle
+				linear = false
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return root, true
+	}
+	return errs[0], linear
+}
+
+func FuzzConvertStacktrace(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{1})
+	f.Add([]byte{1, 2, 3, 4, 5})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		frames := fuzzFrames(data)
+		stack := convertStacktrace(frames)
+		if len(frames) == 0 {
+			if stack != nil {
+				t.Fatalf("Expected no stacktrace for no frames; got %d frames", len(stack.Frames))
+			}
+			return
+		}
+		if len(stack.Frames) != len(frames) {
+			t.Fatalf("Expected %d frames; got %d", len(frames), len(stack.Frames))
+		}
+		// Sentry expects the innermost frame last
+		for i, e := range frames {
+			c := stack.Frames[len(frames)-i-1]
+			if c.Function != e.Name || c.Lineno != e.Line || c.Filename != e.File {
+				t.Fatalf("Expected frame %d to be converted to position %d; got %+v", i, len(frames)-i-1, c)
+			}
+		}
+	})
+}
+
+func FuzzEventFromError(f *testing.F) {
+	f.Add([]byte{}, 3, 0, 0)
+	f.Add([]byte{0, 1, 2, 3}, 3, 0, 0)
+	f.Add([]byte{1, 9, 17, 25}, 8, 2, 0)
+	f.Add([]byte{4, 5, 4, 5}, 4, 0, int(AggregateGroups))
+	f.Add([]byte{6, 0x46, 7}, 64, 0, int(AggregateThreads))
+	f.Fuzz(func(t *testing.T, data []byte, depth, minFrames, mode int) {
+		depth, minFrames, mode = depth%(2*maxChainLength), minFrames%8, mode%3
+		if depth < 0 || minFrames < 0 || mode < 0 {
+			return
+		}
+		err, linear := fuzzChain(data)
+		a, aerr := New(Config{MaxErrorDepth: depth, MinStackFrames: minFrames, Aggregate: AggregateMode(mode)})
+		if aerr != nil {
+			t.Fatal(aerr)
+		}
+		defer a.Close()
+
+		event := a.eventFromError(err, "error", nil)
+		if n := len(event.Exception); n > maxChainLength {
+			t.Fatalf("Expected at most %d exceptions; got %d", maxChainLength, n)
+		} else if n == 0 {
+			t.Fatal("Expected at least one exception")
+		}
+		if AggregateMode(mode) != AggregateGroups && len(event.Exception) > a.maxErrorDepth {
+			t.Fatalf("Expected at most %d exceptions; got %d", a.maxErrorDepth, len(event.Exception))
+		}
+		for _, e := range event.Exception {
+			if e.Type == "" {
+				t.Fatalf("Expected every exception to have a type: %+v", e)
+			}
+			if e.Stacktrace != nil && (len(e.Stacktrace.Frames) == 0 || len(e.Stacktrace.Frames) < minFrames) {
+				t.Fatalf("Expected no stacktrace with fewer than %d frames; got %d", max(1, minFrames), len(e.Stacktrace.Frames))
+			}
+		}
+		if !linear {
+			return
+		}
+		// the outermost error is last and each exception is the cause of the
+		// one after it
+		prev := -1
+		for i := len(event.Exception) - 1; i >= 0; i-- {
+			var index int
+			if _, err := fmt.Sscanf(event.Exception[i].Value, "e%d", &index); err != nil {
+				index = len(data) // the root cause
+			}
+			if index <= prev {
+				t.Fatalf("Expected exception %d (%s) to be a cause of the exception after it", i, event.Exception[i].Value)
+			}
+			prev = index
+		}
+	})
+}
+
+func TestVerboseDefaults(t *testing.T) {
+	tests := []struct {
+		env     string
+		verbose *bool
+		expect  bool
+	}{
+		{"development", nil, true},
+		{"dev", nil, true},
+		{"Local", nil, true},
+		{"staging", nil, false},
+		{"production", nil, false},
+		{"", nil, false},
+		{"development", Bool(false), false},
+		{"production", Bool(true), true},
+	}
+	for _, e := range tests {
+		buf := &bytes.Buffer{}
+		a, _ := newAlerter(t, Config{
+			Environment: e.env,
+			Verbose:     e.verbose,
+			Logger:      slog.New(slog.NewTextHandler(buf, nil)),
+		})
+		a.Error(errors.New("Failed"))
+		if logged := buf.Len() > 0; logged != e.expect {
+			t.Errorf("Expected alerts in %q with verbose %v to be logged: %v; got %v", e.env, fmtBool(e.verbose), e.expect, logged)
+		}
+	}
+}
+
+func fmtBool(v *bool) string {
+	if v == nil {
+		return "unset"
+	}
+	return fmt.Sprint(*v)
+}
+
+func TestSummarizeCause(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("Could not load user: %w", fmt.Errorf("Could not query: %w", root))
+
+	a, tr := newAlerter(t, Config{SummarizeCause: true, MaxErrorDepth: 5})
+	a.Error(titledError{title: "User unavailable", err: err})
+	if v, want := tr.Event(t).Message, "User unavailable: connection refused"; v != want {
+		t.Errorf("Expected the message %q; got %q", want, v)
+	}
+
+	tests := []struct {
+		title  string
+		err    error
+		expect string
+	}{
+		{"", root, "connection refused"},
+		{"Could not load user", err, "Could not load user: connection refused"},
+		{"Could not load user", fmt.Errorf("%w", errors.New("timeout")), "Could not load user: timeout"},
+		{"Query failed: connection refused", err, "Query failed: connection refused"},
+	}
+	for _, e := range tests {
+		if v := summarizeCause(e.title, e.err, 5); v != e.expect {
+			t.Errorf("Expected %q; got %q", e.expect, v)
+		}
+	}
+	// the root cause is the deepest error described
+	if v, want := summarizeCause("Load", err, 2), "Load: Could not query: connection refused"; v != want {
+		t.Errorf("Expected %q; got %q", want, v)
+	}
+}
+
+// titledError is an error which provides a title for its event.
+type titledError struct {
+	title string
+	err   error
+}
+
+func (e titledError) Error() string { return e.title + ": " + e.err.Error() }
+func (e titledError) Title() string { return e.title }
+func (e titledError) Unwrap() error { return e.err }
+
+func TestLogReplaceAttr(t *testing.T) {
+	buf := &bytes.Buffer{}
+	a, _ := newAlerter(t, Config{
+		Verbose: Bool(true),
+		Logger:  slog.New(slog.NewJSONHandler(buf, nil)),
+		LogReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case "ref":
+				a.Key = "error_ref"
+			case "first_seen":
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	a.Error(errors.New("Failed"), WithRef("abc"))
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Could not decode log record: %v: %s", err, buf)
+	}
+	if v := rec["error_ref"]; v != "abc" {
+		t.Errorf("Expected ref to be renamed error_ref; got %v", rec)
+	}
+	if _, ok := rec["ref"]; ok {
+		t.Errorf("Expected ref not to be logged under its own name; got %v", rec)
+	}
+	if _, ok := rec["first_seen"]; ok {
+		t.Errorf("Expected the attribute replaced by the zero value to be discarded; got %v", rec)
+	}
+	if v := rec["msg"]; v != "Failed" {
+		t.Errorf("Expected the attributes of the record itself to be unaffected; got %v", rec)
+	}
+}
+
+// framedError is an error which carries a stack.
+type framedError struct {
+	msg    string
+	frames []debug.Frame
+}
+
+func (e framedError) Error() string         { return e.msg }
+func (e framedError) Frames() []debug.Frame { return e.frames }
+
+func TestMinStackFrames(t *testing.T) {
+	shallow := framedError{"Shallow", fuzzFrames([]byte{1})}
+	deep := framedError{"Deep", fuzzFrames([]byte{1, 2})}
+	tests := []struct {
+		min    int
+		err    error
+		expect int
+	}{
+		{0, shallow, 1},
+		{2, shallow, 0},
+		{2, deep, 2},
+	}
+	for _, e := range tests {
+		a, tr := newAlerter(t, Config{MinStackFrames: e.min})
+		a.Error(e.err)
+		event := tr.Event(t)
+		var n int
+		if s := event.Exception[len(event.Exception)-1].Stacktrace; s != nil {
+			n = len(s.Frames)
+		}
+		if n != e.expect {
+			t.Errorf("Expected %d frames of %v with a minimum of %d; got %d", e.expect, e.err, e.min, n)
+		}
+	}
+}
+
+func TestTryInit(t *testing.T) {
+	defer Set(Set(nil))
+
+	if IsInitialized() {
+		t.Fatal("Expected the shared alerter not to be initialized")
+	}
+	if err := TryInit(Config{Environment: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	first := Default()
+	if !IsInitialized() || first == nil {
+		t.Fatal("Expected the shared alerter to be initialized")
+	}
+	if err := TryInit(Config{Environment: "second"}); !errors.Is(err, ErrReinitialized) {
+		t.Errorf("Expected %v; got %v", ErrReinitialized, err)
+	}
+	if err := InitOrReuse(Config{Environment: "second"}); err != nil {
+		t.Errorf("Expected the shared alerter to be reused; got %v", err)
+	}
+	if Default() != first {
+		t.Error("Expected the shared alerter to be unaffected by initializing it again")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != ErrReinitialized {
+				t.Errorf("Expected Init to panic with %v; got %v", ErrReinitialized, r)
+			}
+		}()
+		Init(Config{})
+	}()
+
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	if IsInitialized() {
+		t.Fatal("Expected the shared alerter to be reset once closed")
+	}
+	if err := InitOrReuse(Config{}); err != nil || Default() == nil || Default() == first {
+		t.Errorf("Expected the shared alerter to be initialized again; got %v", err)
+	}
+	Close()
+}
+
+func TestSet(t *testing.T) {
+	defer Set(Set(nil))
+
+	first, ftr := newAlerter(t, Config{})
+	second, str := newAlerter(t, Config{})
+	if prev := Set(first); prev != nil {
+		t.Errorf("Expected no previous alerter; got %v", prev)
+	}
+	Error(errors.New("First"))
+	if prev := Set(second); prev != first {
+		t.Errorf("Expected the previous alerter to be returned; got %v", prev)
+	}
+	Error(errors.New("Second"))
+	if n, m := len(ftr.Events()), len(str.Events()); n != 1 || m != 1 {
+		t.Errorf("Expected each alerter to receive the alert raised while it was shared; got %d, %d", n, m)
+	}
+
+	Reset()
+	if IsInitialized() {
+		t.Error("Expected the shared alerter to be discarded")
+	}
+	if id := Error(errors.New("Dropped")); id != nil {
+		t.Errorf("Expected no event without a shared alerter; got %v", *id)
+	}
+	if n := len(str.Events()); n != 1 {
+		t.Errorf("Expected the discarded alerter to receive nothing further; got %d events", n)
+	}
+	if err := TryInit(Config{}); err != nil {
+		t.Errorf("Expected the shared alerter to be initialized once reset; got %v", err)
+	}
+	Close()
+}
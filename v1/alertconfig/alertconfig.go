@@ -0,0 +1,381 @@
+// Package alertconfig builds alerters from configuration files and the
+// environment, so that deployments can reconfigure alerting without changing
+// code. It is separate from package alert because it configures the backends
+// provided by its subpackages.
+package alertconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/bww/go-alert/v1/email"
+	"github.com/bww/go-alert/v1/pagerduty"
+	"github.com/bww/go-alert/v1/slack"
+	"github.com/bww/go-alert/v1/webhook"
+	"github.com/bww/go-ident/v1"
+	"github.com/getsentry/sentry-go"
+	"gopkg.in/yaml.v3"
+)
+
+// The environment variable which names a configuration file that NewFromEnv
+// loads before applying the rest of the environment.
+const ConfigEnv = "ALERT_CONFIG"
+
+var ErrUnknownFormat = errors.New("Configuration file must be JSON or YAML")
+
+// Duration is a time.Duration which is configured as a string, such as
+// "5m" or "1h30m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// File describes an alerter as it is configured in a file, in JSON or YAML.
+// For example:
+//
+//	dsn: https://key@sentry.example.com/1
+//	environment: production
+//	component: billing
+//	dedup: {window: 5m, burst: 3}
+//	async: {buffer: 1000, retries: 3}
+//	backends:
+//	  - {type: pagerduty, routing_key: abc123}
+//	  - {type: slack, webhook_url: "https://hooks.slack.com/...", min_level: warning}
+//	  - {type: webhook, urls: ["https://example.com/alerts"]}
+type File struct {
+	DSN         string             `json:"dsn" yaml:"dsn"` // the Sentry DSN; without one, Sentry is not used
+	Environment string             `json:"environment" yaml:"environment"`
+	Release     string             `json:"release" yaml:"release"`
+	Component   string             `json:"component" yaml:"component"`
+	Hostname    string             `json:"hostname" yaml:"hostname"` // defaults to the hostname of the machine
+	Channel     ident.Ident        `json:"channel" yaml:"channel"`
+	Verbose     *bool              `json:"verbose" yaml:"verbose"` // defaults to the environment; see alert.Config.Verbose
+	MinLevel    alert.Level        `json:"min_level" yaml:"min_level"`
+	SampleRate  float64            `json:"sample_rate" yaml:"sample_rate"`
+	SampleRates map[string]float64 `json:"sample_rates" yaml:"sample_rates"` // keyed by level
+	Tags        map[string]string  `json:"tags" yaml:"tags"`
+	Dedup       Dedup              `json:"dedup" yaml:"dedup"`
+	Backoff     Backoff            `json:"backoff" yaml:"backoff"`
+	Digest      Digest             `json:"digest" yaml:"digest"`
+	Async       Async              `json:"async" yaml:"async"`
+	Backends    []Backend          `json:"backends" yaml:"backends"`
+}
+
+// Dedup configures alert.Dedup.
+type Dedup struct {
+	Window  Duration `json:"window" yaml:"window"`
+	Burst   int      `json:"burst" yaml:"burst"`
+	Summary Duration `json:"summary" yaml:"summary"`
+}
+
+// Digest configures alert.Digest.
+type Digest struct {
+	Interval Duration    `json:"interval" yaml:"interval"`
+	MaxLevel alert.Level `json:"max_level" yaml:"max_level"`
+}
+
+// Backoff configures alert.Backoff.
+type Backoff struct {
+	Initial Duration `json:"initial" yaml:"initial"`
+	Max     Duration `json:"max" yaml:"max"`
+}
+
+// Async configures alert.Async.
+type Async struct {
+	Buffer  int      `json:"buffer" yaml:"buffer"`
+	Block   bool     `json:"block" yaml:"block"`
+	Workers int      `json:"workers" yaml:"workers"`
+	Retries int      `json:"retries" yaml:"retries"`
+	Backoff Duration `json:"backoff" yaml:"backoff"`
+}
+
+// Backend configures a backend of the specified type: "slack", "pagerduty",
+// "webhook", "email", or "ping" (see alert.PingURL). Only the settings of
+// its type are used. A backend with a channel receives only the alerts for
+// that channel; see alert.Route.
+type Backend struct {
+	Type     string      `json:"type" yaml:"type"`
+	Channel  ident.Ident `json:"channel" yaml:"channel"`
+	MinLevel alert.Level `json:"min_level" yaml:"min_level"`
+
+	URL        string   `json:"url" yaml:"url"`                 // pagerduty, ping
+	URLs       []string `json:"urls" yaml:"urls"`               // webhook
+	Secret     string   `json:"secret" yaml:"secret"`           // webhook
+	WebhookURL string   `json:"webhook_url" yaml:"webhook_url"` // slack
+	Token      string   `json:"token" yaml:"token"`             // slack
+	To         []string `json:"to" yaml:"to"`                   // email
+	RoutingKey string   `json:"routing_key" yaml:"routing_key"` // pagerduty
+
+	SlackChannel string `json:"slack_channel" yaml:"slack_channel"` // slack
+
+	Addr     string   `json:"addr" yaml:"addr"`         // email
+	From     string   `json:"from" yaml:"from"`         // email
+	Username string   `json:"username" yaml:"username"` // email, slack
+	Password string   `json:"password" yaml:"password"` // email
+	Digest   Duration `json:"digest" yaml:"digest"`     // email
+}
+
+// Load loads a configuration file, which is decoded as JSON or YAML
+// according to its extension.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, f)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, f)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// NewFromConfig creates an alerter from a configuration file. See Load.
+func NewFromConfig(path string) (*alert.Alerter, error) {
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.New()
+}
+
+// NewFromEnv creates an alerter configured by the environment. If ALERT_CONFIG
+// names a configuration file it is loaded first, and the following variables
+// override its settings:
+//
+//	SENTRY_DSN, SENTRY_ENVIRONMENT, SENTRY_RELEASE
+//	ALERT_COMPONENT, ALERT_HOSTNAME, ALERT_VERBOSE, ALERT_MIN_LEVEL, ALERT_SAMPLE_RATE
+//	ALERT_TAGS            comma-separated key=value pairs
+//	ALERT_DEDUP_WINDOW    a duration, e.g., "5m"
+//	ALERT_SLACK_WEBHOOK   adds a Slack backend
+//	ALERT_PAGERDUTY_KEY   adds a PagerDuty backend
+//	ALERT_WEBHOOK_URLS    adds a webhook backend; comma-separated
+func NewFromEnv() (*alert.Alerter, error) {
+	f, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return f.New()
+}
+
+// FromEnv produces the configuration described by the environment; see
+// NewFromEnv.
+func FromEnv() (*File, error) {
+	f := &File{}
+	if path := os.Getenv(ConfigEnv); path != "" {
+		var err error
+		if f, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+	env := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	env("SENTRY_DSN", &f.DSN)
+	env("SENTRY_ENVIRONMENT", &f.Environment)
+	env("SENTRY_RELEASE", &f.Release)
+	env("ALERT_COMPONENT", &f.Component)
+	env("ALERT_HOSTNAME", &f.Hostname)
+	if v := os.Getenv("ALERT_MIN_LEVEL"); v != "" {
+		switch l := alert.Level(v); l {
+		case alert.LevelDebug, alert.LevelInfo, alert.LevelWarning, alert.LevelError, alert.LevelFatal:
+			f.MinLevel = l
+		default:
+			return nil, fmt.Errorf("Invalid ALERT_MIN_LEVEL: %q", v)
+		}
+	}
+	if v := os.Getenv("ALERT_VERBOSE"); v != "" {
+		f.Verbose = alert.Bool(v == "true" || v == "1")
+	}
+	if v := os.Getenv("ALERT_SAMPLE_RATE"); v != "" {
+		if _, err := fmt.Sscan(v, &f.SampleRate); err != nil {
+			return nil, fmt.Errorf("Invalid ALERT_SAMPLE_RATE: %w", err)
+		}
+	}
+	if v := os.Getenv("ALERT_TAGS"); v != "" {
+		if f.Tags == nil {
+			f.Tags = make(map[string]string)
+		}
+		for _, e := range strings.Split(v, ",") {
+			k, v, ok := strings.Cut(e, "=")
+			if k = strings.TrimSpace(k); !ok || k == "" {
+				return nil, fmt.Errorf("Invalid ALERT_TAGS entry, expected key=value: %q", e)
+			}
+			f.Tags[k] = strings.TrimSpace(v)
+		}
+	}
+	if v := os.Getenv("ALERT_DEDUP_WINDOW"); v != "" {
+		if err := f.Dedup.Window.UnmarshalText([]byte(v)); err != nil {
+			return nil, fmt.Errorf("Invalid ALERT_DEDUP_WINDOW: %w", err)
+		}
+	}
+	if v := os.Getenv("ALERT_SLACK_WEBHOOK"); v != "" {
+		f.Backends = append(f.Backends, Backend{Type: "slack", WebhookURL: v})
+	}
+	if v := os.Getenv("ALERT_PAGERDUTY_KEY"); v != "" {
+		f.Backends = append(f.Backends, Backend{Type: "pagerduty", RoutingKey: v})
+	}
+	if v := os.Getenv("ALERT_WEBHOOK_URLS"); v != "" {
+		f.Backends = append(f.Backends, Backend{Type: "webhook", URLs: strings.Split(v, ",")})
+	}
+	return f, nil
+}
+
+// New creates the alerter the configuration describes. Backends which must be
+// closed, such as email digests, are closed with the alerter, once the
+// alerts queued for them have been delivered; see alert.Alerter.AfterClose.
+func (f *File) New() (*alert.Alerter, error) {
+	conf, err := f.Config()
+	if err != nil {
+		return nil, err
+	}
+	a, err := alert.New(conf)
+	if err != nil {
+		return nil, err
+	}
+	backends := conf.Backends
+	for _, r := range conf.Routes {
+		backends = append(backends, r.Backends...)
+	}
+	for _, b := range backends {
+		if c, ok := b.(interface{ Close() error }); ok {
+			a.AfterClose(func() { c.Close() })
+		}
+	}
+	return a, nil
+}
+
+// Config produces the alerter configuration the file describes, which may be
+// adjusted before the alerter is created, e.g., to provide a logger.
+func (f *File) Config() (alert.Config, error) {
+	conf := alert.Config{
+		Channel:     f.Channel,
+		Component:   f.Component,
+		Hostname:    f.Hostname,
+		Environment: f.Environment,
+		Release:     f.Release,
+		Verbose:     f.Verbose,
+		MinLevel:    f.MinLevel,
+		SampleRate:  f.SampleRate,
+		Dedup:       alert.Dedup{Window: time.Duration(f.Dedup.Window), Burst: f.Dedup.Burst, Summary: time.Duration(f.Dedup.Summary)},
+		Backoff:     alert.Backoff{Initial: time.Duration(f.Backoff.Initial), Max: time.Duration(f.Backoff.Max)},
+		Digest:      alert.Digest{Interval: time.Duration(f.Digest.Interval), MaxLevel: f.Digest.MaxLevel},
+		Async: alert.Async{
+			Buffer:  f.Async.Buffer,
+			Block:   f.Async.Block,
+			Workers: f.Async.Workers,
+			Retries: f.Async.Retries,
+			Backoff: time.Duration(f.Async.Backoff),
+		},
+	}
+	if conf.Hostname == "" {
+		conf.Hostname, _ = os.Hostname()
+	}
+	if len(f.Tags) > 0 {
+		conf.Tags = make(alert.Tags, len(f.Tags))
+		for k, v := range f.Tags {
+			conf.Tags[k] = v
+		}
+	}
+	if len(f.SampleRates) > 0 {
+		conf.SampleRates = make(map[alert.Level]float64, len(f.SampleRates))
+		for k, v := range f.SampleRates {
+			conf.SampleRates[alert.Level(k)] = v
+		}
+	}
+	if f.DSN != "" {
+		client, err := sentry.NewClient(sentry.ClientOptions{
+			Dsn:         f.DSN,
+			Environment: f.Environment,
+			Release:     f.Release,
+		})
+		if err != nil {
+			return alert.Config{}, fmt.Errorf("Could not create Sentry client: %w", err)
+		}
+		conf.Sentry = client
+	}
+
+	routes := make(map[ident.Ident]int)
+	for i, e := range f.Backends {
+		b, err := e.backend()
+		if err != nil {
+			return alert.Config{}, fmt.Errorf("Backend %d (%s): %w", i, e.Type, err)
+		}
+		if e.Channel.IsZero() {
+			conf.Backends = append(conf.Backends, b)
+		} else if n, ok := routes[e.Channel]; ok {
+			conf.Routes[n].Backends = append(conf.Routes[n].Backends, b)
+		} else {
+			routes[e.Channel] = len(conf.Routes)
+			conf.Routes = append(conf.Routes, alert.Route{Channel: e.Channel, Backends: []alert.Backend{b}})
+		}
+	}
+	return conf, nil
+}
+
+// backend creates the backend the configuration describes.
+func (e Backend) backend() (alert.Backend, error) {
+	switch e.Type {
+	case "slack":
+		return slack.New(slack.Config{
+			WebhookURL: e.WebhookURL,
+			Token:      e.Token,
+			Channel:    e.SlackChannel,
+			Username:   e.Username,
+			MinLevel:   e.MinLevel,
+		})
+	case "pagerduty":
+		return pagerduty.New(pagerduty.Config{
+			RoutingKey: e.RoutingKey,
+			MinLevel:   e.MinLevel,
+			URL:        e.URL,
+		})
+	case "webhook":
+		return webhook.New(webhook.Config{
+			URLs:     e.URLs,
+			Secret:   []byte(e.Secret),
+			MinLevel: e.MinLevel,
+		})
+	case "email":
+		conf := email.Config{
+			Addr:   e.Addr,
+			From:   e.From,
+			To:     e.To,
+			Digest: time.Duration(e.Digest),
+		}
+		if e.Username != "" {
+			host, _, _ := strings.Cut(e.Addr, ":")
+			conf.Auth = smtp.PlainAuth("", e.Username, e.Password, host)
+		}
+		return email.New(conf)
+	case "ping":
+		if e.URL == "" {
+			return nil, errors.New("Ping backend requires a URL")
+		}
+		return alert.PingURL(e.URL), nil
+	default:
+		return nil, fmt.Errorf("Unknown backend type: %q", e.Type)
+	}
+}
@@ -0,0 +1,227 @@
+package alertconfig
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/bww/go-ident/v1"
+)
+
+func TestVerboseFromEnv(t *testing.T) {
+	t.Setenv(ConfigEnv, "")
+	for _, e := range []struct {
+		value  string
+		expect *bool
+	}{
+		{"", nil},
+		{"true", alert.Bool(true)},
+		{"1", alert.Bool(true)},
+		{"false", alert.Bool(false)},
+	} {
+		t.Setenv("ALERT_VERBOSE", e.value)
+		f, err := FromEnv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case e.expect == nil && f.Verbose != nil:
+			t.Errorf("Expected verbose to default to the environment for %q; got %v", e.value, *f.Verbose)
+		case e.expect != nil && (f.Verbose == nil || *f.Verbose != *e.expect):
+			t.Errorf("Expected verbose %v for %q; got %v", *e.expect, e.value, f.Verbose)
+		}
+	}
+}
+
+// write writes a configuration file to a temporary directory.
+func write(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	channel := ident.New()
+	for _, e := range []struct {
+		name string
+		data string
+	}{
+		{"alert.json", `{
+			"environment": "production",
+			"min_level": "warning",
+			"tags": {"team": "billing"},
+			"dedup": {"window": "5m", "burst": 3},
+			"async": {"buffer": 100, "backoff": "1h30m"},
+			"backends": [{"type": "ping", "url": "https://example.com/ping", "channel": "` + channel.String() + `"}]
+		}`},
+		{"alert.yaml", `
+environment: production
+min_level: warning
+tags: {team: billing}
+dedup: {window: 5m, burst: 3}
+async: {buffer: 100, backoff: 1h30m}
+backends:
+  - {type: ping, url: "https://example.com/ping", channel: ` + channel.String() + `}
+`},
+	} {
+		f, err := Load(write(t, e.name, e.data))
+		if err != nil {
+			t.Fatalf("%s: %v", e.name, err)
+		}
+		if f.Environment != "production" || f.MinLevel != alert.LevelWarning || f.Tags["team"] != "billing" {
+			t.Errorf("%s: Unexpected settings: %+v", e.name, f)
+		}
+		if v := time.Duration(f.Dedup.Window); v != 5*time.Minute || f.Dedup.Burst != 3 {
+			t.Errorf("%s: Unexpected dedup: %v, %d", e.name, v, f.Dedup.Burst)
+		}
+		if v := time.Duration(f.Async.Backoff); v != 90*time.Minute || f.Async.Buffer != 100 {
+			t.Errorf("%s: Unexpected async: %v, %d", e.name, v, f.Async.Buffer)
+		}
+		if len(f.Backends) != 1 || f.Backends[0].Type != "ping" || f.Backends[0].Channel != channel {
+			t.Errorf("%s: Unexpected backends: %+v", e.name, f.Backends)
+		}
+	}
+
+	if _, err := Load(write(t, "alert.toml", "")); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected %v; got %v", ErrUnknownFormat, err)
+	}
+	if _, err := Load(write(t, "alert.json", `{"dedup": {"window": "soon"}}`)); err == nil {
+		t.Error("Expected an invalid duration to fail")
+	}
+}
+
+func TestConfigRoutes(t *testing.T) {
+	db, payments := ident.New(), ident.New()
+	ping := func(c ident.Ident) Backend {
+		return Backend{Type: "ping", URL: "https://example.com/ping", Channel: c}
+	}
+	f := &File{Hostname: "test", Backends: []Backend{ping(db), ping(ident.Ident{}), ping(payments), ping(db)}}
+	conf, err := f.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Backends) != 1 {
+		t.Errorf("Expected backends without a channel to receive every alert; got %d", len(conf.Backends))
+	}
+	if len(conf.Routes) != 2 {
+		t.Fatalf("Expected a route per channel; got %d", len(conf.Routes))
+	}
+	if r := conf.Routes[0]; r.Channel != db || len(r.Backends) != 2 {
+		t.Errorf("Expected the backends for a channel to be grouped into its route; got %v with %d", r.Channel, len(r.Backends))
+	}
+	if r := conf.Routes[1]; r.Channel != payments || len(r.Backends) != 1 {
+		t.Errorf("Unexpected route: %v with %d", r.Channel, len(r.Backends))
+	}
+}
+
+func TestBackend(t *testing.T) {
+	for _, e := range []struct {
+		conf   Backend
+		expect string
+	}{
+		{Backend{Type: "slack", WebhookURL: "https://hooks.slack.com/x"}, "*slack.Backend"},
+		{Backend{Type: "slack", Token: "xoxb-token", SlackChannel: "#alerts"}, "*slack.Backend"},
+		{Backend{Type: "pagerduty", RoutingKey: "key"}, "*pagerduty.Backend"},
+		{Backend{Type: "webhook", URLs: []string{"https://example.com/alerts"}, Secret: "secret"}, "*webhook.Backend"},
+		{Backend{Type: "email", Addr: "smtp.example.com:587", From: "alerts@example.com", Username: "user"}, "*email.Backend"},
+		{Backend{Type: "ping", URL: "https://example.com/ping"}, "alert.pingBackend"},
+	} {
+		b, err := e.conf.backend()
+		if err != nil {
+			t.Errorf("%s: %v", e.conf.Type, err)
+			continue
+		}
+		if v := fmt.Sprintf("%T", b); v != e.expect {
+			t.Errorf("%s: Expected %s; got %s", e.conf.Type, e.expect, v)
+		}
+	}
+
+	for _, e := range []Backend{
+		{Type: "slack"},
+		{Type: "pagerduty"},
+		{Type: "webhook"},
+		{Type: "email"},
+		{Type: "ping"},
+		{Type: "carrier-pigeon"},
+	} {
+		if _, err := e.backend(); err == nil {
+			t.Errorf("%s: Expected an incomplete backend to fail", e.Type)
+		}
+	}
+
+	f := &File{Hostname: "test", Backends: []Backend{{Type: "carrier-pigeon"}}}
+	if _, err := f.Config(); err == nil || err.Error() != `Backend 0 (carrier-pigeon): Unknown backend type: "carrier-pigeon"` {
+		t.Errorf("Expected an unknown backend type to fail; got %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(ConfigEnv, write(t, "alert.yaml", `
+environment: staging
+component: billing
+tags: {team: billing}
+backends:
+  - {type: ping, url: "https://example.com/ping"}
+`))
+	t.Setenv("SENTRY_ENVIRONMENT", "production")
+	t.Setenv("ALERT_MIN_LEVEL", "error")
+	t.Setenv("ALERT_SAMPLE_RATE", "0.5")
+	t.Setenv("ALERT_TAGS", "region = us-east-1,tier=")
+	t.Setenv("ALERT_DEDUP_WINDOW", "10m")
+	t.Setenv("ALERT_SLACK_WEBHOOK", "https://hooks.slack.com/x")
+	t.Setenv("ALERT_PAGERDUTY_KEY", "key")
+	t.Setenv("ALERT_WEBHOOK_URLS", "https://example.com/a,https://example.com/b")
+
+	f, err := FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Environment != "production" || f.Component != "billing" {
+		t.Errorf("Expected the environment to override the file; got %q, %q", f.Environment, f.Component)
+	}
+	if f.MinLevel != alert.LevelError || f.SampleRate != 0.5 || time.Duration(f.Dedup.Window) != 10*time.Minute {
+		t.Errorf("Unexpected settings: %v, %v, %v", f.MinLevel, f.SampleRate, time.Duration(f.Dedup.Window))
+	}
+	if want := map[string]string{"team": "billing", "region": "us-east-1", "tier": ""}; !maps.Equal(f.Tags, want) {
+		t.Errorf("Expected tags %v; got %v", want, f.Tags)
+	}
+	var types []string
+	for _, b := range f.Backends {
+		types = append(types, b.Type)
+	}
+	if want := []string{"ping", "slack", "pagerduty", "webhook"}; !slices.Equal(types, want) {
+		t.Errorf("Expected backends %v; got %v", want, types)
+	}
+	if v := f.Backends[3].URLs; len(v) != 2 {
+		t.Errorf("Expected the webhook URLs to be split; got %v", v)
+	}
+}
+
+func TestFromEnvInvalid(t *testing.T) {
+	t.Setenv(ConfigEnv, "")
+	for _, e := range []struct {
+		key, value string
+	}{
+		{"ALERT_MIN_LEVEL", "severe"},
+		{"ALERT_TAGS", "team=billing,region"},
+		{"ALERT_TAGS", "=billing"},
+		{"ALERT_SAMPLE_RATE", "half"},
+		{"ALERT_DEDUP_WINDOW", "soon"},
+	} {
+		t.Run(e.key, func(t *testing.T) {
+			t.Setenv(e.key, e.value)
+			if _, err := FromEnv(); err == nil {
+				t.Errorf("Expected %s=%q to be rejected", e.key, e.value)
+			}
+		})
+	}
+}
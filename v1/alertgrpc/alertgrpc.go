@@ -0,0 +1,110 @@
+// Package alertgrpc provides gRPC server interceptors which report the panics
+// and failures of the calls a server handles via an alerter. It is separate
+// from package alert so that the alerter itself does not depend on the gRPC
+// runtime.
+package alertgrpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bww/go-alert/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Config configures the interceptors.
+type Config struct {
+	// Report determines whether a call which failed with the specified code
+	// is reported. By default, calls which failed with a code that describes
+	// a problem with the service rather than the request are reported:
+	// Unknown, DeadlineExceeded, Unimplemented, Internal, Unavailable, and
+	// DataLoss. Panics are always reported.
+	Report func(codes.Code) bool
+	// Options are applied to every alert reported. Extra provided this way
+	// replaces the request metadata.
+	Options []alert.Option
+}
+
+// DefaultReport reports calls which failed with a code that describes a
+// problem with the service rather than the request.
+func DefaultReport(c codes.Code) bool {
+	switch c {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnaryServerInterceptor produces an interceptor which reports the panics
+// and failures of unary calls via the alerter. A panic is answered with the
+// status Internal. Alerts are tagged with the method called as "grpc_method"
+// and the address of the peer as "grpc_peer", and the request metadata is
+// attached as the extra "grpc_metadata", which is scrubbed as any extra is.
+func UnaryServerInterceptor(a *alert.Alerter, conf Config) grpc.UnaryServerInterceptor {
+	return func(cxt context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				a.ReportPanic(r, conf.options(cxt, info.FullMethod)...)
+				err = status.Error(codes.Internal, "Internal error")
+			}
+		}()
+		rsp, err = handler(cxt, req)
+		conf.check(a, cxt, info.FullMethod, err)
+		return rsp, err
+	}
+}
+
+// StreamServerInterceptor produces an interceptor which reports the panics
+// and failures of streaming calls via the alerter, in the same manner as
+// UnaryServerInterceptor.
+func StreamServerInterceptor(a *alert.Alerter, conf Config) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		cxt := ss.Context()
+		defer func() {
+			if r := recover(); r != nil {
+				a.ReportPanic(r, conf.options(cxt, info.FullMethod)...)
+				err = status.Error(codes.Internal, "Internal error")
+			}
+		}()
+		err = handler(srv, ss)
+		conf.check(a, cxt, info.FullMethod, err)
+		return err
+	}
+}
+
+// check reports the error a call failed with, if it should be reported.
+func (c Config) check(a *alert.Alerter, cxt context.Context, method string, err error) {
+	if err == nil {
+		return
+	}
+	report := c.Report
+	if report == nil {
+		report = DefaultReport
+	}
+	if report(status.Code(err)) {
+		a.Error(err, c.options(cxt, method)...)
+	}
+}
+
+// options produces the options an alert for a call is reported with.
+func (c Config) options(cxt context.Context, method string) []alert.Option {
+	tags := alert.Tags{"grpc_method": method}
+	if p, ok := peer.FromContext(cxt); ok && p.Addr != nil {
+		tags["grpc_peer"] = p.Addr.String()
+	}
+	var opts []alert.Option
+	if md, ok := metadata.FromIncomingContext(cxt); ok && md.Len() > 0 {
+		extra := make(map[string]interface{}, md.Len())
+		for k, v := range md {
+			extra[k] = strings.Join(v, ", ")
+		}
+		opts = append(opts, alert.WithExtra(map[string]interface{}{"grpc_metadata": extra}))
+	}
+	opts = append(opts, c.Options...)
+	return append(opts, alert.WithContext(cxt), alert.WithTagsAny(tags))
+}
@@ -0,0 +1,178 @@
+package alertgrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/bww/go-alert/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const method = "/orders.Orders/Create"
+
+// backend records the alerts delivered to it.
+type backend struct {
+	sync.Mutex
+	events []*alert.Event
+}
+
+func (b *backend) Capture(e *alert.Event) error {
+	b.Lock()
+	defer b.Unlock()
+	b.events = append(b.events, e)
+	return nil
+}
+
+func (b *backend) Events() []*alert.Event {
+	b.Lock()
+	defer b.Unlock()
+	return append([]*alert.Event(nil), b.events...)
+}
+
+func newAlerter(t *testing.T) (*alert.Alerter, *backend) {
+	t.Helper()
+	b := &backend{}
+	a, err := alert.New(alert.Config{Backends: []alert.Backend{b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a, b
+}
+
+// incoming produces the context of a call from a peer with metadata.
+func incoming() context.Context {
+	cxt := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "abc", "authorization", "Bearer secret"))
+	return peer.NewContext(cxt, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+}
+
+// failing produces a handler which fails with the code.
+func failing(c codes.Code) grpc.UnaryHandler {
+	return func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(c, "Failed")
+	}
+}
+
+func TestUnary(t *testing.T) {
+	a, b := newAlerter(t)
+	intercept := UnaryServerInterceptor(a, Config{})
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	rsp, err := intercept(incoming(), "req", info, func(cxt context.Context, req interface{}) (interface{}, error) {
+		return "rsp", nil
+	})
+	if err != nil || rsp != "rsp" {
+		t.Fatalf("Expected the response of the handler; got %v, %v", rsp, err)
+	}
+	if n := len(b.Events()); n != 0 {
+		t.Fatalf("Expected a successful call not to be reported; got %d alerts", n)
+	}
+
+	if _, err := intercept(incoming(), "req", info, failing(codes.Unavailable)); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected the error of the handler; got %v", err)
+	}
+	events := b.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected the failure to be reported; got %d alerts", len(events))
+	}
+	e := events[0]
+	if v := e.Tags["grpc_method"]; v != method {
+		t.Errorf("Expected grpc_method %s; got %q", method, v)
+	}
+	if v := e.Tags["grpc_peer"]; v != "10.0.0.1:5000" {
+		t.Errorf("Expected grpc_peer to be the address of the peer; got %q", v)
+	}
+	if v := e.Tags["grpc_code"]; v != "Unavailable" {
+		t.Errorf("Expected grpc_code Unavailable; got %q", v)
+	}
+	md, _ := e.Extra["grpc_metadata"].(map[string]interface{})
+	if md["x-request-id"] != "abc" || md["authorization"] != "[redacted]" {
+		t.Errorf("Expected the metadata to be attached and scrubbed; got %v", md)
+	}
+}
+
+func TestReport(t *testing.T) {
+	for _, e := range []struct {
+		conf   Config
+		code   codes.Code
+		expect bool
+	}{
+		{Config{}, codes.Internal, true},
+		{Config{}, codes.DeadlineExceeded, true},
+		{Config{}, codes.NotFound, false},
+		{Config{}, codes.InvalidArgument, false},
+		{Config{Report: func(c codes.Code) bool { return c == codes.NotFound }}, codes.NotFound, true},
+		{Config{Report: func(c codes.Code) bool { return c == codes.NotFound }}, codes.Internal, false},
+	} {
+		a, b := newAlerter(t)
+		intercept := UnaryServerInterceptor(a, e.conf)
+		intercept(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: method}, failing(e.code))
+		if v := len(b.Events()) == 1; v != e.expect {
+			t.Errorf("%v: Expected reported %v; got %v", e.code, e.expect, v)
+		}
+	}
+}
+
+func TestUnaryPanic(t *testing.T) {
+	a, b := newAlerter(t)
+	intercept := UnaryServerInterceptor(a, Config{Report: func(codes.Code) bool { return false }})
+	_, err := intercept(incoming(), "req", &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, interface{}) (interface{}, error) {
+		panic("Nil order")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Expected a panic to be answered with Internal; got %v", err)
+	}
+	events := b.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected a panic to be reported regardless of the filter; got %d alerts", len(events))
+	}
+	if v := events[0].Tags["grpc_method"]; v != method {
+		t.Errorf("Expected grpc_method %s; got %q", method, v)
+	}
+}
+
+// stream is a server stream with a context.
+type stream struct {
+	grpc.ServerStream
+	cxt context.Context
+}
+
+func (s stream) Context() context.Context {
+	return s.cxt
+}
+
+func TestStream(t *testing.T) {
+	a, b := newAlerter(t)
+	intercept := StreamServerInterceptor(a, Config{Options: []alert.Option{alert.WithTags(alert.Tags{"service": "orders"})}})
+	info := &grpc.StreamServerInfo{FullMethod: method, IsServerStream: true}
+	ss := stream{cxt: incoming()}
+
+	fail := status.Error(codes.Internal, "Failed")
+	if err := intercept(nil, ss, info, func(interface{}, grpc.ServerStream) error { return fail }); !errors.Is(err, fail) {
+		t.Errorf("Expected the error of the handler; got %v", err)
+	}
+	if err := intercept(nil, ss, info, func(interface{}, grpc.ServerStream) error { return status.Error(codes.Canceled, "Canceled") }); status.Code(err) != codes.Canceled {
+		t.Errorf("Expected the error of the handler; got %v", err)
+	}
+	err := intercept(nil, ss, info, func(interface{}, grpc.ServerStream) error { panic("Nil order") })
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Expected a panic to be answered with Internal; got %v", err)
+	}
+
+	events := b.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected the failure and the panic to be reported; got %d alerts", len(events))
+	}
+	for _, e := range events {
+		if e.Tags["grpc_method"] != method || e.Tags["grpc_peer"] != "10.0.0.1:5000" || e.Tags["service"] != "orders" {
+			t.Errorf("Unexpected tags: %v", e.Tags)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package alerttest provides utilities for testing code which raises alerts.
+//
+// A Recorder is an alert.Client which records the events it captures rather
+// than delivering them. Configure an alerter to use it as a tee client and
+// assert on what was captured:
+//
+//	rec := alerttest.NewRecorder()
+//	a, _ := alert.New(alert.Config{Tee: []alert.Client{rec}})
+//	a.Error(err, alert.WithTags(alert.Tags{"tenant": "acme"}))
+//	alerttest.AssertCaptured(t, rec, alerttest.ErrorIs(err), alerttest.HasTag("tenant"))
+//
+// A Recorder is also an alert.Backend; New creates an alerter which delivers
+// only to a recorder, and directs the package-level functions to it:
+//
+//	_, rec := alerttest.New(t)
+//	alert.Error(err)
+//	alerttest.AssertReported(t, rec, alerttest.ErrorIs(err))
+//
+// AssertReported and Recorded are equivalent to AssertCaptured and Captured,
+// and read better in tests which raise alerts rather than capture events.
+//
+// A Recorder is also an alert.Notifier, which records the alerts raised via
+// it in the form an alerter with the default configuration reports them,
+// without involving Sentry or any other destination. Code which raises alerts
+// via the package-level functions can be tested by directing them to one:
+//
+//	rec := alerttest.NewRecorder()
+//	defer alert.SetDefault(rec)()
+package alerttest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+// Captured is an event captured by a Recorder.
+type Captured struct {
+	Event *sentry.Event
+	Err   error // the error the event was produced from, if known
+
+	// Context is the context resolved from the options an alert was raised
+	// with, for alerts raised via the Recorder as an alert.Notifier.
+	Context *alert.Context
+	// Alert is the alert as it was delivered, for alerts captured by the
+	// Recorder as an alert.Backend.
+	Alert *alert.Event
+}
+
+// Recorder records the events it captures.
+type Recorder struct {
+	mu       sync.Mutex
+	events   []Captured
+	notifier notifier
+}
+
+// NewRecorder creates a new, empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// CaptureEvent records the event after applying the scope to it, as a real
+// client would.
+func (r *Recorder) CaptureEvent(event *sentry.Event, hint *sentry.EventHint, scope sentry.EventModifier) *sentry.EventID {
+	if scope != nil {
+		event = scope.ApplyToEvent(event, hint)
+		if event == nil {
+			return nil
+		}
+	}
+	if event.EventID == "" {
+		event.EventID = newEventID()
+	}
+	c := Captured{Event: event}
+	if hint != nil {
+		c.Err = hint.OriginalException
+	}
+	r.mu.Lock()
+	r.events = append(r.events, c)
+	r.mu.Unlock()
+	id := event.EventID
+	return &id
+}
+
+// Flush returns immediately; recorded events are never buffered.
+func (r *Recorder) Flush(timeout time.Duration) bool {
+	return true
+}
+
+// Captured produces the events recorded so far, in the order they were
+// captured.
+func (r *Recorder) Captured() []Captured {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Captured(nil), r.events...)
+}
+
+// Recorded produces the events recorded so far, in the order they were
+// captured. It is equivalent to Captured.
+func (r *Recorder) Recorded() []Captured {
+	return r.Captured()
+}
+
+// Reset discards all recorded events.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+func newEventID() sentry.EventID {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return sentry.EventID(hex.EncodeToString(b))
+}
@@ -0,0 +1,56 @@
+package alerttest
+
+import (
+	"testing"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+var _ alert.Backend = (*Recorder)(nil)
+
+// Capture records an alert delivered to the recorder as a backend. The event
+// recorded for it carries what a backend receives: its identifier, level,
+// message, tags, extra, fingerprint, and exceptions.
+func (r *Recorder) Capture(e *alert.Event) error {
+	event := sentry.NewEvent()
+	event.EventID = sentry.EventID(e.ID)
+	event.Timestamp = e.Time
+	event.Level = e.Level
+	event.Message = e.Message
+	event.Tags = e.Tags
+	event.Extra = e.Extra
+	event.Fingerprint = e.Fingerprint
+	event.Exception = e.Exception
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, Captured{Event: event, Err: e.Err, Alert: e})
+	return nil
+}
+
+// New creates an alerter which delivers the alerts it raises only to a new
+// recorder, as a backend, and directs the package-level functions which raise
+// alerts to it for the duration of the test. Unlike a Recorder used as an
+// alert.Notifier, the alerter processes alerts as it would in production, so
+// its configuration applies; the configuration provided, if any, is used
+// with the recorder added to its backends. The alerter is closed when the
+// test completes.
+func New(t testing.TB, conf ...alert.Config) (*alert.Alerter, *Recorder) {
+	t.Helper()
+	var c alert.Config
+	if len(conf) > 0 {
+		c = conf[0]
+	}
+	rec := NewRecorder()
+	c.Backends = append(append([]alert.Backend(nil), c.Backends...), rec)
+	a, err := alert.New(c)
+	if err != nil {
+		t.Fatalf("alerttest: could not create alerter: %v", err)
+	}
+	restore := alert.SetDefault(a)
+	t.Cleanup(func() {
+		restore()
+		a.Close()
+	})
+	return a, rec
+}
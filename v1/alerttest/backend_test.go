@@ -0,0 +1,38 @@
+package alerttest
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("recorded", func(t *testing.T) {
+		_, rec := New(t, alert.Config{Ignore: []alert.Ignore{alert.IgnoreMessage(regexp.MustCompile("^Canceled"))}})
+
+		errSync := errors.New("Sync failed")
+		alert.Error(errSync, alert.WithRef("sync-1"), alert.WithTags(alert.Tags{"tenant": "acme"}))
+		alert.Error(errors.New("Canceled by the client"))
+
+		c := AssertReported(t, rec, ErrorIs(errSync), Level(sentry.LevelError), Tag("tenant", "acme"))
+		if c.Alert == nil || c.Alert.Ref != "sync-1" {
+			t.Errorf("Expected the alert delivered to the backend; got %+v", c.Alert)
+		}
+		// the configuration applies, as it would in production
+		if n := len(rec.Recorded()); n != 1 {
+			t.Errorf("Expected the ignored error not to be recorded; got %d events", n)
+		}
+	})
+
+	// the package-level functions are restored once the test completes
+	rec := NewRecorder()
+	defer alert.SetDefault(rec)()
+	t.Run("restored", func(t *testing.T) {
+		New(t)
+	})
+	alert.Error(errors.New("Failed"))
+	AssertCaptured(t, rec, Level(sentry.LevelError))
+}
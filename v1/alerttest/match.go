@@ -0,0 +1,119 @@
+package alerttest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Matcher determines whether a captured event matches some criteria.
+type Matcher func(c Captured) bool
+
+// ErrorIs matches events produced from an error for which errors.Is reports
+// the target.
+func ErrorIs(target error) Matcher {
+	return func(c Captured) bool {
+		return c.Err != nil && errors.Is(c.Err, target)
+	}
+}
+
+// Level matches events reported at the specified level.
+func Level(lvl sentry.Level) Matcher {
+	return func(c Captured) bool {
+		return c.Event.Level == lvl
+	}
+}
+
+// HasTag matches events which carry the tag, regardless of its value.
+func HasTag(key string) Matcher {
+	return func(c Captured) bool {
+		_, ok := c.Event.Tags[key]
+		return ok
+	}
+}
+
+// Tag matches events which carry the tag with the specified value.
+func Tag(key, value string) Matcher {
+	return func(c Captured) bool {
+		v, ok := c.Event.Tags[key]
+		return ok && v == value
+	}
+}
+
+// Fingerprint matches events with exactly the specified fingerprint.
+func Fingerprint(parts ...string) Matcher {
+	return func(c Captured) bool {
+		return slices.Equal(c.Event.Fingerprint, parts)
+	}
+}
+
+// All matches events which match every one of the provided matchers.
+func All(m ...Matcher) Matcher {
+	return func(c Captured) bool {
+		for _, e := range m {
+			if !e(c) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Find produces the recorded events which match every one of the provided
+// matchers.
+func Find(rec *Recorder, m ...Matcher) []Captured {
+	match := All(m...)
+	var res []Captured
+	for _, e := range rec.Captured() {
+		if match(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// AssertCaptured fails the test unless exactly one recorded event matches
+// every one of the provided matchers, and produces that event.
+func AssertCaptured(t testing.TB, rec *Recorder, m ...Matcher) Captured {
+	t.Helper()
+	res := Find(rec, m...)
+	if len(res) != 1 {
+		t.Fatalf("alerttest: expected exactly one matching event, found %d of %d captured%s", len(res), len(rec.Captured()), describe(rec))
+		return Captured{}
+	}
+	return res[0]
+}
+
+// AssertReported fails the test unless exactly one recorded alert matches
+// every one of the provided matchers, and produces it. It is equivalent to
+// AssertCaptured.
+func AssertReported(t testing.TB, rec *Recorder, m ...Matcher) Captured {
+	t.Helper()
+	return AssertCaptured(t, rec, m...)
+}
+
+// AssertNotCaptured fails the test if any recorded event matches every one
+// of the provided matchers.
+func AssertNotCaptured(t testing.TB, rec *Recorder, m ...Matcher) {
+	t.Helper()
+	if res := Find(rec, m...); len(res) > 0 {
+		t.Fatalf("alerttest: expected no matching events, found %d%s", len(res), describe(rec))
+	}
+}
+
+func describe(rec *Recorder) string {
+	var s string
+	for _, e := range rec.Captured() {
+		var msg string
+		if n := len(e.Event.Exception); n > 0 {
+			msg = e.Event.Exception[n-1].Value
+		} else {
+			msg = e.Event.Message
+		}
+		s += fmt.Sprintf("\n  [%s] %s %v", e.Event.Level, msg, e.Event.Tags)
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package alerttest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+// fakeT records the failure of an assertion rather than failing the test.
+type fakeT struct {
+	testing.TB
+	failure string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Fatalf(f string, args ...interface{}) {
+	t.failure = fmt.Sprintf(f, args...)
+}
+
+var errPayment = errors.New("Payment declined")
+
+func newRecorded(t *testing.T) *Recorder {
+	t.Helper()
+	rec := NewRecorder()
+	a, err := alert.New(alert.Config{Tee: []alert.Client{rec}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	a.Error(fmt.Errorf("Could not charge: %w", errPayment), alert.WithTags(alert.Tags{"tenant": "acme"}), alert.WithFingerprint("payments", "declined"))
+	a.Warning(errors.New("Retrying"), alert.WithTags(alert.Tags{"attempt": 2}))
+	return rec
+}
+
+func TestMatchers(t *testing.T) {
+	rec := newRecorded(t)
+
+	tests := []struct {
+		name   string
+		match  Matcher
+		expect int
+	}{
+		{"error", ErrorIs(errPayment), 1},
+		{"level", Level(sentry.LevelWarning), 1},
+		{"has tag", HasTag("tenant"), 1},
+		{"tag", Tag("attempt", "2"), 1},
+		{"tag mismatch", Tag("tenant", "other"), 0},
+		{"fingerprint", Fingerprint("payments", "declined"), 1},
+		{"fingerprint prefix", Fingerprint("payments"), 0},
+		{"all", All(ErrorIs(errPayment), Level(sentry.LevelError), Tag("tenant", "acme")), 1},
+		{"all mismatch", All(ErrorIs(errPayment), Level(sentry.LevelWarning)), 0},
+		{"none", All(), 2},
+	}
+	for _, e := range tests {
+		if n := len(Find(rec, e.match)); n != e.expect {
+			t.Errorf("%s: expected %d matching events; got %d", e.name, e.expect, n)
+		}
+	}
+}
+
+func TestAssertCaptured(t *testing.T) {
+	rec := newRecorded(t)
+
+	c := AssertCaptured(t, rec, ErrorIs(errPayment))
+	if !errors.Is(c.Err, errPayment) {
+		t.Errorf("Expected the matching event; got %v", c.Err)
+	}
+
+	ft := &fakeT{}
+	AssertCaptured(ft, rec, Level(sentry.LevelFatal))
+	if !strings.Contains(ft.failure, "found 0 of 2 captured") || !strings.Contains(ft.failure, "Retrying") {
+		t.Errorf("Expected the assertion to fail, describing what was captured; got %q", ft.failure)
+	}
+	ft = &fakeT{}
+	AssertCaptured(ft, rec)
+	if !strings.Contains(ft.failure, "found 2 of 2") {
+		t.Errorf("Expected the assertion to fail when several events match; got %q", ft.failure)
+	}
+}
+
+func TestAssertNotCaptured(t *testing.T) {
+	rec := newRecorded(t)
+
+	AssertNotCaptured(t, rec, Level(sentry.LevelFatal))
+
+	ft := &fakeT{}
+	AssertNotCaptured(ft, rec, HasTag("tenant"))
+	if !strings.Contains(ft.failure, "found 1") {
+		t.Errorf("Expected the assertion to fail; got %q", ft.failure)
+	}
+}
+
+func TestAssertReported(t *testing.T) {
+	rec := newRecorded(t)
+	if c := AssertReported(t, rec, Level(sentry.LevelWarning)); c.Event.Message != "Retrying" {
+		t.Errorf("Expected the matching alert; got %q", c.Event.Message)
+	}
+	if n := len(rec.Recorded()); n != 2 {
+		t.Errorf("Expected the alerts recorded; got %d", n)
+	}
+
+	ft := &fakeT{}
+	AssertReported(ft, rec, ErrorIs(errors.New("Unrelated")))
+	if !strings.Contains(ft.failure, "found 0 of 2 captured") {
+		t.Errorf("Expected the assertion to fail; got %q", ft.failure)
+	}
+}
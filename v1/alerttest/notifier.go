@@ -0,0 +1,104 @@
+package alerttest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+var _ alert.Notifier = (*Recorder)(nil)
+
+func (r *Recorder) Report(lvl alert.Level, err error, opts ...alert.Option) *sentry.EventID {
+	return r.raise(false, opts, func(a *alert.Alerter) *sentry.EventID {
+		return a.Report(lvl, err, opts...)
+	})
+}
+
+func (r *Recorder) Error(err error, opts ...alert.Option) *sentry.EventID {
+	return r.raise(false, opts, func(a *alert.Alerter) *sentry.EventID {
+		return a.Error(err, opts...)
+	})
+}
+
+func (r *Recorder) Errorf(f string, args ...interface{}) {
+	r.Error(fmt.Errorf(f, args...))
+}
+
+func (r *Recorder) Warning(err error, opts ...alert.Option) *sentry.EventID {
+	return r.Report(alert.LevelWarning, err, opts...)
+}
+
+func (r *Recorder) Warningf(f string, args ...interface{}) {
+	r.Warning(fmt.Errorf(f, args...))
+}
+
+func (r *Recorder) Info(err error, opts ...alert.Option) *sentry.EventID {
+	return r.Report(alert.LevelInfo, err, opts...)
+}
+
+func (r *Recorder) Infof(f string, args ...interface{}) {
+	r.Info(fmt.Errorf(f, args...))
+}
+
+func (r *Recorder) Message(msg string, opts ...alert.Option) *sentry.EventID {
+	return r.ReportMessage(alert.LevelInfo, msg, opts...)
+}
+
+func (r *Recorder) ReportMessage(lvl alert.Level, msg string, opts ...alert.Option) *sentry.EventID {
+	return r.raise(true, opts, func(a *alert.Alerter) *sentry.EventID {
+		return a.ReportMessage(lvl, msg, opts...)
+	})
+}
+
+func (r *Recorder) Timeout(op string, elapsed time.Duration, opts ...alert.Option) {
+	r.raise(false, opts, func(a *alert.Alerter) *sentry.EventID {
+		a.Timeout(op, elapsed, opts...)
+		return nil
+	})
+}
+
+// notifier is the alerter which processes the alerts raised via a Recorder
+// as an alert.Notifier, so that they are recorded in the form an alerter
+// would report them. It delivers only to the sink, which collects the events
+// captured for the alert being raised.
+type notifier struct {
+	sync.Mutex // serializes alerts, so that events are attributed to the right one
+	once       sync.Once
+	alerter    *alert.Alerter
+	sink       *Recorder
+}
+
+// raise raises an alert via the recorder's alerter, recording the events it
+// captures with the context resolved from the options, and produces the
+// identifier of its event.
+func (r *Recorder) raise(msg bool, opts []alert.Option, fn func(a *alert.Alerter) *sentry.EventID) *sentry.EventID {
+	n := &r.notifier
+	n.once.Do(func() {
+		n.sink = NewRecorder()
+		n.alerter, _ = alert.New(alert.Config{Tee: []alert.Client{n.sink}})
+	})
+	cxt := alert.ResolveContext(opts...)
+
+	n.Lock()
+	defer n.Unlock()
+	id := fn(n.alerter)
+	events := n.sink.Captured()
+	n.sink.Reset()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range events {
+		if msg {
+			e.Err = nil
+		}
+		e.Context = &cxt
+		r.events = append(r.events, e)
+		if id == nil {
+			id = &e.Event.EventID
+		}
+	}
+	return id
+}
@@ -0,0 +1,48 @@
+package alerttest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestRecorderNotifier(t *testing.T) {
+	rec := NewRecorder()
+	defer alert.SetDefault(rec)()
+
+	errSync := errors.New("Sync failed")
+	id := alert.Error(errSync, alert.WithTags(alert.Tags{"tenant": "acme"}), alert.WithComponent("sync"))
+	alert.Warning(errors.New("Degraded"))
+	alert.Message("Deployed")
+
+	c := AssertCaptured(t, rec, ErrorIs(errSync), Level(sentry.LevelError), Tag("tenant", "acme"), Tag("component", "sync"))
+	if id == nil || *id != c.Event.EventID {
+		t.Errorf("Expected the identifier of the event recorded; got %v", id)
+	}
+	if c.Context == nil || c.Context.Component != "sync" {
+		t.Errorf("Expected the context resolved from the options; got %+v", c.Context)
+	}
+
+	// alerts are recorded in the form an alerter reports them
+	w := AssertCaptured(t, rec, Level(sentry.LevelWarning))
+	if w.Event.Message != "Degraded" || len(w.Event.Exception) != 0 {
+		t.Errorf("Expected the warning to be recorded as a message; got %q with %d exceptions", w.Event.Message, len(w.Event.Exception))
+	}
+	m := AssertCaptured(t, rec, Level(sentry.LevelInfo))
+	if m.Err != nil || m.Event.Message != "Deployed" {
+		t.Errorf("Expected the message to be recorded without an error; got %q, %v", m.Event.Message, m.Err)
+	}
+}
+
+func TestRecorderReset(t *testing.T) {
+	rec := NewRecorder()
+	rec.Error(errors.New("Failed"))
+	rec.Reset()
+	if n := len(rec.Captured()); n != 0 {
+		t.Errorf("Expected no events once reset; got %d", n)
+	}
+	rec.Error(errors.New("Failed again"))
+	AssertCaptured(t, rec, Level(sentry.LevelError))
+}
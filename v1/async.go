@@ -0,0 +1,186 @@
+package alert
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+var (
+	ErrQueueFull    = errors.New("Async queue is full; event dropped")
+	ErrQueueStopped = errors.New("Async queue was stopped; event abandoned")
+)
+
+// Async describes how events are delivered asynchronously. When enabled,
+// reporting an alert resolves its event, including everything derived from
+// its options, and queues it for a background worker to capture, so the
+// caller never waits on a client. Flush and Close wait for the queue to
+// drain.
+//
+// The zero value disables asynchronous delivery.
+type Async struct {
+	// Buffer is the number of events which may be queued.
+	Buffer int
+	// Block waits for room in the queue when it is full. By default the
+	// oldest queued event is dropped to make room instead.
+	Block bool
+	// Workers is the number of goroutines which capture queued events,
+	// which defaults to one. Events captured by more than one worker may be
+	// delivered out of order.
+	Workers int
+	// Retries is the number of times delivery of an alert to a backend
+	// which fails is retried, waiting twice as long before each retry as
+	// the one before it, starting with Backoff, which defaults to one
+	// second. Only the backends which failed are retried. Alerts which still
+	// cannot be delivered are handed to Config.OnDeliveryFailure.
+	Retries int
+	Backoff time.Duration
+}
+
+// The default delay before the first retry of a failed delivery.
+const defaultRetryBackoff = time.Second
+
+func (a Async) enabled() bool {
+	return a.Buffer > 0
+}
+
+// dispatch is an event queued for capture.
+type dispatch struct {
+	hub   *sentry.Hub
+	event *sentry.Event
+	err   error
+	alert *Event
+	// backends, when set, are those a failed delivery is retried for on
+	// the specified attempt; the event is not captured again.
+	backends []Backend
+	attempt  int
+}
+
+// asyncQueue queues events for capture by background workers.
+type asyncQueue struct {
+	Async
+	queue   chan dispatch
+	stop    chan struct{}
+	pending atomic.Int64 // queued or being captured
+}
+
+func newAsyncQueue(conf Async) *asyncQueue {
+	return &asyncQueue{
+		Async: conf,
+		queue: make(chan dispatch, conf.Buffer),
+		stop:  make(chan struct{}),
+	}
+}
+
+// start starts the workers which capture queued events.
+func (q *asyncQueue) start(a *Alerter) {
+	for range max(q.Workers, 1) {
+		go q.run(a)
+	}
+}
+
+// run captures queued events until the queue is stopped.
+func (q *asyncQueue) run(a *Alerter) {
+	for {
+		select {
+		case d := <-q.queue:
+			if d.backends != nil {
+				_, failed := a.deliver(d.alert, d.backends)
+				a.deliveryFailed(d.alert, failed, d.attempt)
+			} else {
+				a.capture(d.hub, d.event, d.err, d.alert)
+			}
+			q.add(a, -1)
+		case <-q.stop:
+			return
+		}
+	}
+}
+
+// Enqueue queues an event for capture. If the queue is full, it either waits
+// or drops the oldest queued event, which is reported to the error handler.
+func (q *asyncQueue) Enqueue(a *Alerter, d dispatch) {
+	q.add(a, 1)
+	for {
+		select {
+		case q.queue <- d:
+			return
+		case <-q.stop:
+			q.add(a, -1)
+			return
+		default:
+		}
+		if q.Block {
+			select {
+			case q.queue <- d:
+			case <-q.stop:
+				q.add(a, -1)
+			}
+			return
+		}
+		select {
+		case old := <-q.queue:
+			q.add(a, -1)
+			a.notify(ErrQueueFull)
+			a.countDeliveryError("queue", ErrQueueFull)
+			a.deadLetter(old.alert, &DeliveryError{Err: ErrQueueFull})
+		default:
+		}
+	}
+}
+
+// retry queues a failed delivery again once the backoff for its attempt
+// has elapsed. The delivery is pending while it waits, so Flush waits for
+// it too. Deliveries which are waiting when the queue is stopped are handed
+// to the dead letter handler.
+func (q *asyncQueue) retry(a *Alerter, d dispatch) {
+	wait := q.Backoff
+	if wait <= 0 {
+		wait = defaultRetryBackoff
+	}
+	wait <<= d.attempt - 1
+	q.add(a, 1)
+	time.AfterFunc(wait, func() {
+		defer q.add(a, -1)
+		select {
+		case <-q.stop:
+			failed := make([]*DeliveryError, len(d.backends))
+			for i, b := range d.backends {
+				failed[i] = &DeliveryError{Backend: b, Err: ErrQueueStopped}
+			}
+			a.deadLetter(d.alert, failed...)
+		default:
+			q.Enqueue(a, d)
+		}
+	})
+}
+
+// add adjusts the number of pending events and records the depth of the
+// queue.
+func (q *asyncQueue) add(a *Alerter, delta int64) {
+	a.observeQueueDepth(q.pending.Add(delta))
+}
+
+// Drain waits until every queued event has been captured or the timeout
+// elapses, and reports whether the queue drained.
+func (q *asyncQueue) Drain(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for q.pending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+// Stop stops the workers. Events which are still queued are abandoned.
+func (q *asyncQueue) Stop() {
+	select {
+	case <-q.stop:
+	default:
+		close(q.stop)
+	}
+}
@@ -0,0 +1,246 @@
+package alert
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gate is a backend which holds every alert delivered to it until it is
+// released, signalling as each one arrives.
+type gate struct {
+	backend
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newGate() *gate {
+	return &gate{entered: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (g *gate) Capture(e *Event) error {
+	g.entered <- struct{}{}
+	<-g.release
+	return g.backend.Capture(e)
+}
+
+// Wait waits for an alert to arrive.
+func (g *gate) Wait(tb testing.TB) {
+	tb.Helper()
+	select {
+	case <-g.entered:
+	case <-time.After(5 * time.Second):
+		tb.Fatal("Timed out waiting for an alert to be delivered")
+	}
+}
+
+func (g *gate) Open() {
+	close(g.release)
+}
+
+func TestAsync(t *testing.T) {
+	g := newGate()
+	a, err := New(Config{Backends: []Backend{g}, Async: Async{Buffer: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	// the caller does not wait for the backend
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Error(errors.New("Failed"))
+		a.Error(errors.New("Failed again"))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected reporting not to block while the backend does")
+	}
+	g.Wait(t)
+	if n := len(g.Events()); n != 0 {
+		t.Fatalf("Expected nothing to be delivered yet; got %d", n)
+	}
+
+	g.Open()
+	if !a.Flush(5 * time.Second) {
+		t.Fatal("Expected the queue to drain")
+	}
+	if n := len(g.Events()); n != 2 {
+		t.Errorf("Expected both alerts to be delivered once flushed; got %d", n)
+	}
+}
+
+func TestAsyncDropOldest(t *testing.T) {
+	g := newGate()
+	var full, deadLetters atomic.Int32
+	a, err := New(Config{
+		Backends: []Backend{g},
+		Async:    Async{Buffer: 1},
+		OnError: func(err error) {
+			if errors.Is(err, ErrQueueFull) {
+				full.Add(1)
+			}
+		},
+		OnDeliveryFailure: func(*Event, *DeliveryError) { deadLetters.Add(1) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Error(errors.New("First"))
+	g.Wait(t) // the worker holds the first alert; the queue holds one more
+	for _, msg := range []string{"Second", "Third", "Fourth"} {
+		a.Error(errors.New(msg))
+	}
+	if v := full.Load(); v != 2 {
+		t.Errorf("Expected the queue to be full twice; got %d", v)
+	}
+	if v := deadLetters.Load(); v != 2 {
+		t.Errorf("Expected the dropped alerts to be dead-lettered; got %d", v)
+	}
+
+	g.Open()
+	a.Flush(5 * time.Second)
+	var msgs []string
+	for _, e := range g.Events() {
+		msgs = append(msgs, e.Message)
+	}
+	if len(msgs) != 2 || msgs[0] != "First" || msgs[1] != "Fourth" {
+		t.Errorf("Expected the oldest queued alerts to be dropped; got %v", msgs)
+	}
+}
+
+func TestAsyncBlock(t *testing.T) {
+	g := newGate()
+	a, err := New(Config{Backends: []Backend{g}, Async: Async{Buffer: 1, Block: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Error(errors.New("First"))
+	g.Wait(t)
+	a.Error(errors.New("Second"))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Error(errors.New("Third"))
+	}()
+	select {
+	case <-done:
+		t.Fatal("Expected reporting to wait for room in the queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	g.Open()
+	<-done
+	a.Flush(5 * time.Second)
+	if n := len(g.Events()); n != 3 {
+		t.Errorf("Expected every alert to be delivered; got %d", n)
+	}
+}
+
+func TestAsyncSnapshot(t *testing.T) {
+	g := newGate()
+	a, err := New(Config{Backends: []Backend{g}, Async: Async{Buffer: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	tags := Tags{"tenant": "acme"}
+	extra := map[string]interface{}{"order": 1}
+	a.Error(errors.New("Failed"), WithTags(tags), WithExtra(extra))
+	tags["tenant"] = "other"
+	extra["order"] = 2
+
+	g.Open()
+	a.Flush(5 * time.Second)
+	events := g.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected one alert; got %d", len(events))
+	}
+	if v := events[0].Tags["tenant"]; v != "acme" {
+		t.Errorf("Expected the tags as they were when the alert was raised; got %q", v)
+	}
+	if v := events[0].Extra["order"]; v != 1 {
+		t.Errorf("Expected the extra as it was when the alert was raised; got %v", v)
+	}
+}
+
+func TestAsyncWorkers(t *testing.T) {
+	g := newGate()
+	a, err := New(Config{Backends: []Backend{g}, Async: Async{Buffer: 10, Workers: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	for i := 0; i < 3; i++ {
+		a.Error(errors.New("Failed"))
+	}
+	// every worker holds an alert at once
+	for i := 0; i < 3; i++ {
+		g.Wait(t)
+	}
+	g.Open()
+	if !a.Flush(5 * time.Second) {
+		t.Fatal("Expected the queue to drain")
+	}
+	if n := len(g.Events()); n != 3 {
+		t.Errorf("Expected every alert to be delivered; got %d", n)
+	}
+}
+
+func TestAsyncFlush(t *testing.T) {
+	g := newGate()
+	a, tr := newAlerter(t, Config{Backends: []Backend{g}, Async: Async{Buffer: 10}})
+
+	a.Error(errors.New("Failed"))
+	g.Wait(t)
+	start := time.Now()
+	if a.Flush(20 * time.Millisecond) {
+		t.Error("Expected the flush to time out while an alert is pending")
+	}
+	if d := time.Since(start); d < 20*time.Millisecond || d > time.Second {
+		t.Errorf("Expected the flush to wait for its timeout; waited %v", d)
+	}
+	if tr.flushes != 0 {
+		t.Errorf("Expected the client not to be flushed before the queue drains; got %d", tr.flushes)
+	}
+
+	g.Open()
+	if !a.Flush(5 * time.Second) {
+		t.Error("Expected the flush to complete once the alert is delivered")
+	}
+	if n := len(tr.Events()); n != 1 || tr.flushes != 1 {
+		t.Errorf("Expected the event to be captured and the client flushed; got %d events, %d flushes", n, tr.flushes)
+	}
+}
+
+func TestAsyncClose(t *testing.T) {
+	b := &slowBackend{}
+	a, err := New(Config{Backends: []Backend{b}, Async: Async{Buffer: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		a.Error(errors.New("Failed"))
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(b.Events()); n != 5 {
+		t.Errorf("Expected closing to deliver every queued alert; got %d", n)
+	}
+
+	a.Error(errors.New("Closed"))
+	time.Sleep(20 * time.Millisecond)
+	if n := len(b.Events()); n != 5 {
+		t.Errorf("Expected alerts raised once closed not to be delivered; got %d", n)
+	}
+}
@@ -0,0 +1,52 @@
+package alert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithAttachment(t *testing.T) {
+	log, recs := newLogger()
+	b := &backend{}
+	a, tr := newAlerter(t, Config{Backends: []Backend{b}, Verbose: Bool(true), Logger: log})
+	a.Error(errors.New("Could not decode order"),
+		WithAttachment("body.json", "application/json", []byte(`{"id":`)),
+		WithAttachment("goroutines.txt", "text/plain", []byte("goroutine 1 [running]")),
+	)
+	a.Error(errors.New("Failed"))
+
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events; got %d", len(events))
+	}
+	atts := events[0].Attachments
+	if len(atts) != 2 || atts[0].Filename != "body.json" || atts[0].ContentType != "application/json" || string(atts[0].Payload) != `{"id":` {
+		t.Fatalf("Expected the payloads to be attached to the event; got %v", atts)
+	}
+	if atts[1].Filename != "goroutines.txt" {
+		t.Errorf("Expected every payload to be attached; got %v", atts[1].Filename)
+	}
+	if n := len(events[1].Attachments); n != 0 {
+		t.Errorf("Expected attachments not to carry over to other alerts; got %d", n)
+	}
+
+	if e := b.Events()[0]; len(e.Attachments) != 2 || e.Attachments[0].Filename != "body.json" {
+		t.Errorf("Expected the payloads to be delivered to backends; got %v", e.Attachments)
+	}
+
+	rec := recs.Records(t)[0]
+	logged, _ := rec["attachments"].([]interface{})
+	if len(logged) != 2 {
+		t.Fatalf("Expected the attachments to be logged; got %v", rec["attachments"])
+	}
+	first, _ := logged[0].(map[string]interface{})
+	if first["name"] != "body.json" || first["size"] != float64(6) {
+		t.Errorf("Expected the name and size of the payload to be logged; got %v", first)
+	}
+	recs.Lock()
+	defer recs.Unlock()
+	if strings.Contains(recs.String(), "goroutine 1") {
+		t.Error("Expected payloads not to be logged")
+	}
+}
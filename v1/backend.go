@@ -0,0 +1,307 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bww/go-ident/v1"
+	"github.com/bww/go-router/v2"
+	"github.com/getsentry/sentry-go"
+)
+
+// Event is an alert as it is delivered to a backend, and as it is presented
+// to Config.BeforeSend before delivery. Everything derived from the error and
+// the options it was raised with has been resolved, and tags and extra have
+// been scrubbed.
+type Event struct {
+	ID          string
+	Time        time.Time
+	Level       sentry.Level
+	Message     string
+	Err         error
+	Ref         string
+	Component   string
+	Priority    Priority
+	Channel     ident.Ident // the channel of the alert; see WithChannel
+	Environment string      // see Config.Environment
+	Release     string      // see Config.Release
+	Tags        map[string]string
+	Extra       map[string]interface{}
+	Fingerprint []string
+	// Exception describes the error and its causes, innermost first, as it
+	// is reported to Sentry. Alerts which are reported as messages have none.
+	Exception []sentry.Exception
+	Request   *http.Request
+	// RequestBody is the portion of the body of the request which is
+	// captured, if any; see Config.RequestCapture.
+	RequestBody []byte
+	User        *sentry.User
+	// Attachments are the payloads attached via WithAttachment. Backends
+	// which cannot deliver them may describe or link them instead.
+	Attachments []*sentry.Attachment
+}
+
+// AtLeast determines whether the event is at least as severe as the minimum
+// level, which backends that deliver only severe alerts can use to filter.
+func (e *Event) AtLeast(min Level) bool {
+	return atLeast(e.Level, min)
+}
+
+// Backend delivers alerts to a destination, such as Sentry or a paging
+// service. Backends receive every alert that is reported, after it has been
+// filtered, deduplicated, and sampled, and may filter further themselves.
+// Capture is invoked on the goroutine delivering the alert, which is that of
+// the caller unless delivery is asynchronous (see Config.Async), so
+// implementations which are slow should deliver in the background.
+//
+// Errors returned by Capture are reported to the alerter's error handler. A
+// backend which filters out an alert, e.g., because it is less severe than
+// those it delivers, returns ErrSkipped, so that the alert is not considered
+// delivered by it.
+type Backend interface {
+	Capture(event *Event) error
+}
+
+// ErrSkipped is returned by backends which do not deliver an alert by
+// design. It is neither a success nor a failure: the alert does not count as
+// delivered by the backend, and delivery is not retried or dead-lettered.
+var ErrSkipped = errors.New("Alert was skipped")
+
+// Resolver is implemented by backends which track the state of the alerts
+// they deliver, such as paging services, so that an alert which is no longer
+// relevant can be resolved. See Alerter.Resolve.
+type Resolver interface {
+	Resolve(ref string) error
+}
+
+// Resolve resolves the alert with the specified reference in every backend
+// which supports it, including those of every route, e.g., once the
+// condition it describes has cleared.
+//
+// Alerts are identified by their reference, which is derived from the error
+// via errutil.Refstr unless one is provided via WithRef. Errors are reported
+// to the error handler, and the first is returned.
+func (a *Alerter) Resolve(ref string) error {
+	backends := slices.Clip(a.backends)
+	for _, r := range a.routes {
+		backends = append(backends, r.Backends...)
+	}
+	var first error
+	for _, b := range backends {
+		r, ok := b.(Resolver)
+		if !ok {
+			continue
+		}
+		if err := r.Resolve(ref); err != nil {
+			a.notify(err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+func Resolve(ref string) error {
+	if a := shared.Load(); a != nil {
+		return a.Resolve(ref)
+	}
+	return ErrUnavailable
+}
+
+// SentryBackend adapts a Sentry client, or any other Client, to a Backend.
+// Unlike Config.Sentry, which binds the client to the alerter's hub so that
+// its scope applies, the events delivered this way carry exactly what is
+// described by the alert.
+func SentryBackend(c Client) Backend {
+	return sentryBackend{c}
+}
+
+type sentryBackend struct {
+	client Client
+}
+
+func (b sentryBackend) Capture(e *Event) error {
+	event := sentry.NewEvent()
+	event.EventID = sentry.EventID(e.ID)
+	event.Timestamp = e.Time
+	event.Level = e.Level
+	event.Message = e.Message
+	event.Tags = e.Tags
+	event.Extra = e.Extra
+	event.Fingerprint = e.Fingerprint
+	event.Exception = e.Exception
+	if e.Request != nil {
+		event.Request = sentry.NewRequest(e.Request)
+		event.Request.Data = string(e.RequestBody)
+	}
+	if e.User != nil {
+		event.User = *e.User
+	}
+	event.Attachments = e.Attachments
+	event.Environment = e.Environment
+	event.Release = e.Release
+	if b.client.CaptureEvent(event, &sentry.EventHint{OriginalException: e.Err}, nil) == nil {
+		return ErrNotCaptured
+	}
+	return nil
+}
+
+// Route delivers the alerts for a channel to backends, so that different
+// classes of alerts reach different destinations from one alerter, e.g., to
+// page the team which owns a database for alerts about it:
+//
+//	Routes: []alert.Route{
+//		{Channel: dbChannel, Backends: []alert.Backend{dbPager}},
+//		{Channel: paymentsChannel, Backends: []alert.Backend{paymentsPager, paymentsSlack}},
+//	}
+//
+// The channel of an alert is that of the alerter (see Config.Channel and
+// Named) unless it is overridden via WithChannel. Alerts are delivered via
+// every matching route and to Config.Backends regardless of channel. Route
+// backends receive alerts in the same manner as Config.Backends.
+type Route struct {
+	Channel  ident.Ident
+	Backends []Backend
+}
+
+// routed produces the backends which receive alerts for the channel.
+func (a *Alerter) routed(channel ident.Ident) []Backend {
+	if len(a.routes) == 0 {
+		return a.backends
+	}
+	res := slices.Clip(a.backends)
+	for _, r := range a.routes {
+		if r.Channel == channel {
+			res = append(res, r.Backends...)
+		}
+	}
+	return res
+}
+
+// DeliveryError describes the failure of a backend to deliver an alert. It
+// identifies the backend so that the alert can be delivered to it again
+// later; see Config.OnDeliveryFailure.
+type DeliveryError struct {
+	Backend Backend
+	Err     error
+}
+
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("Could not deliver alert via %T: %v", e.Backend, e.Err)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// deliver delivers an alert to the backends and reports whether any of them
+// accepted it, along with the failures of those which did not. Backends which
+// skip the alert are neither.
+func (a *Alerter) deliver(e *Event, backends []Backend) (bool, []*DeliveryError) {
+	var ok bool
+	var failed []*DeliveryError
+	for _, b := range backends {
+		if err := b.Capture(e); errors.Is(err, ErrSkipped) {
+			continue
+		} else if err != nil {
+			a.notify(err)
+			a.countDeliveryError(fmt.Sprintf("%T", b), err)
+			failed = append(failed, &DeliveryError{Backend: b, Err: err})
+		} else {
+			ok = true
+		}
+	}
+	return ok, failed
+}
+
+// deliveryFailed handles the failures of backends to deliver an alert on the
+// specified attempt: they are retried if delivery is asynchronous and
+// retries remain, and are otherwise handed to the dead letter handler.
+func (a *Alerter) deliveryFailed(e *Event, failed []*DeliveryError, attempt int) {
+	if len(failed) == 0 {
+		return
+	}
+	if a.async != nil && attempt < a.async.Retries {
+		backends := make([]Backend, len(failed))
+		for i, f := range failed {
+			backends[i] = f.Backend
+		}
+		a.async.retry(a, dispatch{alert: e, backends: backends, attempt: attempt + 1})
+		return
+	}
+	a.deadLetter(e, failed...)
+}
+
+// deadLetter hands alerts which could not be delivered to the dead letter
+// handler, if there is one.
+func (a *Alerter) deadLetter(e *Event, failed ...*DeliveryError) {
+	if a.onDeliveryFailure == nil || e == nil {
+		return
+	}
+	for _, f := range failed {
+		a.onDeliveryFailure(e, f)
+	}
+}
+
+// backendEvent produces the alert described by an event, as it is delivered
+// to backends.
+func (a *Alerter) backendEvent(event *sentry.Event, err error, ref, component string, priority Priority, tags Tags, req *router.Request) *Event {
+	e := &Event{
+		ID:          string(event.EventID),
+		Time:        a.now(),
+		Level:       event.Level,
+		Message:     event.Message,
+		Err:         err,
+		Ref:         ref,
+		Component:   component,
+		Priority:    priority,
+		Channel:     a.channel,
+		Tags:        make(map[string]string, len(tags)),
+		Extra:       event.Extra,
+		Fingerprint: event.Fingerprint,
+		Exception:   append([]sentry.Exception(nil), event.Exception...),
+	}
+	if e.Message == "" && len(event.Exception) > 0 {
+		e.Message = event.Exception[len(event.Exception)-1].Value
+	}
+	for k, v := range tags {
+		e.Tags[k] = fmt.Sprint(v)
+	}
+	if req != nil {
+		e.Request = a.request((*http.Request)(req))
+		e.RequestBody, _ = a.requestBody(req)
+	}
+	return e
+}
+
+// applyEvent applies the changes made to an alert by Config.BeforeSend to
+// the Sentry event and scope it was produced from, where tags identifies the
+// tags set on the scope for the alert.
+func applyEvent(s *sentry.Scope, event *sentry.Event, e *Event, tags Tags) {
+	event.Level = e.Level
+	if len(e.Exception) == 0 {
+		event.Message = e.Message
+	}
+	event.Exception = e.Exception
+	event.Extra = e.Extra
+	event.Fingerprint = e.Fingerprint
+	for k := range tags {
+		if _, ok := e.Tags[k]; !ok {
+			s.RemoveTag(k)
+		}
+	}
+	s.SetTags(e.Tags)
+	s.SetRequest(e.Request)
+	if e.RequestBody != nil {
+		s.SetRequestBody(e.RequestBody)
+	}
+	if e.User != nil {
+		s.SetUser(*e.User)
+	} else {
+		s.SetUser(sentry.User{})
+	}
+}
@@ -0,0 +1,124 @@
+package alert
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// resolver is a backend which records the references it resolves.
+type resolver struct {
+	backend
+	resolved []string
+}
+
+func (r *resolver) Resolve(ref string) error {
+	r.resolved = append(r.resolved, ref)
+	return nil
+}
+
+func TestBackends(t *testing.T) {
+	client, tr := newClient(t)
+	first, failing := &backend{}, &backend{err: errors.New("Unavailable")}
+	var notified, deadLetters int
+	a, err := New(Config{
+		Environment:       "production",
+		Backends:          []Backend{first, failing, SentryBackend(client)},
+		OnError:           func(error) { notified++ },
+		OnDeliveryFailure: func(*Event, *DeliveryError) { deadLetters++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	errJob := errors.New("Job failed")
+	if id := a.Error(errJob, WithRef("job-1"), WithComponent("worker"), WithTags(Tags{"tenant": "acme"})); id == nil {
+		t.Fatal("Expected the alert to be captured without a Sentry client")
+	}
+
+	events := first.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected the backend to receive the alert; got %d events", len(events))
+	}
+	e := events[0]
+	if e.Err != errJob || e.Ref != "job-1" || e.Component != "worker" || e.Level != LevelError || e.Environment != "production" {
+		t.Errorf("Expected the resolved alert; got %+v", e)
+	}
+	if v := e.Tags["tenant"]; v != "acme" {
+		t.Errorf("Expected the tags of the alert; got %v", e.Tags)
+	}
+	if v := tr.Event(t); string(v.EventID) != e.ID || v.Tags["tenant"] != "acme" {
+		t.Errorf("Expected the Sentry backend to receive the same alert; got %+v", v)
+	}
+	if notified != 1 || deadLetters != 1 {
+		t.Errorf("Expected the failing backend to be reported and dead-lettered once; got %d, %d", notified, deadLetters)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	r, plain := &resolver{}, &backend{}
+	a, err := New(Config{Backends: []Backend{r, plain}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if err := a.Resolve("job-1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.resolved) != 1 || r.resolved[0] != "job-1" {
+		t.Errorf("Expected the resolver to resolve the reference; got %v", r.resolved)
+	}
+}
+
+// skipper is a backend which skips every alert.
+type skipper struct{ calls int }
+
+func (s *skipper) Capture(*Event) error {
+	s.calls++
+	return ErrSkipped
+}
+
+func TestSkipped(t *testing.T) {
+	m := &metrics{}
+	var notified, deadLetters int
+	s := &skipper{}
+	a, err := New(Config{
+		Backends:          []Backend{s},
+		Metrics:           m,
+		OnError:           func(error) { notified++ },
+		OnDeliveryFailure: func(*Event, *DeliveryError) { deadLetters++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if id := a.Error(errors.New("Failed")); id != nil {
+		t.Errorf("Expected no identifier for an alert every backend skipped; got %v", *id)
+	}
+	if _, err := a.CaptureSync(LevelError, errors.New("Failed")); !errors.Is(err, ErrNotCaptured) {
+		t.Errorf("Expected %v; got %v", ErrNotCaptured, err)
+	}
+	if s.calls != 2 {
+		t.Errorf("Expected the backend to be offered both alerts; got %d", s.calls)
+	}
+	if notified != 0 || deadLetters != 0 {
+		t.Errorf("Expected a skipped alert not to be a failure; got %d errors, %d dead letters", notified, deadLetters)
+	}
+	if v := m.Outcomes(); !slices.Equal(v, []Outcome{OutcomeIgnored, OutcomeIgnored}) {
+		t.Errorf("Expected skipped alerts to be ignored; got %v", v)
+	}
+
+	// another backend which delivers it is unaffected
+	b := &backend{}
+	a, err = New(Config{Backends: []Backend{s, b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if id := a.Error(errors.New("Failed")); id == nil || len(b.Events()) != 1 {
+		t.Error("Expected the alert to be delivered by the backend which accepts it")
+	}
+}
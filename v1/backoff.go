@@ -0,0 +1,46 @@
+package alert
+
+import (
+	"time"
+)
+
+// Backoff describes how repeated occurrences of an error are suppressed.
+//
+// After an error is reported, further occurrences are suppressed for the
+// initial window. Each time the error is reported again after its window has
+// elapsed, the window doubles, up to the maximum. Once an error stops
+// occurring for longer than its current window it is forgotten, and the next
+// occurrence starts over with the initial window.
+//
+// The zero value disables backoff.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+}
+
+func (b Backoff) enabled() bool {
+	return b.Initial > 0
+}
+
+// admit determines whether an occurrence of an error should be reported,
+// updating its backoff state accordingly. It is intended to be used as an
+// update function for recent.Observe.
+func (b Backoff) admit(e *occurrence, now time.Time) bool {
+	if e.window > 0 && now.Sub(e.Last) > e.window {
+		e.window = 0 // the error stopped for a time; start over
+	}
+	if e.window > 0 && now.Before(e.next) {
+		e.Suppressed++
+		return false
+	}
+	if e.window == 0 {
+		e.window = b.Initial
+	} else {
+		e.window *= 2
+	}
+	if b.Max > 0 && e.window > b.Max {
+		e.window = b.Max
+	}
+	e.next = now.Add(e.window)
+	return true
+}
@@ -0,0 +1,47 @@
+package alert
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	c := newClock()
+	start := c.Now()
+	a, tr := newAlerter(t, Config{Clock: c.Now, Backoff: Backoff{Initial: time.Minute, Max: 4 * time.Minute}})
+
+	var reported []time.Duration
+	for i := 0; i < 20; i++ {
+		if a.Error(errors.New("Flapping")) != nil {
+			reported = append(reported, c.Now().Sub(start))
+		}
+		c.Advance(30 * time.Second)
+	}
+	// the window doubles each time the error is reported, up to the maximum
+	want := []time.Duration{0, time.Minute, 3 * time.Minute, 7 * time.Minute}
+	if !slices.Equal(reported, want) {
+		t.Fatalf("Expected the error to be reported at %v; got %v", want, reported)
+	}
+	events := tr.Events()
+	for i, e := range []interface{}{nil, 1, 3, 7} {
+		if v := events[i].Extra["suppressed"]; v != e {
+			t.Errorf("Expected event %d to note %v suppressed occurrences; got %v", i, e, v)
+		}
+	}
+
+	// once the error stops for longer than its window, it starts over
+	c.Advance(10 * time.Minute)
+	if a.Error(errors.New("Flapping")) == nil {
+		t.Fatal("Expected the error to be reported after it stopped")
+	}
+	c.Advance(30 * time.Second)
+	if a.Error(errors.New("Flapping")) != nil {
+		t.Error("Expected the error to be suppressed for the initial window")
+	}
+	c.Advance(31 * time.Second)
+	if a.Error(errors.New("Flapping")) == nil {
+		t.Error("Expected the error to be reported once the initial window elapsed")
+	}
+}
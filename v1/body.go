@@ -0,0 +1,60 @@
+package alert
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/bww/go-router/v2"
+)
+
+// bodyHash produces the hex-encoded SHA-256 hash of the request body, if the
+// body can be read again.
+func bodyHash(req *router.Request) (string, bool) {
+	if req.GetBody == nil {
+		return "", false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", false
+	}
+	defer body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", false
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// BufferBody produces router middleware which buffers the bodies of requests,
+// up to the specified number of bytes, so they can be read again by the
+// alerter, e.g., to capture them or hash them (see RequestCapture and
+// WithBodyHash). Requests received by a server cannot otherwise be read
+// again. Bodies larger than the limit are passed on unbuffered.
+func BufferBody(limit int64) router.Middle {
+	return router.MiddleFunc(func(h router.Handler) router.Handler {
+		return func(req *router.Request, cxt router.Context) (*router.Response, error) {
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				data, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+				if err != nil {
+					return nil, err
+				}
+				if int64(len(data)) > limit {
+					req.Body = struct {
+						io.Reader
+						io.Closer
+					}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+				} else {
+					req.Body.Close()
+					req.Body = io.NopCloser(bytes.NewReader(data))
+					req.GetBody = func() (io.ReadCloser, error) {
+						return io.NopCloser(bytes.NewReader(data)), nil
+					}
+				}
+			}
+			return h(req, cxt)
+		}
+	})
+}
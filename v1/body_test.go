@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bww/go-router/v2"
+)
+
+func TestWithBodyHash(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	for _, body := range []string{`{"id":1}`, `{"id":1}`, `{"id":2}`} {
+		req, err := router.NewRequest("POST", "https://example.com/orders", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.Error(errors.New("Invalid order"), WithRequest(req), WithBodyHash())
+	}
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected three events; got %d", len(events))
+	}
+	sum := sha256.Sum256([]byte(`{"id":1}`))
+	if v, want := events[0].Tags["body_hash"], hex.EncodeToString(sum[:]); v != want {
+		t.Errorf("Expected the hash of the body %q; got %q", want, v)
+	}
+	if events[0].Tags["body_hash"] != events[1].Tags["body_hash"] {
+		t.Error("Expected identical bodies to hash identically")
+	}
+	if events[0].Tags["body_hash"] == events[2].Tags["body_hash"] {
+		t.Error("Expected different bodies to hash differently")
+	}
+}
+
+func TestWithBodyHashUnbuffered(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	req, err := router.NewRequest("POST", "https://example.com/orders", io.NopCloser(strings.NewReader("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Error(errors.New("Invalid order"), WithRequest(req), WithBodyHash())
+
+	if v, ok := tr.Event(t).Tags["body_hash"]; ok {
+		t.Errorf("Expected no hash of a body which cannot be read again; got %q", v)
+	}
+}
+
+func TestBufferBody(t *testing.T) {
+	var hashed bool
+	h := BufferBody(1024).Wrap(func(req *router.Request, cxt router.Context) (*router.Response, error) {
+		_, hashed = bodyHash(req)
+		data, _ := io.ReadAll(req.Body)
+		if string(data) != "body" {
+			t.Errorf("Expected the body to be readable by the handler; got %q", data)
+		}
+		return nil, nil
+	})
+	req, err := router.NewRequest("POST", "https://example.com/orders", io.NopCloser(strings.NewReader("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h(req, router.Context{})
+	if !hashed {
+		t.Error("Expected a buffered body to be readable again")
+	}
+}
@@ -0,0 +1,102 @@
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The maximum number of breadcrumbs retained by an alerter and attached to
+// an event.
+const maxBreadcrumbs = 100
+
+// breadcrumbs retains the breadcrumbs recorded via AddBreadcrumb until the
+// next event is captured. The number retained is bounded; the oldest are
+// discarded first.
+type breadcrumbs struct {
+	sync.Mutex
+	crumbs []sentry.Breadcrumb
+}
+
+func (b *breadcrumbs) Add(c sentry.Breadcrumb) {
+	b.Lock()
+	defer b.Unlock()
+	if len(b.crumbs) >= maxBreadcrumbs {
+		b.crumbs = b.crumbs[1:]
+	}
+	b.crumbs = append(b.crumbs, c)
+}
+
+// Drain produces the retained breadcrumbs and discards them.
+func (b *breadcrumbs) Drain() []sentry.Breadcrumb {
+	b.Lock()
+	defer b.Unlock()
+	c := b.crumbs
+	b.crumbs = nil
+	return c
+}
+
+// AddBreadcrumb records a breadcrumb which is attached to the next event the
+// alerter captures. Breadcrumbs recorded this way are shared by every
+// goroutine using the alerter, so they are best suited to process-wide
+// events; breadcrumbs which relate to a particular request or job should be
+// provided via WithBreadcrumbs instead.
+func (a *Alerter) AddBreadcrumb(c sentry.Breadcrumb) {
+	if c.Timestamp.IsZero() {
+		c.Timestamp = a.now()
+	}
+	a.breadcrumbs.Add(c)
+}
+
+// Breadcrumb records a breadcrumb, in the manner of AddBreadcrumb, in the
+// specified category.
+func (a *Alerter) Breadcrumb(category, message string, data map[string]interface{}) {
+	a.AddBreadcrumb(sentry.Breadcrumb{Category: category, Message: message, Data: data})
+}
+
+type breadcrumbsKey struct{}
+
+// WithBreadcrumbTrail produces a context which retains the breadcrumbs
+// recorded in it via AddContextBreadcrumb until the next event is captured
+// for an alert raised in that context. This is typically called by
+// middleware, which replaces the context of each request it handles with the
+// result, so that each request has a trail of its own.
+func WithBreadcrumbTrail(cxt context.Context) context.Context {
+	return context.WithValue(cxt, breadcrumbsKey{}, &breadcrumbs{})
+}
+
+// AddContextBreadcrumb records a breadcrumb in the trail of the context, if
+// it has one; see WithBreadcrumbTrail. Otherwise the breadcrumb is discarded.
+// The breadcrumb is timestamped by the alerter's clock.
+func (a *Alerter) AddContextBreadcrumb(cxt context.Context, category, message string, data map[string]interface{}) {
+	addContextBreadcrumb(cxt, sentry.Breadcrumb{Category: category, Message: message, Data: data, Timestamp: a.now()})
+}
+
+// AddContextBreadcrumb records a breadcrumb in the trail of the context, in
+// the manner of Alerter.AddContextBreadcrumb, via the alerter the context
+// carries, if any, and otherwise via the shared alerter.
+func AddContextBreadcrumb(cxt context.Context, category, message string, data map[string]interface{}) {
+	if a := FromContext(cxt); a != nil {
+		a.AddContextBreadcrumb(cxt, category, message, data)
+	} else if a := shared.Load(); a != nil {
+		a.AddContextBreadcrumb(cxt, category, message, data)
+	} else {
+		addContextBreadcrumb(cxt, sentry.Breadcrumb{Category: category, Message: message, Data: data, Timestamp: time.Now()})
+	}
+}
+
+func addContextBreadcrumb(cxt context.Context, c sentry.Breadcrumb) {
+	if b, ok := cxt.Value(breadcrumbsKey{}).(*breadcrumbs); ok {
+		b.Add(c)
+	}
+}
+
+// contextBreadcrumbs drains the trail of the context, if it has one.
+func contextBreadcrumbs(cxt context.Context) []sentry.Breadcrumb {
+	if b, ok := cxt.Value(breadcrumbsKey{}).(*breadcrumbs); ok {
+		return b.Drain()
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestContextBreadcrumbClock(t *testing.T) {
+	clock := newClock()
+	a, tr := newAlerter(t, Config{Clock: clock.Now})
+	cxt := WithBreadcrumbTrail(NewContext(context.Background(), a))
+
+	AddContextBreadcrumb(cxt, "job", "Started", nil)
+	clock.Advance(time.Minute)
+	a.AddContextBreadcrumb(cxt, "job", "Retried", nil)
+	a.ErrorContext(cxt, errors.New("Failed"))
+
+	crumbs := tr.Event(t).Breadcrumbs
+	if len(crumbs) != 2 {
+		t.Fatalf("Expected the breadcrumbs of the context to be attached; got %v", crumbs)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if c := crumbs[0]; c.Message != "Started" || !c.Timestamp.Equal(start) {
+		t.Errorf("Expected the breadcrumb to be timestamped by the alerter's clock; got %q at %v", c.Message, c.Timestamp)
+	}
+	if c := crumbs[1]; c.Message != "Retried" || !c.Timestamp.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected the breadcrumb to be timestamped by the alerter's clock; got %q at %v", c.Message, c.Timestamp)
+	}
+}
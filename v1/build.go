@@ -0,0 +1,54 @@
+package alert
+
+import (
+	rdebug "runtime/debug"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// build describes the build of the program an alerter runs in, as it is
+// attached to alerts.
+type build struct {
+	release  string
+	revision string
+	context  sentry.Context // the Sentry "build" context
+}
+
+// newBuild describes the build reported by the provided function, if any.
+// The release is the one provided, or else the version of the main module,
+// or else the revision it was built from, if they are known.
+func newBuild(release string, info func() (*rdebug.BuildInfo, bool)) build {
+	b := build{release: release}
+	if info == nil {
+		return b
+	}
+	bi, ok := info()
+	if !ok || bi == nil {
+		return b
+	}
+	b.context = sentry.Context{"go_version": bi.GoVersion}
+	if bi.Main.Path != "" {
+		b.context["module"] = bi.Main.Path
+	}
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		b.context["version"] = v
+		if b.release == "" {
+			b.release = v
+		}
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.revision = s.Value
+			b.context["revision"] = s.Value
+		case "vcs.time":
+			b.context["time"] = s.Value
+		case "vcs.modified":
+			b.context["modified"] = s.Value == "true"
+		}
+	}
+	if b.release == "" {
+		b.release = b.revision
+	}
+	return b
+}
@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The import path of this package, which prefixes the names of its functions.
+var pkgPath = reflect.TypeOf(Alerter{}).PkgPath()
+
+// internalFunction determines whether the named function belongs to this
+// package.
+func internalFunction(name string) bool {
+	return strings.HasPrefix(name, pkgPath+".")
+}
+
+// callerFunction produces the name of the innermost function on the calling
+// goroutine's stack which does not belong to this package.
+func callerFunction() string {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		f, more := frames.Next()
+		if f.Function != "" && !internalFunction(f.Function) {
+			return f.Function
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// callPath produces the names of the innermost functions on the calling
+// goroutine's stack which do not belong to this package, up to the specified
+// depth, from outermost to innermost, e.g., "main.main > app.(*Worker).run".
+// Package paths are omitted from the names, as are runtime functions.
+func callPath(depth int) string {
+	pc := make([]uintptr, 32+depth)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	var names []string
+	for len(names) < depth {
+		f, more := frames.Next()
+		if f.Function != "" && !internalFunction(f.Function) && !strings.HasPrefix(f.Function, "runtime.") {
+			names = append(names, f.Function[strings.LastIndex(f.Function, "/")+1:])
+		}
+		if !more {
+			break
+		}
+	}
+	slices.Reverse(names)
+	return strings.Join(names, " > ")
+}
+
+// callerStacktrace produces the calling goroutine's stack, outermost first,
+// omitting the frames of this package and of the runtime.
+func callerStacktrace() *sentry.Stacktrace {
+	pc := make([]uintptr, 100)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	var conv []sentry.Frame
+	for {
+		f, more := frames.Next()
+		if f.Function != "" && !internalFunction(f.Function) && !strings.HasPrefix(f.Function, "runtime.") {
+			conv = append(conv, sentry.NewFrame(f))
+		}
+		if !more {
+			break
+		}
+	}
+	if len(conv) == 0 {
+		return nil
+	}
+	slices.Reverse(conv)
+	return &sentry.Stacktrace{Frames: conv}
+}
+
+// hasStacktrace determines whether any of the exceptions carry a stack.
+func hasStacktrace(excs []sentry.Exception) bool {
+	for _, e := range excs {
+		if e.Stacktrace != nil && len(e.Stacktrace.Frames) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package alert_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/bww/go-alert/v1/alerttest"
+)
+
+// The functions of package alert are omitted from the frames these features
+// describe, including its internal tests, so they are tested from without.
+
+func newRecorded(t *testing.T, conf alert.Config) (*alert.Alerter, *alerttest.Recorder) {
+	t.Helper()
+	rec := alerttest.NewRecorder()
+	conf.Tee = []alert.Client{rec}
+	a, err := alert.New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	return a, rec
+}
+
+func syncInvoices(a *alert.Alerter) {
+	a.Error(errors.New("Sync failed"))
+}
+
+func TestCallerTransaction(t *testing.T) {
+	a, rec := newRecorded(t, alert.Config{CallerTransaction: true})
+	syncInvoices(a)
+
+	c := alerttest.AssertCaptured(t, rec)
+	if v, want := c.Event.Transaction, "github.com/bww/go-alert/v1_test.syncInvoices"; v != want {
+		t.Errorf("Expected the transaction %q; got %q", want, v)
+	}
+}
+
+func TestCallerTransactionDisabled(t *testing.T) {
+	a, rec := newRecorded(t, alert.Config{})
+	syncInvoices(a)
+
+	if v := alerttest.AssertCaptured(t, rec).Event.Transaction; v != "" {
+		t.Errorf("Expected no transaction by default; got %q", v)
+	}
+}
+
+func reconcile(a *alert.Alerter) {
+	a.Error(errors.New("Reconcile failed"), alert.WithCallPath(2))
+}
+
+func TestCallPath(t *testing.T) {
+	a, rec := newRecorded(t, alert.Config{})
+	reconcile(a)
+
+	c := alerttest.AssertCaptured(t, rec)
+	if v, want := c.Event.Tags["call_path"], "v1_test.TestCallPath > v1_test.reconcile"; v != want {
+		t.Errorf("Expected the call path %q; got %q", want, v)
+	}
+}
@@ -0,0 +1,141 @@
+package alert
+
+import (
+	"reflect"
+)
+
+// The maximum number of errors in a chain that are inspected when it is
+// searched.
+const maxChainLength = 64
+
+// visited records the errors encountered while traversing a chain, so that
+// chains which contain a cycle, e.g., where A unwraps to B and B unwraps to A,
+// can be detected rather than traversed until some limit is reached.
+type visited map[interface{}]struct{}
+
+type refKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// Visit records the error and reports whether it was the first time it was
+// encountered. Errors of reference types are identified by their address and
+// other comparable errors by their value. Errors which are neither cannot be
+// identified and are always reported as being encountered for the first time.
+func (v visited) Visit(err error) bool {
+	var key interface{}
+	rv := reflect.ValueOf(err)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		key = refKey{rv.Type(), rv.Pointer()}
+	default:
+		if !rv.Comparable() {
+			return true
+		}
+		key = err
+	}
+	if _, ok := v[key]; ok {
+		return false
+	}
+	v[key] = struct{}{}
+	return true
+}
+
+// unwrap produces the error wrapped by err, or nil if it wraps nothing. All
+// traversal of error chains in this package goes through this function, so
+// that every consumer agrees on what the chain is.
+//
+// An error may implement both Unwrap() error, per the Go 1.13 convention, and
+// Cause() error, as used by github.com/pkg/errors and similar libraries. When
+// it does, Unwrap takes precedence and Cause is ignored.
+func unwrap(err error) error {
+	switch c := err.(type) {
+	case interface{ Unwrap() error }:
+		return c.Unwrap()
+	case interface{ Cause() error }:
+		return c.Cause()
+	default:
+		return nil
+	}
+}
+
+// walkChain invokes the function for each error in the chain, starting with
+// err itself, until the function returns false or the chain ends. It reports
+// whether the chain contains a cycle, in which case traversal ends when the
+// cycle is first detected.
+func walkChain(err error, fn func(e error) bool) (cycle bool) {
+	seen := make(visited)
+	for i := 0; err != nil && i < maxChainLength; i++ {
+		if !seen.Visit(err) {
+			return true
+		}
+		if !fn(err) {
+			return false
+		}
+		err = unwrap(err)
+	}
+	return false
+}
+
+// hasCycle determines whether the error chain contains a cycle.
+func hasCycle(err error) bool {
+	return walkChain(err, func(error) bool { return true })
+}
+
+// acyclic produces an equivalent of the error chain which ends where the
+// original chain would cycle, so that it can be searched with errors.Is and
+// errors.As, neither of which detect cycles. A chain without a cycle is
+// returned as-is.
+func acyclic(err error) error {
+	if !hasCycle(err) {
+		return err
+	}
+	var errs []error
+	walkChain(err, func(e error) bool {
+		errs = append(errs, e)
+		return true
+	})
+	var next error
+	for i := len(errs) - 1; i >= 0; i-- {
+		next = &link{err: errs[i], next: next}
+	}
+	return next
+}
+
+// link stands in for an error in an acyclic chain. It matches errors.Is and
+// errors.As targets as the error it stands in for would, but unwraps to the
+// next link in the chain rather than to the error the original unwraps to.
+type link struct {
+	err  error
+	next error
+}
+
+func (l *link) Error() string {
+	return l.err.Error()
+}
+
+func (l *link) Unwrap() error {
+	return l.next
+}
+
+func (l *link) Is(target error) bool {
+	if reflect.ValueOf(l.err).Comparable() && l.err == target {
+		return true
+	}
+	if c, ok := l.err.(interface{ Is(error) bool }); ok {
+		return c.Is(target)
+	}
+	return false
+}
+
+func (l *link) As(target interface{}) bool {
+	v := reflect.ValueOf(target).Elem()
+	if reflect.TypeOf(l.err).AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(l.err))
+		return true
+	}
+	if c, ok := l.err.(interface{ As(interface{}) bool }); ok {
+		return c.As(target)
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// dualError implements both Unwrap and Cause, each producing a different
+// error.
+type dualError struct {
+	unwrapped, cause error
+}
+
+func (e dualError) Error() string { return "dual" }
+func (e dualError) Unwrap() error { return e.unwrapped }
+func (e dualError) Cause() error  { return e.cause }
+
+// causeError implements only Cause.
+type causeError struct {
+	cause error
+}
+
+func (e causeError) Error() string { return "cause" }
+func (e causeError) Cause() error  { return e.cause }
+
+func TestUnwrapPrecedence(t *testing.T) {
+	viaUnwrap := errors.New("via unwrap")
+	viaCause := errors.New("via cause")
+	err := dualError{unwrapped: viaUnwrap, cause: viaCause}
+
+	if v := unwrap(err); v != viaUnwrap {
+		t.Errorf("Expected Unwrap to take precedence over Cause; got %v", v)
+	}
+	if v := unwrap(causeError{cause: viaCause}); v != viaCause {
+		t.Errorf("Expected Cause to be followed absent Unwrap; got %v", v)
+	}
+
+	a, tr := newAlerter(t, Config{})
+	a.Error(err)
+	event := tr.Event(t)
+	if len(event.Exception) != 2 {
+		t.Fatalf("Expected two exceptions; got %d", len(event.Exception))
+	}
+	if v := event.Exception[0].Value; v != viaUnwrap.Error() {
+		t.Errorf("Expected the cause of the event to be the unwrapped error; got %q", v)
+	}
+}
+
+// cyclicError is an error whose chain may be made to cycle.
+type cyclicError struct {
+	msg  string
+	next error
+}
+
+func (e *cyclicError) Error() string { return e.msg }
+func (e *cyclicError) Unwrap() error { return e.next }
+
+func TestCyclicChain(t *testing.T) {
+	a := &cyclicError{msg: "a"}
+	b := &cyclicError{msg: "b", next: a}
+	a.next = b
+
+	if !hasCycle(a) {
+		t.Fatal("Expected the cycle to be detected")
+	}
+	if !errors.Is(acyclic(a), b) {
+		t.Error("Expected the acyclic equivalent to be searchable")
+	}
+	var target *cyclicError
+	if !errors.As(acyclic(a), &target) || target != a {
+		t.Errorf("Expected the acyclic equivalent to match as the original; got %v", target)
+	}
+
+	al, tr := newAlerter(t, Config{Sentinels: []Sentinel{{Err: b, Level: "fatal"}}, MaxErrorDepth: 10})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		al.Error(a)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected reporting a cyclic chain to complete")
+	}
+
+	event := tr.Event(t)
+	if v := event.Tags["cycle_detected"]; v != "true" {
+		t.Errorf("Expected the cycle to be tagged; got %q", v)
+	}
+	if len(event.Exception) != 2 {
+		t.Errorf("Expected each error in the cycle to be described once; got %d exceptions", len(event.Exception))
+	}
+	if event.Level != "fatal" {
+		t.Errorf("Expected the chain to be searched for sentinels; got %s", event.Level)
+	}
+}
+
+func TestLongChain(t *testing.T) {
+	var err error = errors.New("root")
+	for i := 0; i < 10*maxChainLength; i++ {
+		err = &cyclicError{msg: "wrapped", next: err}
+	}
+	var n int
+	walkChain(err, func(error) bool {
+		n++
+		return true
+	})
+	if n != maxChainLength {
+		t.Errorf("Expected traversal to end after %d errors; got %d", maxChainLength, n)
+	}
+}
@@ -0,0 +1,144 @@
+package alert
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Client captures events on behalf of an alerter. It is implemented by
+// *sentry.Client.
+type Client interface {
+	CaptureEvent(event *sentry.Event, hint *sentry.EventHint, scope sentry.EventModifier) *sentry.EventID
+	Flush(timeout time.Duration) bool
+}
+
+// capture reports an event to the Sentry client and to every tee client,
+// with the scope of the hub, and the alert it describes, if provided, to
+// every backend. Each tee client receives its own copy of the event, since
+// clients may modify the events they capture.
+//
+// The identifier of the event captured by the Sentry client is returned or,
+// if it did not capture the event, that of the first tee client or backend
+// which did.
+func (a *Alerter) capture(hub *sentry.Hub, event *sentry.Event, err error, ev *Event) *sentry.EventID {
+	d := a.send(hub, event, err, ev)
+	a.deliveryFailed(ev, d.failed, 0)
+	return d.id
+}
+
+// send delivers an event in the manner of capture and describes the result,
+// without handling the failures of backends.
+func (a *Alerter) send(hub *sentry.Hub, event *sentry.Event, err error, ev *Event) delivery {
+	var d delivery
+	if ev != nil {
+		var ok bool
+		ok, d.failed = a.deliver(ev, a.routed(ev.Channel))
+		if ok {
+			v := sentry.EventID(ev.ID)
+			d.id = &v
+		}
+	}
+	scope := hub.Scope()
+	hint := &sentry.EventHint{OriginalException: err}
+	for _, c := range a.tee {
+		if v := c.CaptureEvent(copyEvent(event), hint, scope); v != nil {
+			d.clients = append(d.clients, c)
+			if d.id == nil {
+				d.id = v
+			}
+		}
+	}
+	if a.sentry != nil && atLeast(event.Level, a.minLevel) {
+		if v := a.sentry.CaptureEvent(event, hint, scope); v != nil {
+			d.clients = append(d.clients, a.sentry)
+			d.id = v
+		}
+	}
+	return d
+}
+
+// copyEvent produces a copy of an event which shares none of the maps or
+// slices a client, or the scope it applies, may modify with the original.
+func copyEvent(event *sentry.Event) *sentry.Event {
+	dup := *event
+	dup.Tags = maps.Clone(event.Tags)
+	dup.Extra = maps.Clone(event.Extra)
+	dup.Modules = maps.Clone(event.Modules)
+	if event.Contexts != nil {
+		dup.Contexts = make(map[string]sentry.Context, len(event.Contexts))
+		for k, v := range event.Contexts {
+			dup.Contexts[k] = maps.Clone(v)
+		}
+	}
+	dup.Fingerprint = slices.Clone(event.Fingerprint)
+	dup.Breadcrumbs = slices.Clone(event.Breadcrumbs)
+	dup.Exception = slices.Clone(event.Exception)
+	dup.Attachments = slices.Clone(event.Attachments)
+	return &dup
+}
+
+// MinLevel wraps a client such that only events at least as severe as the
+// provided level are captured; less severe events are discarded. This allows
+// for clients to be configured as destinations for different tiers of alerts.
+func MinLevel(c Client, min sentry.Level) Client {
+	return minLevelClient{c, min}
+}
+
+type minLevelClient struct {
+	Client
+	min sentry.Level
+}
+
+func (c minLevelClient) CaptureEvent(event *sentry.Event, hint *sentry.EventHint, scope sentry.EventModifier) *sentry.EventID {
+	if !atLeast(event.Level, c.min) {
+		return nil
+	}
+	return c.Client.CaptureEvent(event, hint, scope)
+}
+
+// Flush waits until the events buffered by the Sentry client and every tee
+// client have been delivered, or until the timeout elapses. Events queued for
+// asynchronous delivery are captured first. Clients are flushed concurrently
+// and the result is true only if every one of them was drained within the
+// timeout.
+func (a *Alerter) Flush(timeout time.Duration) bool {
+	if a.async != nil {
+		start := time.Now()
+		if !a.async.Drain(timeout) {
+			return false
+		}
+		timeout -= time.Since(start)
+	}
+	clients := make([]Client, 0, len(a.tee)+1)
+	if a.sentry != nil {
+		clients = append(clients, a.sentry)
+	}
+	return flushClients(append(clients, a.tee...), timeout)
+}
+
+// flushClients flushes the clients concurrently and reports whether every
+// one of them was drained within the timeout.
+func flushClients(clients []Client, timeout time.Duration) bool {
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		res = true
+	)
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c Client) {
+			defer wg.Done()
+			if !c.Flush(timeout) {
+				mu.Lock()
+				res = false
+				mu.Unlock()
+			}
+		}(c)
+	}
+	wg.Wait()
+	return res
+}
@@ -0,0 +1,129 @@
+package alert
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestTee(t *testing.T) {
+	// a client bound to the global hub must not receive anything
+	global, gtr := newClient(t)
+	prev := sentry.CurrentHub().Client()
+	sentry.CurrentHub().BindClient(global)
+	t.Cleanup(func() { sentry.CurrentHub().BindClient(prev) })
+
+	first, ftr := newClient(t)
+	second, str := newClient(t)
+	a, tr := newAlerter(t, Config{Tee: []Client{first, second}})
+	a.Error(errors.New("Failed"), WithTags(Tags{"key": "value"}))
+
+	for name, e := range map[string]*transport{"primary": tr, "first": ftr, "second": str} {
+		events := e.Events()
+		if len(events) != 1 {
+			t.Errorf("Expected the %s client to receive the event; got %d events", name, len(events))
+			continue
+		}
+		if v := events[0].Tags["key"]; v != "value" {
+			t.Errorf("Expected the %s client to receive the scope of the alert; got tag %q", name, v)
+		}
+	}
+	if n := len(gtr.Events()); n != 0 {
+		t.Errorf("Expected no events to be delivered via the global hub; got %d", n)
+	}
+}
+
+func TestTeeCopies(t *testing.T) {
+	first, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:       testDSN,
+		Transport: &transport{},
+		BeforeSend: func(e *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+			e.Tags["key"] = "modified"
+			e.Extra["extra"] = "modified"
+			if c := e.Contexts["build"]; c != nil {
+				c["modified"] = true
+			}
+			return e
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, str := newClient(t)
+	a, tr := newAlerter(t, Config{Tee: []Client{first, second}})
+	a.Error(errors.New("Failed"), WithTags(Tags{"key": "value"}), WithExtra(map[string]interface{}{"extra": "value"}))
+
+	for name, e := range map[string]*transport{"primary": tr, "second": str} {
+		event := e.Event(t)
+		if v := event.Tags["key"]; v != "value" {
+			t.Errorf("Expected the tags of the %s client to be unaffected by the first; got %q", name, v)
+		}
+		if v := event.Extra["extra"]; v != "value" {
+			t.Errorf("Expected the extra of the %s client to be unaffected by the first; got %v", name, v)
+		}
+		if _, ok := event.Contexts["build"]["modified"]; ok {
+			t.Errorf("Expected the contexts of the %s client to be unaffected by the first", name)
+		}
+	}
+}
+
+func TestTeeMinLevel(t *testing.T) {
+	errorsOnly, etr := newClient(t)
+	a, tr := newAlerter(t, Config{Tee: []Client{MinLevel(errorsOnly, sentry.LevelError)}})
+	a.Warning(errors.New("Degraded"))
+	a.Error(errors.New("Failed"))
+
+	if n := len(tr.Events()); n != 2 {
+		t.Errorf("Expected the primary client to receive both events; got %d", n)
+	}
+	if event := etr.Event(t); event.Level != sentry.LevelError {
+		t.Errorf("Expected only the error to reach the tee client; got %s", event.Level)
+	}
+}
+
+func TestFlushTee(t *testing.T) {
+	tee, ttr := newClient(t)
+	a, tr := newAlerter(t, Config{Tee: []Client{tee}})
+	if !a.Flush(time.Second) {
+		t.Error("Expected the clients to be flushed")
+	}
+	ttr.stalled = true
+	if a.Flush(time.Second) {
+		t.Error("Expected the flush to fail when a tee client cannot be flushed")
+	}
+	if tr.flushes != 2 || ttr.flushes != 2 {
+		t.Errorf("Expected every client to be flushed each time; got %d and %d", tr.flushes, ttr.flushes)
+	}
+}
+
+func TestSeverityDestinations(t *testing.T) {
+	errorsOnly, etr := newClient(t)
+	fatalOnly, ftr := newClient(t)
+	a, tr := newAlerter(t, Config{
+		MinLevel: sentry.LevelWarning,
+		Tee:      []Client{MinLevel(errorsOnly, sentry.LevelError), MinLevel(fatalOnly, sentry.LevelFatal)},
+	})
+	for _, lvl := range []sentry.Level{sentry.LevelInfo, sentry.LevelWarning, sentry.LevelError, sentry.LevelFatal} {
+		a.Error(errors.New("Failed at "+string(lvl)), WithLevel(lvl))
+	}
+
+	for name, e := range map[string]struct {
+		tr     *transport
+		expect []sentry.Level
+	}{
+		"primary": {tr, []sentry.Level{sentry.LevelWarning, sentry.LevelError, sentry.LevelFatal}},
+		"errors":  {etr, []sentry.Level{sentry.LevelError, sentry.LevelFatal}},
+		"fatal":   {ftr, []sentry.Level{sentry.LevelFatal}},
+	} {
+		var levels []sentry.Level
+		for _, v := range e.tr.Events() {
+			levels = append(levels, v.Level)
+		}
+		if !slices.Equal(levels, e.expect) {
+			t.Errorf("Expected the %s client to receive %v; got %v", name, e.expect, levels)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The maximum time Close waits for close callbacks to complete and for
+// buffered events to be delivered.
+const closeTimeout = 5 * time.Second
+
+var ErrCloseTimeout = errors.New("Timed out closing")
+
+// FlushAndReport flushes buffered events and then writes a short summary of
+// the alerts raised over the lifetime of the alerter to the writer. This is
+// intended to be deferred at the end of a CLI command or batch job, e.g.:
+//
+//	defer a.FlushAndReport(os.Stderr, 5*time.Second)
+func (a *Alerter) FlushAndReport(w io.Writer, timeout time.Duration) {
+	flushed := a.Flush(timeout)
+	fmt.Fprintf(w, "alert: %s\n", a.stats.Summary())
+	if !flushed {
+		fmt.Fprintf(w, "alert: timed out after %v delivering buffered events\n", timeout)
+	}
+}
+
+// OnClose registers a function to be invoked when the alerter is closed.
+// Functions are invoked in the reverse order they were registered, before
+// buffered events are flushed, which gives anything that produces alerts a
+// chance to drain first; see AfterClose.
+func (a *Alerter) OnClose(fn func()) {
+	a.closeLock.Lock()
+	defer a.closeLock.Unlock()
+	a.onClose = append(a.onClose, fn)
+}
+
+// AfterClose registers a function to be invoked when the alerter is closed,
+// after buffered events have been flushed and before the alerter is detached
+// from its clients. This is where backends which must be closed, such as
+// those which collect digests, should be closed, so that the events queued
+// for them are delivered first. Functions are invoked in the reverse order
+// they were registered, whether or not the flush completes.
+func (a *Alerter) AfterClose(fn func()) {
+	a.closeLock.Lock()
+	defer a.closeLock.Unlock()
+	a.afterClose = append(a.afterClose, fn)
+}
+
+// Close invokes the registered close functions, flushes buffered events,
+// invokes the functions registered via AfterClose, and then detaches the
+// alerter from its clients, unbinding its Sentry client
+// from its hub. Alerts raised after the alerter is closed are only
+// logged.
+//
+// Close does not wait indefinitely: if the close functions and the flush
+// together take longer than the close timeout, ErrCloseTimeout is returned.
+// Close functions which are still running at that point are abandoned, not
+// interrupted. The alerter is detached from its clients regardless.
+func (a *Alerter) Close() error {
+	defer a.detach()
+	a.closeLock.Lock()
+	fns, after := a.onClose, a.afterClose
+	a.onClose, a.afterClose = nil, nil
+	a.closeLock.Unlock()
+
+	deadline := time.Now().Add(closeTimeout)
+	if !invokeBefore(fns, deadline) {
+		return ErrCloseTimeout
+	}
+	flushed := a.Flush(time.Until(deadline))
+	if !invokeBefore(after, deadline) || !flushed {
+		return ErrCloseTimeout
+	}
+	return nil
+}
+
+// invokeBefore invokes functions in the reverse order they are provided and
+// reports whether they completed before the deadline. Functions which are
+// still running at the deadline are abandoned.
+func invokeBefore(fns []func(), deadline time.Time) bool {
+	if len(fns) == 0 {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := len(fns) - 1; i >= 0; i-- {
+			fns[i]()
+		}
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
+}
+
+// detach prevents the alerter from delivering further events and unbinds its
+// Sentry client from its hub.
+func (a *Alerter) detach() {
+	a.closed.Store(true)
+	if a.async != nil {
+		a.async.Stop()
+	}
+	a.hub.BindClient(nil)
+}
+
+// Flush waits until the events buffered by the shared alerter have been
+// delivered or the timeout elapses, and reports whether they were delivered.
+func Flush(timeout time.Duration) bool {
+	if a := shared.Load(); a != nil {
+		return a.Flush(timeout)
+	}
+	return true
+}
+
+// Close closes the shared alerter, if it has been initialized, and resets it
+// so that it may be initialized again. See Alerter.Close.
+func Close() error {
+	lock.Lock()
+	defer lock.Unlock()
+	a := shared.Swap(nil)
+	if a == nil {
+		return nil
+	}
+	return a.Close()
+}
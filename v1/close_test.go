@@ -0,0 +1,141 @@
+package alert
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnClose(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	var order []int
+	for i := 0; i < 3; i++ {
+		a.OnClose(func() {
+			order = append(order, i)
+			if i == 0 && tr.flushes > 0 {
+				t.Error("Expected close functions to run before the flush")
+			}
+		})
+	}
+	a.OnClose(func() {
+		a.Error(errors.New("Draining"))
+	})
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{2, 1, 0}; !slices.Equal(order, want) {
+		t.Errorf("Expected close functions to run in reverse order %v; got %v", want, order)
+	}
+	if n := len(tr.Events()); n != 1 {
+		t.Errorf("Expected an alert raised by a close function to be delivered; got %d events", n)
+	}
+	if tr.flushes != 1 {
+		t.Errorf("Expected the client to be flushed once; got %d", tr.flushes)
+	}
+
+	a.Error(errors.New("Closed"))
+	if n := len(tr.Events()); n != 1 {
+		t.Errorf("Expected alerts raised once closed not to be delivered; got %d events", n)
+	}
+}
+
+// slowBackend is a backend which takes its time delivering alerts.
+type slowBackend struct {
+	backend
+}
+
+func (b *slowBackend) Capture(e *Event) error {
+	time.Sleep(10 * time.Millisecond)
+	return b.backend.Capture(e)
+}
+
+func TestAfterClose(t *testing.T) {
+	b := &slowBackend{}
+	a, tr := newAlerter(t, Config{Async: Async{Buffer: 10}, Backends: []Backend{b}})
+	var order []int
+	var delivered int
+	for i := 0; i < 2; i++ {
+		a.AfterClose(func() {
+			order = append(order, i)
+			delivered = len(b.Events())
+		})
+	}
+	a.OnClose(func() {
+		if len(order) > 0 {
+			t.Error("Expected close functions to run before those registered via AfterClose")
+		}
+	})
+	for i := 0; i < 3; i++ {
+		a.Error(errors.New("Failed"))
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 0}; !slices.Equal(order, want) {
+		t.Errorf("Expected functions to run in reverse order %v; got %v", want, order)
+	}
+	if delivered != 3 {
+		t.Errorf("Expected queued alerts to be delivered before functions registered via AfterClose run; got %d of 3", delivered)
+	}
+	if tr.flushes != 1 {
+		t.Errorf("Expected the client to be flushed once; got %d", tr.flushes)
+	}
+}
+
+func TestFlushAndReport(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Failed"))
+	a.Error(errors.New("Failed again"))
+	a.Warn(errors.New("Slow"))
+
+	buf := &strings.Builder{}
+	a.FlushAndReport(buf, time.Second)
+	if tr.flushes != 1 {
+		t.Errorf("Expected buffered events to be flushed once; got %d", tr.flushes)
+	}
+	if v, want := buf.String(), "alert: 3 alerts (2 error, 1 warning); 3 sent\n"; v != want {
+		t.Errorf("Expected the summary %q; got %q", want, v)
+	}
+
+	tr.stalled = true
+	buf.Reset()
+	a.FlushAndReport(buf, time.Second)
+	if v := buf.String(); !strings.Contains(v, "timed out after 1s") {
+		t.Errorf("Expected the summary to report the flush timing out; got %q", v)
+	}
+}
+
+func TestClose(t *testing.T) {
+	log, recs := newLogger()
+	a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log})
+	a.Error(errors.New("Failed"))
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if tr.flushes != 1 {
+		t.Errorf("Expected closing to flush buffered events; got %d flushes", tr.flushes)
+	}
+
+	if id := a.Error(errors.New("Failed again")); id != nil {
+		t.Errorf("Expected no event to be captured once closed; got %v", *id)
+	}
+	if n := len(tr.Events()); n != 1 {
+		t.Errorf("Expected the client to be cleared once closed; got %d events", n)
+	}
+	if n := len(recs.Records(t)); n != 2 {
+		t.Errorf("Expected alerts raised once closed to still be logged; got %d records", n)
+	}
+
+	a, tr = newAlerter(t, Config{})
+	tr.stalled = true
+	if a.Flush(time.Second) {
+		t.Error("Expected the flush to report that buffered events were not delivered")
+	}
+	if err := a.Close(); !errors.Is(err, ErrCloseTimeout) {
+		t.Errorf("Expected %v; got %v", ErrCloseTimeout, err)
+	}
+}
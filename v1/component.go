@@ -0,0 +1,31 @@
+package alert
+
+import (
+	"math/rand/v2"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ComponentPolicy describes how the alerts raised by a particular component
+// are reported. The zero value reports every alert.
+type ComponentPolicy struct {
+	// Tags are applied to every alert raised by the component. Tags provided
+	// when an alert is raised take precedence.
+	Tags Tags
+	// MinLevel is the least severe level of alert reported to Sentry. Less
+	// severe alerts are still logged.
+	MinLevel sentry.Level
+	// SampleRate is the proportion of alerts, between 0 and 1, which are
+	// reported to Sentry. A rate of 0 or of 1 or more reports every alert.
+	// Fatal alerts are always reported.
+	SampleRate float64
+}
+
+// sample determines whether an alert should be reported under a sample rate.
+// A rate of 0 or of 1 or more reports every alert.
+func sample(rate float64) bool {
+	if rate <= 0 || rate >= 1 {
+		return true
+	}
+	return rand.Float64() < rate
+}
@@ -0,0 +1,46 @@
+package alert
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestComponentPolicies(t *testing.T) {
+	a, tr := newAlerter(t, Config{Components: map[string]ComponentPolicy{
+		"api":    {MinLevel: sentry.LevelError, Tags: Tags{"team": "platform"}},
+		"worker": {MinLevel: sentry.LevelWarning, Tags: Tags{"team": "jobs"}},
+	}})
+	for _, component := range []string{"api", "worker"} {
+		a.Warning(errors.New("Degraded"), WithComponent(component))
+		a.Error(errors.New("Failed"), WithComponent(component), WithTags(Tags{"team": "caller"}))
+	}
+
+	var sent []string
+	for _, e := range tr.Events() {
+		sent = append(sent, e.Tags["component"]+" "+string(e.Level)+" "+e.Tags["team"])
+	}
+	want := []string{"api error caller", "worker warning jobs", "worker error caller"}
+	if !slices.Equal(sent, want) {
+		t.Errorf("Expected %v to be sent; got %v", want, sent)
+	}
+}
+
+func TestSample(t *testing.T) {
+	for _, rate := range []float64{0, 1, 2} {
+		if !sample(rate) {
+			t.Errorf("Expected every alert to be reported at the rate %v", rate)
+		}
+	}
+	var n int
+	for i := 0; i < 1000; i++ {
+		if sample(0.1) {
+			n++
+		}
+	}
+	if n == 0 || n > 300 {
+		t.Errorf("Expected about a tenth of alerts to be reported at the rate 0.1; got %d in 1000", n)
+	}
+}
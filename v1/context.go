@@ -0,0 +1,109 @@
+package alert
+
+import (
+	"context"
+	"maps"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type (
+	alerterKey struct{}
+	tagsKey    struct{}
+	extraKey   struct{}
+)
+
+// NewContext produces a context which carries the alerter, so that alerts
+// raised via the package-level ErrorContext in that context are reported by
+// it rather than by the shared alerter.
+func NewContext(cxt context.Context, a *Alerter) context.Context {
+	return context.WithValue(cxt, alerterKey{}, a)
+}
+
+// FromContext produces the alerter carried by the context, if any.
+func FromContext(cxt context.Context) *Alerter {
+	a, _ := cxt.Value(alerterKey{}).(*Alerter)
+	return a
+}
+
+// ContextWithTags produces a context which carries the tags, in addition to
+// any already carried by the parent, which take precedence where keys
+// collide. Alerts raised in the context inherit them; tags provided via
+// WithTags take precedence over those inherited.
+//
+// An alert is raised in a context when it is provided via WithContext, or is
+// that of the request provided via WithRequest. Middleware can thus attach
+// request-scoped tags once, rather than each caller providing them.
+func ContextWithTags(cxt context.Context, tags Tags) context.Context {
+	merged := maps.Clone(TagsFromContext(cxt))
+	if merged == nil {
+		merged = make(Tags, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return context.WithValue(cxt, tagsKey{}, merged)
+}
+
+// TagsFromContext produces the tags carried by the context, if any. The
+// result must not be modified.
+func TagsFromContext(cxt context.Context) Tags {
+	t, _ := cxt.Value(tagsKey{}).(Tags)
+	return t
+}
+
+// ContextWithExtra produces a context which carries the extra values, in
+// the manner of ContextWithTags.
+func ContextWithExtra(cxt context.Context, extra map[string]interface{}) context.Context {
+	merged := maps.Clone(ExtraFromContext(cxt))
+	if merged == nil {
+		merged = make(map[string]interface{}, len(extra))
+	}
+	maps.Copy(merged, extra)
+	return context.WithValue(cxt, extraKey{}, merged)
+}
+
+// ExtraFromContext produces the extra values carried by the context, if
+// any. The result must not be modified.
+func ExtraFromContext(cxt context.Context) map[string]interface{} {
+	e, _ := cxt.Value(extraKey{}).(map[string]interface{})
+	return e
+}
+
+// ResolveContext produces the context which describes an alert raised with
+// the options, as the alerter resolves it, including the tags and extra
+// values inherited from the context.Context it is raised in. This is intended
+// for test doubles, such as alerttest.Recorder.
+func ResolveContext(opts ...Option) Context {
+	return newContext(opts).inherit()
+}
+
+// inherit merges the tags and extra values carried by the context the alert
+// is raised in beneath those provided by the caller.
+func (c Context) inherit() Context {
+	g := c.goContext()
+	if t := TagsFromContext(g); len(t) > 0 {
+		merged := maps.Clone(t)
+		maps.Copy(merged, c.Tags)
+		c.Tags = merged
+	}
+	if e := ExtraFromContext(g); len(e) > 0 {
+		merged := maps.Clone(e)
+		maps.Copy(merged, c.Extra)
+		c.Extra = merged
+	}
+	return c
+}
+
+// ErrorContext reports an error raised in the context; it is equivalent to
+// Error with the context provided via WithContext.
+func (a *Alerter) ErrorContext(cxt context.Context, err error, opts ...Option) *sentry.EventID {
+	return a.Error(err, append([]Option{WithContext(cxt)}, opts...)...)
+}
+
+// ErrorContext reports an error raised in the context via the alerter the
+// context carries, if any, and otherwise as Error does.
+func ErrorContext(cxt context.Context, err error, opts ...Option) *sentry.EventID {
+	if a := FromContext(cxt); a != nil {
+		return a.ErrorContext(cxt, err, opts...)
+	}
+	return Error(err, append([]Option{WithContext(cxt)}, opts...)...)
+}
@@ -0,0 +1,87 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Crashloop describes how an alerter detects a crash loop: a burst of fatal
+// alerts, such as those raised for recovered panics, in a short interval.
+// When at least Threshold fatal alerts are raised within Window, a distinct,
+// urgent CrashloopError is reported, once per window. If Exit is set the
+// process then exits with status 2, failing fast rather than continuing to
+// crash.
+//
+// The zero value disables detection.
+type Crashloop struct {
+	Threshold int
+	Window    time.Duration
+	Exit      bool
+}
+
+func (c Crashloop) enabled() bool {
+	return c.Threshold > 0 && c.Window > 0
+}
+
+// CrashloopError is reported when a crash loop is detected.
+type CrashloopError struct {
+	Count  int
+	Window time.Duration
+}
+
+func (e CrashloopError) Error() string {
+	return fmt.Sprintf("Crash loop: %d fatal errors within %v", e.Count, e.Window)
+}
+
+// crashloopKey identifies the entry for fatal alerts in the recent buffer,
+// where they are tracked alongside the errors an alerter reports. It begins
+// with separators, so it does not collide with the fingerprint of an error.
+const crashloopKey = "\x00\x00crashloop"
+
+// observe records a fatal alert at the specified time in the recent buffer
+// and determines whether it completes a crash loop which should be reported.
+// Only the most recent Threshold fatal alerts are retained, which is enough
+// to determine whether that many were raised within the window.
+func (c Crashloop) observe(r *recent, now time.Time) (int, bool) {
+	var (
+		count   int
+		looping bool
+	)
+	r.Observe(crashloopKey, now, func(e *occurrence, now time.Time) {
+		since := now.Add(-c.Window)
+		n := 0
+		for _, t := range e.fatals {
+			if t.After(since) {
+				e.fatals[n] = t
+				n++
+			}
+		}
+		e.fatals = append(e.fatals[:n], now)
+		if n := len(e.fatals); n > c.Threshold {
+			e.fatals = append(e.fatals[:0], e.fatals[n-c.Threshold:]...)
+		}
+		count = len(e.fatals)
+		if count < c.Threshold || (!e.looped.IsZero() && e.looped.After(since)) {
+			return
+		}
+		e.looped, looping = now, true
+	})
+	return count, looping
+}
+
+// reportCrashloop reports a detected crash loop and, if so configured, exits.
+func (a *Alerter) reportCrashloop(count int, opts ...Option) {
+	opts = append([]Option{
+		WithLevel(sentry.LevelFatal),
+		WithPriority(PriorityUrgent),
+		WithFingerprint("crashloop"),
+	}, opts...)
+	opts = append(opts, mergeTags(Tags{"crashloop": true}))
+	a.report(CrashloopError{Count: count, Window: a.crashloop.Window}, opts...)
+	if a.crashloop.Exit {
+		a.Flush(panicFlushTimeout)
+		exit(2)
+	}
+}
@@ -0,0 +1,104 @@
+package alert
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCrashloop(t *testing.T) {
+	clock := newClock()
+	a, tr := newAlerter(t, Config{Clock: clock.Now, Crashloop: Crashloop{Threshold: 3, Window: time.Minute}})
+	crash := func() {
+		defer a.Recover()
+		panic("Failed")
+	}
+	crashloops := func() int {
+		var n int
+		for _, e := range tr.Events() {
+			if e.Tags["crashloop"] == "true" {
+				n++
+			}
+		}
+		return n
+	}
+
+	for i := 0; i < 2; i++ {
+		crash()
+		clock.Advance(10 * time.Second)
+	}
+	a.Error(errors.New("Not fatal"))
+	if n := crashloops(); n != 0 {
+		t.Fatalf("Expected no crash loop below the threshold; got %d", n)
+	}
+	crash()
+	if n := crashloops(); n != 1 {
+		t.Fatalf("Expected rapid fatals to report a crash loop; got %d", n)
+	}
+	events := tr.Events()
+	loop := events[len(events)-1]
+	if err, want := loop.Exception[len(loop.Exception)-1].Value, (CrashloopError{Count: 3, Window: time.Minute}).Error(); err != want {
+		t.Errorf("Expected %q; got %q", want, err)
+	}
+	if v := loop.Tags["priority"]; v != string(PriorityUrgent) {
+		t.Errorf("Expected the crash loop to be urgent; got %q", v)
+	}
+	if v := loop.Fingerprint; len(v) != 1 || v[0] != "crashloop" {
+		t.Errorf("Expected crash loops to be grouped together; got %v", v)
+	}
+
+	// once per window
+	crash()
+	if n := crashloops(); n != 1 {
+		t.Errorf("Expected a crash loop to be reported once per window; got %d", n)
+	}
+	clock.Advance(2 * time.Minute)
+	crash()
+	if n := crashloops(); n != 1 {
+		t.Errorf("Expected fatals spread out beyond the window not to report a crash loop; got %d", n)
+	}
+	crash()
+	crash()
+	if n := crashloops(); n != 2 {
+		t.Errorf("Expected another burst to report a crash loop again; got %d", n)
+	}
+}
+
+func TestCrashloopExit(t *testing.T) {
+	status := -1
+	exit = func(code int) { status = code }
+	defer func() { exit = os.Exit }()
+
+	a, tr := newAlerter(t, Config{Crashloop: Crashloop{Threshold: 2, Window: time.Minute, Exit: true}})
+	a.Fatal(errors.New("Failed"))
+	if status != -1 {
+		t.Fatalf("Expected not to exit below the threshold; got status %d", status)
+	}
+	a.Fatal(errors.New("Failed"))
+	if status != 2 {
+		t.Errorf("Expected to exit with status 2; got %d", status)
+	}
+	if tr.flushes != 1 {
+		t.Errorf("Expected the crash loop to be flushed before exiting; got %d flushes", tr.flushes)
+	}
+}
+
+func TestCrashloopBounded(t *testing.T) {
+	c := Crashloop{Threshold: 3, Window: time.Minute}
+	r := newRecent(0)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var loops int
+	for i := 0; i < 100; i++ {
+		if _, looping := c.observe(r, now); looping {
+			loops++
+		}
+	}
+	if loops != 1 {
+		t.Errorf("Expected a crash loop to be reported once per window; got %d", loops)
+	}
+	occ := r.Observe(crashloopKey, now, nil)
+	if n := len(occ.fatals); n != c.Threshold {
+		t.Errorf("Expected only the most recent %d fatal alerts to be retained; got %d", c.Threshold, n)
+	}
+}
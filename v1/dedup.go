@@ -0,0 +1,104 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+)
+
+// Dedup describes how bursts of the same error are collapsed: an error is
+// reported at most Burst times per Window, and occurrences beyond that are
+// suppressed. The number of occurrences suppressed is attached as the extra
+// "suppressed" to the next report of the error.
+//
+// If Summary is positive, a warning which summarizes the occurrences
+// suppressed since the previous summary is also reported at that interval,
+// and once more when the alerter is closed, so that errors which stop after
+// a burst are still accounted for. The summary is described by Rollup, and
+// occurrences it accounts for are not attached to the next report of the
+// error.
+//
+// Errors are the same if they have the same reference and message. The
+// zero value disables deduplication.
+type Dedup struct {
+	Window  time.Duration
+	Burst   int
+	Summary time.Duration
+}
+
+func (d Dedup) enabled() bool {
+	return d.Window > 0
+}
+
+// admit determines whether an occurrence of an error should be reported,
+// updating its state accordingly. It is intended to be used as an update
+// function for recent.Observe.
+func (d Dedup) admit(e *occurrence, now time.Time) bool {
+	if e.dedupStart.IsZero() || now.Sub(e.dedupStart) >= d.Window {
+		e.dedupStart, e.dedupSent = now, 0
+	}
+	if e.dedupSent >= max(d.Burst, 1) {
+		e.Suppressed++
+		return false
+	}
+	e.dedupSent++
+	return true
+}
+
+// summarizeEvery periodically reports a summary of the suppressed occurrences
+// of errors until the alerter is closed.
+func (a *Alerter) summarizeEvery(interval time.Duration) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	a.OnClose(func() {
+		close(done)
+		<-stopped
+		a.reportSuppressed()
+	})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				a.reportSuppressed()
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// suppressedError summarizes the number of occurrences of errors which have
+// been suppressed. It is never suppressed itself.
+type suppressedError int
+
+func (e suppressedError) Error() string {
+	return fmt.Sprintf("Suppressed %d duplicate alerts", int(e))
+}
+
+// reportSuppressed reports a summary of the occurrences of errors that have
+// been suppressed since the previous rollup, if there are any.
+func (a *Alerter) reportSuppressed() {
+	rollup := a.Rollup()
+	if len(rollup) == 0 {
+		return
+	}
+	var n int
+	errs := make([]map[string]interface{}, len(rollup))
+	for i, e := range rollup {
+		n += e.Since
+		errs[i] = map[string]interface{}{
+			"ref":        e.Ref,
+			"message":    e.Message,
+			"suppressed": e.Since,
+			"count":      e.Count,
+			"first_seen": e.First,
+			"last_seen":  e.Last,
+		}
+	}
+	a.Warning(suppressedError(n),
+		WithFingerprint("alert", "suppressed"),
+		WithExtra(map[string]interface{}{"suppressed": errs}),
+	)
+}
@@ -0,0 +1,86 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// The maximum length of each side of a rendered diff, in bytes.
+const maxDiffLength = 2048
+
+// Diff describes an expected and an actual value which differ.
+type Diff struct {
+	Expected interface{}
+	Actual   interface{}
+}
+
+// extra produces the extra section which describes the mismatch: a rendering
+// of each value, with the scrubber applied to it, and a comparison of them in
+// the style of a unified diff. Only the renderings are attached, and they are
+// truncated, so that a large value cannot bloat the event.
+func (m Diff) extra(s Scrubber) map[string]interface{} {
+	expected := renderValue(s, "expected", m.Expected)
+	actual := renderValue(s, "actual", m.Actual)
+	return map[string]interface{}{
+		"expected": expected,
+		"actual":   actual,
+		"diff":     fmt.Sprintf("- %s\n+ %s", expected, actual),
+	}
+}
+
+// renderValue produces the representation of a value which is attached to an
+// event, truncated to the maximum length of a diff: its JSON encoding, with
+// the scrubber applied to the fields of any objects it contains, or else its
+// default format.
+func renderValue(s Scrubber, key string, v interface{}) string {
+	v, ok := s(key, v)
+	if !ok {
+		return redacted
+	}
+	text := fmt.Sprintf("%+v", v)
+	if data, err := json.Marshal(v); err == nil {
+		var generic interface{}
+		if json.Unmarshal(data, &generic) == nil {
+			if data, err = json.Marshal(scrubValue(s, generic)); err == nil {
+				text = string(data)
+			}
+		}
+	}
+	return truncate(text, maxDiffLength)
+}
+
+// scrubValue applies the scrubber to the fields of the objects within a value
+// decoded from JSON.
+func scrubValue(s Scrubber, v interface{}) interface{} {
+	switch c := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(c))
+		for k, e := range c {
+			if e, ok := s(k, e); ok {
+				res[k] = scrubValue(s, e)
+			}
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(c))
+		for i, e := range c {
+			res[i] = scrubValue(s, e)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// truncate shortens s to at most n bytes, without splitting a rune, noting
+// that it was truncated.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "... (truncated)"
+}
@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWithDiff(t *testing.T) {
+	type account struct {
+		ID       int    `json:"id"`
+		Balance  int    `json:"balance"`
+		Password string `json:"password"`
+	}
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Balance mismatch"), WithDiff(account{1, 100, "hunter2"}, account{1, 90, "hunter2"}))
+
+	diff, ok := tr.Event(t).Extra["diff"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected the diff in extra; got %#v", tr.Event(t).Extra)
+	}
+	expected, _ := diff["expected"].(string)
+	actual, _ := diff["actual"].(string)
+	if !strings.Contains(expected, `"balance":100`) || !strings.Contains(actual, `"balance":90`) {
+		t.Errorf("Expected the expected and actual values; got %q and %q", expected, actual)
+	}
+	if v, _ := diff["diff"].(string); v != "- "+expected+"\n+ "+actual {
+		t.Errorf("Expected the comparison of the values; got %q", v)
+	}
+	for _, v := range []string{expected, actual} {
+		if strings.Contains(v, "hunter2") {
+			t.Errorf("Expected the values to be scrubbed; got %q", v)
+		}
+	}
+}
+
+func TestWithDiffTruncated(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Mismatch"), WithDiff(strings.Repeat("é", maxDiffLength), "short"))
+
+	diff := tr.Event(t).Extra["diff"].(map[string]interface{})
+	expected := diff["expected"].(string)
+	if !strings.HasSuffix(expected, "... (truncated)") || len(expected) > maxDiffLength+len("... (truncated)") {
+		t.Errorf("Expected the value to be truncated; got %d bytes", len(expected))
+	}
+	if !utf8.ValidString(expected) {
+		t.Error("Expected the value to be truncated on a rune boundary")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in     string
+		n      int
+		expect string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"truncated", 5, "trunc... (truncated)"},
+		{"héllo", 2, "h... (truncated)"},
+		{"日本語", 4, "日... (truncated)"},
+	}
+	for _, e := range tests {
+		if v := truncate(e.in, e.n); v != e.expect {
+			t.Errorf("Expected truncate(%q, %d) to be %q; got %q", e.in, e.n, e.expect, v)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package alert
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// The number of occurrences of an error whose extras are sampled in a digest.
+const digestSamples = 3
+
+// Digest collects less severe alerts, which are often noisy, into periodic
+// digests rather than reporting each of them: alerts no more severe than
+// MaxLevel, which defaults to warning, are aggregated in memory and every
+// Interval a single DigestError is reported for each distinct error, e.g.:
+//
+//	Digest: alert.Digest{Interval: 15 * time.Minute}
+//
+// A digest describes the number of occurrences of the error, when it was
+// first and last seen, and the extras of a sample of its occurrences. It is
+// reported with the options the first occurrence was raised with, at its
+// level. More severe alerts are reported immediately, as usual. Digested
+// alerts are still logged, and any digests which remain when the alerter is
+// closed are reported.
+//
+// The zero value does not digest alerts.
+type Digest struct {
+	Interval time.Duration
+	MaxLevel Level
+}
+
+func (d Digest) enabled() bool {
+	return d.Interval > 0
+}
+
+// admit determines whether an alert at the specified level is collected into
+// a digest.
+func (d Digest) admit(lvl Level) bool {
+	limit := d.MaxLevel
+	if limit == "" {
+		limit = LevelWarning
+	}
+	return atLeast(limit, lvl)
+}
+
+// DigestError is reported for an error whose occurrences were collected into
+// a digest; see Digest.
+type DigestError struct {
+	Err   error
+	Count int
+	First time.Time
+	Last  time.Time
+}
+
+func (e DigestError) Error() string {
+	return fmt.Sprintf("%d occurrences since %s: %v", e.Count, e.First.Format(time.RFC3339), e.Err)
+}
+
+func (e DigestError) Unwrap() error {
+	return e.Err
+}
+
+// Fingerprint groups every digest of an error together, since the message
+// of each differs.
+func (e DigestError) Fingerprint() []string {
+	if parts, ok := errorFingerprint(e.Err); ok {
+		return parts
+	}
+	return []string{"digest", e.Err.Error()}
+}
+
+// digestEntry accumulates the occurrences of an error between flushes.
+type digestEntry struct {
+	err     error
+	level   Level
+	opts    []Option
+	count   int
+	first   time.Time
+	last    time.Time
+	samples []map[string]interface{}
+}
+
+// digests accumulates the alerts collected into digests, by fingerprint.
+type digests struct {
+	sync.Mutex
+	entries map[string]*digestEntry
+}
+
+// Add records an occurrence of an error.
+func (d *digests) Add(key string, err error, lvl Level, extra map[string]interface{}, opts []Option, now time.Time) {
+	d.Lock()
+	defer d.Unlock()
+	if d.entries == nil {
+		d.entries = make(map[string]*digestEntry)
+	}
+	e, ok := d.entries[key]
+	if !ok {
+		e = &digestEntry{err: err, level: lvl, opts: opts, first: now}
+		d.entries[key] = e
+	}
+	e.count++
+	e.last = now
+	if len(extra) > 0 && len(e.samples) < digestSamples {
+		e.samples = append(e.samples, maps.Clone(extra))
+	}
+}
+
+// Drain produces the accumulated entries, ordered by when they were first
+// seen, and resets them.
+func (d *digests) Drain() []*digestEntry {
+	d.Lock()
+	entries := d.entries
+	d.entries = nil
+	d.Unlock()
+	res := make([]*digestEntry, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, e)
+	}
+	slices.SortFunc(res, func(a, b *digestEntry) int {
+		return a.first.Compare(b.first)
+	})
+	return res
+}
+
+// digestEvery periodically reports the accumulated digests until the
+// alerter is closed.
+func (a *Alerter) digestEvery(interval time.Duration) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	a.OnClose(func() {
+		close(done)
+		<-stopped
+		a.reportDigests()
+	})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				a.reportDigests()
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// reportDigests reports a digest of each error accumulated since the
+// previous flush.
+func (a *Alerter) reportDigests() {
+	for _, e := range a.digests.Drain() {
+		digest := map[string]interface{}{
+			"count":      e.count,
+			"first_seen": e.first.Format(time.RFC3339),
+			"last_seen":  e.last.Format(time.RFC3339),
+		}
+		if len(e.samples) > 0 {
+			digest["samples"] = e.samples
+		}
+		opts := append(slices.Clip(e.opts), WithLevel(e.level), WithSentryLevel(e.level), mergeTags(Tags{"digest": true}), mergeExtra(map[string]interface{}{"digest": digest}))
+		a.report(DigestError{Err: e.err, Count: e.count, First: e.first, Last: e.last}, opts...)
+	}
+}
@@ -0,0 +1,124 @@
+package alert
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDigest(t *testing.T) {
+	c, b, m := newClock(), &backend{}, &metrics{}
+	start := c.Now()
+	// the interval never elapses during the test; digests are reported
+	// explicitly
+	a, err := New(Config{Clock: c.Now, Backends: []Backend{b}, Metrics: m, Digest: Digest{Interval: time.Hour}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	for i := 0; i < 5; i++ {
+		a.Warning(errors.New("Slow query"), WithExtra(map[string]interface{}{"query": i}))
+		c.Advance(time.Minute)
+	}
+	a.Info(errors.New("Cache miss"))
+	a.Error(errors.New("Failed"))
+	a.Fatal(errors.New("Out of memory"))
+
+	events := b.Events()
+	if len(events) != 2 || events[0].Level != LevelError || events[1].Level != LevelFatal {
+		t.Fatalf("Expected only errors and fatal alerts to be reported immediately; got %d", len(events))
+	}
+	if v, want := m.Outcomes(), []Outcome{OutcomeDigested, OutcomeDigested, OutcomeDigested, OutcomeDigested, OutcomeDigested, OutcomeDigested, OutcomeSent, OutcomeSent}; !slices.Equal(v, want) {
+		t.Errorf("Expected outcomes %v; got %v", want, v)
+	}
+
+	a.reportDigests()
+	events = b.Events()[2:]
+	if len(events) != 2 {
+		t.Fatalf("Expected a digest per distinct error; got %d", len(events))
+	}
+	var d DigestError
+	if !errors.As(events[0].Err, &d) || d.Count != 5 || d.Err.Error() != "Slow query" {
+		t.Fatalf("Expected a digest of 5 occurrences of the first error; got %v", events[0].Err)
+	}
+	if !d.First.Equal(start) || !d.Last.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("Expected the digest to be seen from %v to %v; got %v to %v", start, start.Add(4*time.Minute), d.First, d.Last)
+	}
+	if e := events[0]; e.Level != LevelWarning || e.Tags["digest"] != "true" {
+		t.Errorf("Expected the digest to be reported at its level and tagged; got %s, %v", e.Level, e.Tags)
+	}
+	digest, _ := events[0].Extra["digest"].(map[string]interface{})
+	if digest["count"] != 5 || digest["first_seen"] != start.Format(time.RFC3339) {
+		t.Errorf("Unexpected digest: %v", digest)
+	}
+	if v, _ := digest["samples"].([]map[string]interface{}); len(v) != digestSamples || v[0]["query"] != 0 {
+		t.Errorf("Expected the extras of the first %d occurrences to be sampled; got %v", digestSamples, v)
+	}
+	if errors.As(events[1].Err, &d); d.Count != 1 || events[1].Level != LevelInfo {
+		t.Errorf("Expected a digest of the informational alert; got %v at %s", events[1].Err, events[1].Level)
+	}
+
+	// the digests were drained
+	a.reportDigests()
+	if n := len(b.Events()); n != 4 {
+		t.Errorf("Expected nothing more to be reported; got %d alerts", n)
+	}
+}
+
+func TestDigestMaxLevel(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}, Digest: Digest{Interval: time.Hour, MaxLevel: LevelInfo}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Info(errors.New("Cache miss"))
+	a.Warning(errors.New("Slow query"))
+	if events := b.Events(); len(events) != 1 || events[0].Level != LevelWarning {
+		t.Errorf("Expected alerts more severe than the maximum level to be reported immediately; got %d", len(events))
+	}
+}
+
+func TestDigestClose(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}, Digest: Digest{Interval: time.Hour}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Warning(errors.New("Slow query"))
+	a.Warning(errors.New("Slow query"))
+	if n := len(b.Events()); n != 0 {
+		t.Fatalf("Expected the warnings to be digested; got %d alerts", n)
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	events := b.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected the remaining digest to be reported when closed; got %d", len(events))
+	}
+	if d, ok := events[0].Err.(DigestError); !ok || d.Count != 2 {
+		t.Errorf("Expected a digest of 2 occurrences; got %v", events[0].Err)
+	}
+}
+
+func TestDigestInterval(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}, Digest: Digest{Interval: 10 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Warning(errors.New("Slow query"))
+	deadline := time.Now().Add(5 * time.Second)
+	for len(b.Events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := len(b.Events()); n != 1 {
+		t.Errorf("Expected the digest to be reported when the interval elapses; got %d", n)
+	}
+}
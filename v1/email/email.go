@@ -0,0 +1,265 @@
+// Package email provides an alert backend which delivers alerts by email via
+// SMTP, which is intended for low volumes of severe alerts in deployments
+// that have no other means of alerting.
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+)
+
+// The maximum number of alerts retained for each level between digests.
+// Alerts beyond this are counted but not described.
+const maxDigestLength = 100
+
+var (
+	ErrNoServer = errors.New("Email backend requires an SMTP server address")
+	ErrNoSender = errors.New("Email backend requires a sender address")
+)
+
+// Config configures an email backend.
+type Config struct {
+	Addr string    // the address of the SMTP server, as host:port
+	Auth smtp.Auth // optional
+	From string
+
+	// Recipients are the addresses alerts at each level are sent to; alerts
+	// at a level with no recipients are sent to To, if there are any, and
+	// otherwise are not sent. For example, to send only fatal alerts:
+	//
+	//	Recipients: map[alert.Level][]string{alert.LevelFatal: {"oncall@example.com"}}
+	Recipients map[alert.Level][]string
+	To         []string
+
+	// Subject and Body produce the subject and body of each message. They
+	// are executed with a Message, which describes one alert or, in digest
+	// mode, several. By default DefaultSubject and DefaultBody are used.
+	Subject *template.Template
+	Body    *template.Template
+
+	// Digest, if positive, collects alerts and sends a single message for
+	// each level describing the alerts collected at that interval, rather
+	// than a message for every alert. Collected alerts are sent when the
+	// backend is closed, so Close should be registered with the alerter:
+	//
+	//	a.AfterClose(func() { b.Close() })
+	Digest time.Duration
+	// OnError is invoked with errors sending digests in the background,
+	// which cannot be returned to the alerter; typically this reports to the
+	// alerter's error handler (see alert.Config.OnError). By default they
+	// are discarded.
+	OnError func(error)
+}
+
+// Message describes the alerts a message is sent for, in the order they were
+// raised.
+type Message struct {
+	Level   alert.Level
+	Events  []*alert.Event
+	Dropped int // the number of alerts beyond those described
+}
+
+// Count produces the number of alerts the message is sent for.
+func (m Message) Count() int {
+	return len(m.Events) + m.Dropped
+}
+
+var DefaultSubject = template.Must(template.New("subject").Parse(
+	`[{{.Level}}] {{if eq .Count 1}}{{(index .Events 0).Message}}{{else}}{{.Count}} alerts{{end}}`,
+))
+
+var DefaultBody = template.Must(template.New("body").Parse(
+	`{{range .Events}}{{.Time.Format "2006-01-02 15:04:05 MST"}} [{{.Level}}] {{.Message}}
+{{- if .Ref}}
+  ref: {{.Ref}}{{end}}
+{{- if .Component}}
+  component: {{.Component}}{{end}}
+{{- range .Exception}}
+  {{.Type}}: {{.Value}}{{end}}
+{{- range $k, $v := .Tags}}
+  {{$k}}: {{$v}}{{end}}
+
+{{end}}{{if .Dropped}}...and {{.Dropped}} more
+{{end}}`,
+))
+
+// Backend sends alerts by email. Unless it collects alerts into digests,
+// messages are sent on the goroutine which delivers alerts, so alerters
+// using this backend should generally deliver asynchronously; see
+// alert.Config.Async.
+type Backend struct {
+	addr       string
+	auth       smtp.Auth
+	from       string
+	recipients map[alert.Level][]string
+	to         []string
+	subject    *template.Template
+	body       *template.Template
+	send       func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	onError    func(error)
+
+	mu      sync.Mutex
+	pending map[alert.Level]*Message
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+var _ alert.Backend = (*Backend)(nil)
+
+func New(conf Config) (*Backend, error) {
+	if conf.Addr == "" {
+		return nil, ErrNoServer
+	}
+	if conf.From == "" {
+		return nil, ErrNoSender
+	}
+	b := &Backend{
+		addr:       conf.Addr,
+		auth:       conf.Auth,
+		from:       conf.From,
+		recipients: conf.Recipients,
+		to:         conf.To,
+		subject:    conf.Subject,
+		body:       conf.Body,
+		send:       smtp.SendMail,
+		onError:    conf.OnError,
+	}
+	if b.subject == nil {
+		b.subject = DefaultSubject
+	}
+	if b.body == nil {
+		b.body = DefaultBody
+	}
+	if conf.Digest > 0 {
+		b.pending = make(map[alert.Level]*Message)
+		b.done = make(chan struct{})
+		b.stopped = make(chan struct{})
+		go b.run(conf.Digest)
+	}
+	return b, nil
+}
+
+// recipientsFor produces the addresses alerts at the specified level are
+// sent to.
+func (b *Backend) recipientsFor(lvl alert.Level) []string {
+	if r := b.recipients[lvl]; len(r) > 0 {
+		return r
+	}
+	return b.to
+}
+
+func (b *Backend) Capture(e *alert.Event) error {
+	if len(b.recipientsFor(e.Level)) == 0 {
+		return alert.ErrSkipped
+	}
+	if b.pending == nil {
+		return b.sendMessage(Message{Level: e.Level, Events: []*alert.Event{e}})
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m, ok := b.pending[e.Level]
+	if !ok {
+		m = &Message{Level: e.Level}
+		b.pending[e.Level] = m
+	}
+	if len(m.Events) < maxDigestLength {
+		m.Events = append(m.Events, e)
+	} else {
+		m.Dropped++
+	}
+	return nil
+}
+
+// run sends digests periodically until the backend is closed.
+func (b *Backend) run(interval time.Duration) {
+	defer close(b.stopped)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := b.Flush(); err != nil && b.onError != nil {
+				b.onError(err)
+			}
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// Flush sends a digest for each level at which alerts have been collected
+// since the previous digest. It does nothing unless the backend collects
+// alerts into digests.
+func (b *Backend) Flush() error {
+	if b.pending == nil {
+		return nil
+	}
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[alert.Level]*Message)
+	b.mu.Unlock()
+	var errs []error
+	for _, m := range pending {
+		if err := b.sendMessage(*m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops collecting digests and sends any alerts which have been
+// collected.
+func (b *Backend) Close() error {
+	if b.pending == nil {
+		return nil
+	}
+	select {
+	case <-b.done:
+		return nil
+	default:
+		close(b.done)
+	}
+	<-b.stopped
+	return b.Flush()
+}
+
+func (b *Backend) sendMessage(m Message) error {
+	to := b.recipientsFor(m.Level)
+	subject := &strings.Builder{}
+	if err := b.subject.Execute(subject, m); err != nil {
+		return fmt.Errorf("Could not produce email subject: %w", err)
+	}
+	body := &bytes.Buffer{}
+	if err := b.body.Execute(body, m); err != nil {
+		return fmt.Errorf("Could not produce email body: %w", err)
+	}
+
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", b.from)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", headerValue(subject.String()))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(bytes.ReplaceAll(body.Bytes(), []byte("\n"), []byte("\r\n")))
+
+	if err := b.send(b.addr, b.auth, b.from, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("Could not send email: %w", err)
+	}
+	return nil
+}
+
+// headerValue makes a value safe to use in a header by collapsing it onto a
+// single line.
+func headerValue(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,183 @@
+package email
+
+import (
+	"errors"
+	"net/smtp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+)
+
+// message is an email as it was sent.
+type message struct {
+	To   []string
+	Data string
+}
+
+// outbox records the messages sent, failing to send them with err, if set.
+type outbox struct {
+	sync.Mutex
+	messages []message
+	err      error
+}
+
+func (o *outbox) send(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	o.Lock()
+	defer o.Unlock()
+	if o.err != nil {
+		return o.err
+	}
+	o.messages = append(o.messages, message{To: to, Data: string(msg)})
+	return nil
+}
+
+func (o *outbox) Messages() []message {
+	o.Lock()
+	defer o.Unlock()
+	return append([]message(nil), o.messages...)
+}
+
+func newBackend(t *testing.T, conf Config) (*Backend, *outbox) {
+	t.Helper()
+	conf.Addr, conf.From = "smtp.example.com:587", "alerts@example.com"
+	b, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &outbox{}
+	b.send = o.send
+	t.Cleanup(func() { b.Close() })
+	return b, o
+}
+
+func newEvent(lvl alert.Level, msg string) *alert.Event {
+	return &alert.Event{Time: time.Now(), Level: lvl, Message: msg, Ref: "job"}
+}
+
+func TestImmediate(t *testing.T) {
+	b, o := newBackend(t, Config{
+		To:         []string{"team@example.com"},
+		Recipients: map[alert.Level][]string{alert.LevelFatal: {"oncall@example.com"}},
+	})
+	if err := b.Capture(newEvent(alert.LevelError, "Could not connect")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Capture(newEvent(alert.LevelFatal, "Out of memory")); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := o.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("Expected a message per alert; got %d", len(msgs))
+	}
+	if m := msgs[0]; !slices.Equal(m.To, []string{"team@example.com"}) || !strings.Contains(m.Data, "Subject: [error] Could not connect\r\n") {
+		t.Errorf("Unexpected message: %v\n%s", m.To, m.Data)
+	}
+	if m := msgs[0]; !strings.Contains(m.Data, "From: alerts@example.com\r\n") || !strings.Contains(m.Data, "  ref: job\r\n") {
+		t.Errorf("Expected the message to describe the alert:\n%s", m.Data)
+	}
+	if m := msgs[1]; !slices.Equal(m.To, []string{"oncall@example.com"}) {
+		t.Errorf("Expected fatal alerts to be sent to their recipients; got %v", m.To)
+	}
+
+	o.err = errors.New("Connection refused")
+	if err := b.Capture(newEvent(alert.LevelError, "Failed")); err == nil {
+		t.Error("Expected an error sending immediately to be returned")
+	}
+}
+
+func TestNoRecipients(t *testing.T) {
+	b, o := newBackend(t, Config{Recipients: map[alert.Level][]string{alert.LevelFatal: {"oncall@example.com"}}})
+	if err := b.Capture(newEvent(alert.LevelError, "Failed")); !errors.Is(err, alert.ErrSkipped) {
+		t.Errorf("Expected an alert without recipients to be skipped; got %v", err)
+	}
+	if n := len(o.Messages()); n != 0 {
+		t.Errorf("Expected nothing to be sent; got %d messages", n)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	b, o := newBackend(t, Config{To: []string{"team@example.com"}, Digest: time.Hour})
+	for i := 0; i < maxDigestLength+5; i++ {
+		if err := b.Capture(newEvent(alert.LevelError, "Failed")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Capture(newEvent(alert.LevelWarning, "Slow"))
+	if n := len(o.Messages()); n != 0 {
+		t.Fatalf("Expected alerts to be collected; got %d messages", n)
+	}
+
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	msgs := o.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("Expected a digest per level; got %d", len(msgs))
+	}
+	var digest string
+	for _, m := range msgs {
+		if strings.Contains(m.Data, "Subject: [error]") {
+			digest = m.Data
+		}
+	}
+	if !strings.Contains(digest, "Subject: [error] 105 alerts\r\n") {
+		t.Errorf("Expected the digest to count every alert:\n%s", digest)
+	}
+	if n := strings.Count(digest, "[error] Failed"); n != maxDigestLength {
+		t.Errorf("Expected %d alerts to be described; got %d", maxDigestLength, n)
+	}
+	if !strings.Contains(digest, "...and 5 more\r\n") {
+		t.Errorf("Expected the alerts beyond the limit to be counted:\n%s", digest)
+	}
+
+	if err := b.Flush(); err != nil || len(o.Messages()) != 2 {
+		t.Errorf("Expected nothing to be sent without alerts collected; got %v", err)
+	}
+}
+
+func TestDigestClose(t *testing.T) {
+	b, o := newBackend(t, Config{To: []string{"team@example.com"}, Digest: time.Hour})
+	b.Capture(newEvent(alert.LevelError, "Failed"))
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := o.Messages(); len(msgs) != 1 || !strings.Contains(msgs[0].Data, "Subject: [error] Failed\r\n") {
+		t.Errorf("Expected collected alerts to be sent when closed; got %v", msgs)
+	}
+	if err := b.Close(); err != nil {
+		t.Errorf("Expected closing again to do nothing; got %v", err)
+	}
+}
+
+func TestDigestError(t *testing.T) {
+	errs := make(chan error, 1)
+	b, o := newBackend(t, Config{
+		To:     []string{"team@example.com"},
+		Digest: 10 * time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	fail := errors.New("Connection refused")
+	o.Lock()
+	o.err = fail
+	o.Unlock()
+
+	b.Capture(newEvent(alert.LevelError, "Failed"))
+	select {
+	case err := <-errs:
+		if !errors.Is(err, fail) {
+			t.Errorf("Expected %v; got %v", fail, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected an error sending a digest to be reported")
+	}
+}
@@ -0,0 +1,41 @@
+package alert
+
+import (
+	errutil "github.com/bww/go-util/v1/errors"
+)
+
+// promoteErrutil promotes the structured metadata exposed by the error types
+// of go-util/errors, anywhere in the chain, into tags and extra. Only the
+// first value of each kind found is used, in keeping with errutil.Refstr.
+//
+// Errors implementing errutil.Redacted are only noted as such; their
+// unredacted form is never inspected, since it may contain sensitive
+// information.
+func promoteErrutil(err error, tags Tags, extra map[string]interface{}) {
+	var detail, recoverable, redacted bool
+	walkChain(err, func(err error) bool {
+		if !detail {
+			var d interface{}
+			switch c := err.(type) {
+			case *errutil.Error:
+				d = c.Detail
+			case errutil.Error:
+				d = c.Detail
+			}
+			if d != nil {
+				extra["detail"] = d
+				detail = true
+			}
+		}
+		if c, ok := err.(errutil.Recovery); ok && !recoverable {
+			tags["recoverable"] = c.Recoverable()
+			recoverable = true
+		}
+		if _, ok := err.(errutil.Redacted); ok && !redacted {
+			tags["redacted"] = true
+			redacted = true
+			return false // don't descend into the sensitive, unredacted error
+		}
+		return true
+	})
+}
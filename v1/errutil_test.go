@@ -0,0 +1,39 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+
+	errutil "github.com/bww/go-util/v1/errors"
+)
+
+func TestPromoteErrutil(t *testing.T) {
+	err := errutil.Reference(errutil.NewRecoverable(errutil.Wrap(errors.New("timeout"), "Could not sync").SetDetail("account 42"), true))
+
+	a, tr := newAlerter(t, Config{})
+	a.Error(err)
+
+	event := tr.Event(t)
+	if v := event.Extra["detail"]; v != "account 42" {
+		t.Errorf("Expected the detail in extra; got %v", v)
+	}
+	if v := event.Tags["recoverable"]; v != "true" {
+		t.Errorf("Expected recoverable to be tagged; got %q", v)
+	}
+	if v := event.Tags["ref"]; v != err.Reference() {
+		t.Errorf("Expected the reference %q to be tagged; got %q", err.Reference(), v)
+	}
+}
+
+func TestPromoteErrutilRedacted(t *testing.T) {
+	internal := errutil.Wrap(errors.New("password=hunter2"), "Login failed").SetDetail("secret detail")
+	tags, extra := make(Tags), make(map[string]interface{})
+	promoteErrutil(errutil.Redact(internal, errors.New("Login failed")), tags, extra)
+
+	if v := tags["redacted"]; v != true {
+		t.Errorf("Expected the error to be tagged redacted; got %v", v)
+	}
+	if _, ok := extra["detail"]; ok {
+		t.Error("Expected the unredacted error not to be inspected")
+	}
+}
@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/bww/go-ident/v1"
+)
+
+// Escalation describes when an error which recurs should be escalated: when
+// it occurs more than Count times within Window, an EscalationError which
+// wraps it is reported at Level, and for Channel if one is provided, once
+// per window, e.g., to page someone about a warning which is ignorable in
+// isolation but not when it recurs hundreds of times in a few minutes:
+//
+//	Escalations: []alert.Escalation{
+//		{Count: 100, Window: 5 * time.Minute, Level: alert.LevelError, Channel: oncall},
+//	}
+//
+// Occurrences are counted per error, as they are for deduplication, whether
+// or not they are reported. If MinLevel is provided only alerts at least as
+// severe are counted. The level of the escalated alert defaults to error.
+//
+// The zero value never escalates.
+type Escalation struct {
+	Count    int
+	Window   time.Duration
+	MinLevel Level
+	Level    Level
+	Channel  ident.Ident
+}
+
+func (e Escalation) enabled() bool {
+	return e.Count > 0 && e.Window > 0
+}
+
+// EscalationError is reported when an error escalates; see Escalation.
+type EscalationError struct {
+	Err    error
+	Count  int
+	Window time.Duration
+}
+
+func (e EscalationError) Error() string {
+	return fmt.Sprintf("Escalated after %d occurrences within %v: %v", e.Count, e.Window, e.Err)
+}
+
+func (e EscalationError) Unwrap() error {
+	return e.Err
+}
+
+// escalationWindow counts the occurrences of an error in the current window
+// of an escalation.
+type escalationWindow struct {
+	start     time.Time
+	count     int
+	escalated bool
+}
+
+// escalate records an occurrence of an error at the specified level against
+// every escalation, and produces the first one which it escalates via, along
+// with the number of occurrences in its window. It must be invoked under the
+// lock of the occurrence.
+func escalate(rules []Escalation, e *occurrence, lvl Level, now time.Time) (Escalation, int, bool) {
+	if len(e.escalations) < len(rules) {
+		e.escalations = make([]escalationWindow, len(rules))
+	}
+	var (
+		match Escalation
+		count int
+		found bool
+	)
+	for i, r := range rules {
+		if !r.enabled() || (r.MinLevel != "" && !atLeast(lvl, r.MinLevel)) {
+			continue
+		}
+		w := &e.escalations[i]
+		if w.start.IsZero() || now.Sub(w.start) >= r.Window {
+			*w = escalationWindow{start: now}
+		}
+		w.count++
+		if w.count > r.Count && !w.escalated {
+			w.escalated = true
+			if !found {
+				match, count, found = r, w.count, true
+			}
+		}
+	}
+	return match, count, found
+}
+
+// reportEscalation reports an escalated error with the options it was
+// originally raised with.
+func (a *Alerter) reportEscalation(rule Escalation, err error, count int, opts ...Option) {
+	lvl := rule.Level
+	if lvl == "" {
+		lvl = LevelError
+	}
+	opts = append(slices.Clip(opts), WithLevel(lvl), WithSentryLevel(lvl), mergeTags(Tags{"escalated": true}))
+	if !rule.Channel.IsZero() {
+		opts = append(opts, WithChannel(rule.Channel))
+	}
+	a.report(EscalationError{Err: err, Count: count, Window: rule.Window}, opts...)
+}
@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bww/go-ident/v1"
+)
+
+func TestEscalation(t *testing.T) {
+	c, all, oncall := newClock(), &backend{}, &backend{}
+	channel := ident.New()
+	a, err := New(Config{
+		Clock:       c.Now,
+		Backends:    []Backend{all},
+		Routes:      []Route{{Channel: channel, Backends: []Backend{oncall}}},
+		Escalations: []Escalation{{Count: 3, Window: 5 * time.Minute, Channel: channel}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	warn := func(n int) {
+		for i := 0; i < n; i++ {
+			a.Warning(errors.New("Slow query"))
+			c.Advance(time.Second)
+		}
+	}
+	warn(3)
+	if n := len(oncall.Events()); n != 0 {
+		t.Fatalf("Expected no escalation within the count; got %d", n)
+	}
+	warn(1)
+	events := oncall.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected the error to escalate once it exceeds the count; got %d", len(events))
+	}
+	e := events[0]
+	var esc EscalationError
+	if !errors.As(e.Err, &esc) || esc.Count != 4 || esc.Window != 5*time.Minute {
+		t.Errorf("Expected an escalation error after 4 occurrences; got %v", e.Err)
+	}
+	if e.Level != LevelError || e.Channel != channel || e.Tags["escalated"] != "true" {
+		t.Errorf("Expected the escalation to be reported at error for the channel; got %s, %v, %v", e.Level, e.Channel, e.Tags)
+	}
+	if n := len(all.Events()); n != 5 {
+		t.Errorf("Expected the occurrences and the escalation to be delivered; got %d", n)
+	}
+
+	// the error escalates once per window
+	warn(10)
+	if n := len(oncall.Events()); n != 1 {
+		t.Errorf("Expected the error to escalate once per window; got %d", n)
+	}
+
+	// and counting starts over with the next window
+	c.Advance(5 * time.Minute)
+	warn(4)
+	if n := len(oncall.Events()); n != 2 {
+		t.Errorf("Expected the error to escalate again in the next window; got %d", n)
+	}
+}
+
+func TestEscalationWindow(t *testing.T) {
+	c, b := newClock(), &backend{}
+	a, err := New(Config{
+		Clock:       c.Now,
+		Backends:    []Backend{b},
+		Escalations: []Escalation{{Count: 2, Window: time.Minute, Level: LevelFatal}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	// occurrences spread over more than the window never escalate
+	for i := 0; i < 10; i++ {
+		a.Warning(errors.New("Slow query"))
+		c.Advance(31 * time.Second)
+	}
+	for _, e := range b.Events() {
+		if _, ok := e.Err.(EscalationError); ok {
+			t.Fatal("Expected occurrences spread over more than the window not to escalate")
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		a.Warning(errors.New("Slow query"))
+	}
+	events := b.Events()
+	if e := events[len(events)-1]; e.Level != LevelFatal {
+		t.Errorf("Expected the escalation to be reported at the level of the rule; got %s", e.Level)
+	}
+}
+
+func TestEscalationMinLevel(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{
+		Backends:    []Backend{b},
+		Dedup:       Dedup{Window: time.Hour, Burst: 1},
+		Escalations: []Escalation{{Count: 2, Window: time.Hour, MinLevel: LevelWarning}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	for i := 0; i < 5; i++ {
+		a.Info(errors.New("Cache miss"))
+	}
+	if n := len(b.Events()); n != 1 {
+		t.Fatalf("Expected alerts below the minimum level not to be counted; got %d alerts", n)
+	}
+
+	// occurrences which are deduplicated are counted regardless
+	for i := 0; i < 3; i++ {
+		a.Warning(errors.New("Slow query"))
+	}
+	events := b.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected the first warning and the escalation; got %d alerts", len(events))
+	}
+	if _, ok := events[2].Err.(EscalationError); !ok {
+		t.Errorf("Expected deduplicated occurrences to escalate; got %v", events[2].Err)
+	}
+}
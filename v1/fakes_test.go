@@ -0,0 +1,204 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const testDSN = "https://key@o1.ingest.sentry.io/1"
+
+// transport records the events a Sentry client sends rather than sending
+// them.
+type transport struct {
+	sync.Mutex
+	events  []*sentry.Event
+	flushes int
+	stalled bool // flushing times out
+}
+
+func (t *transport) Configure(sentry.ClientOptions) {}
+
+func (t *transport) SendEvent(e *sentry.Event) {
+	t.Lock()
+	defer t.Unlock()
+	t.events = append(t.events, e)
+}
+
+func (t *transport) Flush(time.Duration) bool {
+	t.Lock()
+	defer t.Unlock()
+	t.flushes++
+	return !t.stalled
+}
+
+func (t *transport) FlushWithContext(context.Context) bool {
+	return t.Flush(0)
+}
+
+func (t *transport) Close() {}
+
+// Events produces the events sent so far.
+func (t *transport) Events() []*sentry.Event {
+	t.Lock()
+	defer t.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// Event produces the only event sent so far, failing the test if exactly one
+// has not been.
+func (t *transport) Event(tb testing.TB) *sentry.Event {
+	tb.Helper()
+	events := t.Events()
+	if len(events) != 1 {
+		tb.Fatalf("Expected exactly one event; got %d", len(events))
+	}
+	return events[0]
+}
+
+// newClient produces a Sentry client which records the events it sends.
+func newClient(tb testing.TB) (*sentry.Client, *transport) {
+	tb.Helper()
+	t := &transport{}
+	c, err := sentry.NewClient(sentry.ClientOptions{Dsn: testDSN, Transport: t})
+	if err != nil {
+		tb.Fatalf("Could not create client: %v", err)
+	}
+	return c, t
+}
+
+// newAlerter produces an alerter which reports to a client recording the
+// events it sends, which is closed when the test completes.
+func newAlerter(tb testing.TB, conf Config) (*Alerter, *transport) {
+	tb.Helper()
+	c, t := newClient(tb)
+	conf.Sentry = c
+	a, err := New(conf)
+	if err != nil {
+		tb.Fatalf("Could not create alerter: %v", err)
+	}
+	tb.Cleanup(func() { a.Close() })
+	return a, t
+}
+
+// clock is a fake clock, which only advances when it is told to.
+type clock struct {
+	sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// backend records the alerts delivered to it, failing to deliver them with
+// err, if set.
+type backend struct {
+	sync.Mutex
+	events []*Event
+	err    error
+}
+
+func (b *backend) Capture(e *Event) error {
+	b.Lock()
+	defer b.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.events = append(b.events, e)
+	return nil
+}
+
+// Events produces the alerts delivered so far.
+func (b *backend) Events() []*Event {
+	b.Lock()
+	defer b.Unlock()
+	return append([]*Event(nil), b.events...)
+}
+
+// logs records the log records written to it as JSON.
+type logs struct {
+	sync.Mutex
+	bytes.Buffer
+}
+
+func (l *logs) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.Buffer.Write(p)
+}
+
+// newLogger produces a logger which records every record it handles.
+func newLogger() (*slog.Logger, *logs) {
+	l := &logs{}
+	return slog.New(slog.NewJSONHandler(l, &slog.HandlerOptions{Level: slog.LevelDebug})), l
+}
+
+// Records decodes the records written so far.
+func (l *logs) Records(tb testing.TB) []map[string]interface{} {
+	tb.Helper()
+	l.Lock()
+	defer l.Unlock()
+	var recs []map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(l.Bytes()))
+	for dec.More() {
+		var rec map[string]interface{}
+		if err := dec.Decode(&rec); err != nil {
+			tb.Fatalf("Could not decode log record: %v", err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// Record decodes the only record written so far, failing the test if exactly
+// one has not been.
+func (l *logs) Record(tb testing.TB) map[string]interface{} {
+	tb.Helper()
+	recs := l.Records(tb)
+	if len(recs) != 1 {
+		tb.Fatalf("Expected exactly one log record; got %d", len(recs))
+	}
+	return recs[0]
+}
+
+// metrics records the alerts counted.
+type metrics struct {
+	sync.Mutex
+	alerts []AlertMetric
+}
+
+func (m *metrics) CountAlert(e AlertMetric) {
+	m.Lock()
+	defer m.Unlock()
+	m.alerts = append(m.alerts, e)
+}
+
+// Outcomes produces the outcome of each alert counted so far.
+func (m *metrics) Outcomes() []Outcome {
+	m.Lock()
+	defer m.Unlock()
+	res := make([]Outcome, len(m.alerts))
+	for i, e := range m.alerts {
+		res[i] = e.Outcome
+	}
+	return res
+}
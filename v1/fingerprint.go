@@ -0,0 +1,16 @@
+package alert
+
+// errorFingerprint searches the error chain for an error which describes how
+// events for it are grouped, via a method Fingerprint() []string, and produces
+// that fingerprint. The outermost such error applies, so a wrapper can
+// override the grouping of the error it wraps.
+func errorFingerprint(err error) ([]string, bool) {
+	var parts []string
+	walkChain(err, func(err error) bool {
+		if c, ok := err.(interface{ Fingerprint() []string }); ok {
+			parts = c.Fingerprint()
+		}
+		return len(parts) == 0
+	})
+	return parts, len(parts) > 0
+}
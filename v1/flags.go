@@ -0,0 +1,32 @@
+package alert
+
+import (
+	"sort"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// scrubFlags produces a snapshot of feature flags with the scrubber applied
+// to each, keyed by flag name, as it is reported and logged.
+func scrubFlags(s Scrubber, flags map[string]bool) map[string]interface{} {
+	res := make(map[string]interface{}, len(flags))
+	for k, v := range flags {
+		res[k] = v
+	}
+	return scrubFields(s, res)
+}
+
+// flagsContext produces the Sentry context which describes a snapshot of
+// feature flags, ordered by flag name.
+func flagsContext(flags map[string]interface{}) sentry.Context {
+	names := make([]string, 0, len(flags))
+	for k := range flags {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	values := make([]map[string]interface{}, len(names))
+	for i, k := range names {
+		values[i] = map[string]interface{}{"flag": k, "result": flags[k]}
+	}
+	return sentry.Context{"values": values}
+}
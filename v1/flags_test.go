@@ -0,0 +1,45 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestWithFlags(t *testing.T) {
+	type key struct{}
+	scrub := func(key string, value interface{}) (interface{}, bool) {
+		return value, key != "internal_beta"
+	}
+	a, tr := newAlerter(t, Config{
+		Scrubber: scrub,
+		FlagsProvider: func(cxt context.Context) map[string]bool {
+			return map[string]bool{"provided": cxt.Value(key{}) == "yes"}
+		},
+	})
+
+	a.Error(errors.New("Failed"), WithFlags(map[string]bool{"new_checkout": true, "dark_mode": false, "internal_beta": true}))
+	a.Error(errors.New("Failed"), WithContext(context.WithValue(context.Background(), key{}, "yes")))
+
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events; got %d", len(events))
+	}
+	expect := []sentry.Context{
+		{"values": []map[string]interface{}{
+			{"flag": "dark_mode", "result": false},
+			{"flag": "new_checkout", "result": true},
+		}},
+		{"values": []map[string]interface{}{
+			{"flag": "provided", "result": true},
+		}},
+	}
+	for i, e := range events {
+		if v := e.Contexts["flags"]; !reflect.DeepEqual(v, expect[i]) {
+			t.Errorf("Expected event %d to have the flags context %v; got %v", i, expect[i], v)
+		}
+	}
+}
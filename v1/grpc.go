@@ -0,0 +1,108 @@
+package alert
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// grpcCode mirrors google.golang.org/grpc/codes.Code. Status errors are
+// detected structurally, via their GRPCStatus method, so that this package
+// does not need to depend on the gRPC runtime.
+type grpcCode uint32
+
+var grpcCodeNames = []string{
+	"OK",
+	"Canceled",
+	"Unknown",
+	"InvalidArgument",
+	"DeadlineExceeded",
+	"NotFound",
+	"AlreadyExists",
+	"PermissionDenied",
+	"ResourceExhausted",
+	"FailedPrecondition",
+	"Aborted",
+	"OutOfRange",
+	"Unimplemented",
+	"Internal",
+	"Unavailable",
+	"DataLoss",
+	"Unauthenticated",
+}
+
+func (c grpcCode) String() string {
+	if int(c) < len(grpcCodeNames) {
+		return grpcCodeNames[c]
+	} else {
+		return "Code(" + strconv.FormatUint(uint64(c), 10) + ")"
+	}
+}
+
+// Level produces the severity that an error with this code is reported at.
+// Codes which describe a problem with the request rather than the service are
+// reported as warnings.
+func (c grpcCode) Level() sentry.Level {
+	switch c {
+	case 0:
+		return sentry.LevelInfo
+	case 2, 4, 12, 13, 14: // Unknown, DeadlineExceeded, Unimplemented, Internal, Unavailable
+		return sentry.LevelError
+	case 15: // DataLoss
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelWarning
+	}
+}
+
+type grpcStatus struct {
+	Code    grpcCode
+	Message string
+	Details []interface{}
+}
+
+// grpcStatusFromError searches the error chain for an error implementing
+// GRPCStatus() *status.Status and, if one is found, extracts its status.
+func grpcStatusFromError(err error) (grpcStatus, bool) {
+	var (
+		st    grpcStatus
+		found bool
+	)
+	walkChain(err, func(err error) bool {
+		m := reflect.ValueOf(err).MethodByName("GRPCStatus")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			return true
+		}
+		s := m.Call(nil)[0]
+		if s.Kind() == reflect.Pointer && s.IsNil() {
+			return true
+		}
+		if v, ok := callMethod(s, "Code"); ok && v.CanUint() {
+			st.Code = grpcCode(v.Uint())
+		} else {
+			return true
+		}
+		if v, ok := callMethod(s, "Message"); ok && v.Kind() == reflect.String {
+			st.Message = v.String()
+		}
+		if v, ok := callMethod(s, "Details"); ok && v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				st.Details = append(st.Details, v.Index(i).Interface())
+			}
+		}
+		found = true
+		return false
+	})
+	return st, found
+}
+
+// callMethod invokes the named niladic method on v and returns its first
+// result, if the method exists.
+func callMethod(v reflect.Value, name string) (reflect.Value, bool) {
+	m := v.MethodByName(name)
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() < 1 {
+		return reflect.Value{}, false
+	}
+	return m.Call(nil)[0], true
+}
@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCStatus(t *testing.T) {
+	tests := []struct {
+		code  codes.Code
+		level sentry.Level
+	}{
+		{codes.NotFound, sentry.LevelWarning},
+		{codes.Unavailable, sentry.LevelError},
+		{codes.DataLoss, sentry.LevelFatal},
+	}
+	for _, e := range tests {
+		t.Run(e.code.String(), func(t *testing.T) {
+			a, tr := newAlerter(t, Config{})
+			a.Error(fmt.Errorf("Could not fetch: %w", status.Error(e.code, "Upstream failed")))
+
+			event := tr.Event(t)
+			if v := event.Tags["grpc_code"]; v != e.code.String() {
+				t.Errorf("Expected grpc_code %s; got %q", e.code, v)
+			}
+			if event.Level != e.level {
+				t.Errorf("Expected level %s; got %s", e.level, event.Level)
+			}
+		})
+	}
+}
+
+func TestGRPCStatusLevelOverride(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(status.Error(codes.NotFound, "Missing"), WithLevel(sentry.LevelError))
+
+	if v := tr.Event(t).Level; v != sentry.LevelError {
+		t.Errorf("Expected the level provided to take precedence; got %s", v)
+	}
+}
+
+func TestGRPCCodeNames(t *testing.T) {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if v := grpcCode(c).String(); v != c.String() {
+			t.Errorf("Expected the name of code %d to be %s; got %s", c, c, v)
+		}
+	}
+	if v := grpcCode(99).String(); v != "Code(99)" {
+		t.Errorf("Expected an unknown code to be named by number; got %s", v)
+	}
+}
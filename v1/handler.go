@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"net/http"
+
+	"github.com/bww/go-router/v2"
+	"github.com/getsentry/sentry-go"
+)
+
+// statusFromError searches the error chain for an error which describes the
+// HTTP status it should be answered with, via a method StatusCode() int or
+// Status() int, and produces that status.
+func statusFromError(err error) (int, bool) {
+	var status int
+	walkChain(err, func(err error) bool {
+		switch c := err.(type) {
+		case interface{ StatusCode() int }:
+			status = c.StatusCode()
+		case interface{ Status() int }:
+			status = c.Status()
+		}
+		return status == 0
+	})
+	return status, status != 0
+}
+
+// statusLevel produces the level of an error which is answered with the
+// specified status: client errors are warnings and anything else is an error.
+func statusLevel(status int) sentry.Level {
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		return sentry.LevelWarning
+	}
+	return sentry.LevelError
+}
+
+// Handler wraps a handler such that any error it returns is reported, with
+// the request and the time spent handling it attached (see WithDuration), and
+// then returned as usual so that the router's own error handling still
+// applies. If the error describes the status it should be answered with, via
+// a method StatusCode() int or Status() int, the status is tagged and client
+// errors are reported as warnings, unless the options provide a level.
+func (a *Alerter) Handler(h router.Handler, opts ...Option) router.Handler {
+	return func(req *router.Request, cxt router.Context) (*router.Response, error) {
+		start := a.now()
+		rsp, err := h(req, cxt)
+		if err != nil {
+			eopts := []Option{WithRequest(req), WithDuration(a.now().Sub(start))}
+			status, ok := statusFromError(err)
+			if ok {
+				eopts = append(eopts, WithLevel(statusLevel(status)))
+			}
+			eopts = append(eopts, opts...)
+			if ok {
+				// merged last, so that tags provided by the caller don't replace it
+				eopts = append(eopts, mergeTags(Tags{"status": status}))
+			}
+			a.Error(err, eopts...)
+		}
+		return rsp, err
+	}
+}
+
+// Handler wraps a handler such that errors it returns are reported via the
+// shared alerter. See Alerter.Handler.
+func Handler(h router.Handler, opts ...Option) router.Handler {
+	return func(req *router.Request, cxt router.Context) (*router.Response, error) {
+		if a := Default(); a != nil {
+			return a.Handler(h, opts...)(req, cxt)
+		}
+		return h(req, cxt)
+	}
+}
@@ -0,0 +1,92 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bww/go-router/v2"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestWithDuration(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Slow"), WithDuration(1500*time.Millisecond))
+
+	if v := tr.Event(t).Tags["duration_ms"]; v != "1500" {
+		t.Errorf("Expected duration_ms 1500; got %q", v)
+	}
+}
+
+func TestHandlerDuration(t *testing.T) {
+	c := newClock()
+	a, tr := newAlerter(t, Config{Clock: c.Now})
+	h := a.Handler(func(req *router.Request, cxt router.Context) (*router.Response, error) {
+		c.Advance(250 * time.Millisecond)
+		return nil, errors.New("Failed")
+	})
+
+	h(newRequest(t, "GET", "https://example.com/", nil), router.Context{})
+
+	if v := tr.Event(t).Tags["duration_ms"]; v != "250" {
+		t.Errorf("Expected duration_ms 250; got %q", v)
+	}
+}
+
+// statusError is an error which describes the status it is answered with.
+type statusError int
+
+func (e statusError) Error() string   { return http.StatusText(int(e)) }
+func (e statusError) StatusCode() int { return int(e) }
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		err    error
+		level  sentry.Level
+		status string
+	}{
+		{errors.New("Failed"), sentry.LevelError, "overridden"},
+		{fmt.Errorf("Could not find user: %w", statusError(http.StatusNotFound)), sentry.LevelWarning, "404"},
+		{statusError(http.StatusBadGateway), sentry.LevelError, "502"},
+	}
+	for _, e := range tests {
+		a, tr := newAlerter(t, Config{})
+		h := a.Handler(func(req *router.Request, cxt router.Context) (*router.Response, error) {
+			return nil, e.err
+		}, WithTags(Tags{"status": "overridden", "route": "users"}))
+
+		rsp, err := h(newRequest(t, "GET", "https://example.com/users/1", nil), router.Context{})
+		if err != e.err || rsp != nil {
+			t.Errorf("Expected the error to propagate to the router; got %v, %v", rsp, err)
+		}
+		event := tr.Event(t)
+		if event.Level != e.level {
+			t.Errorf("Expected %v to be reported at %s; got %s", e.err, e.level, event.Level)
+		}
+		// the status of the error is not replaced by the options
+		if v := event.Tags["status"]; v != e.status {
+			t.Errorf("Expected the status %q; got %q", e.status, v)
+		}
+		if v := event.Tags["route"]; v != "users" {
+			t.Errorf("Expected the tags provided by the options; got %q", v)
+		}
+		if event.Request == nil || !strings.HasSuffix(event.Request.URL, "example.com/users/1") {
+			t.Errorf("Expected the request to be attached; got %+v", event.Request)
+		}
+	}
+
+	a, tr := newAlerter(t, Config{})
+	expect := router.NewResponse(http.StatusOK)
+	rsp, err := a.Handler(func(req *router.Request, cxt router.Context) (*router.Response, error) {
+		return expect, nil
+	})(newRequest(t, "GET", "https://example.com/", nil), router.Context{})
+	if rsp != expect || err != nil {
+		t.Errorf("Expected the response to be returned; got %v, %v", rsp, err)
+	}
+	if n := len(tr.Events()); n != 0 {
+		t.Errorf("Expected nothing to be reported for a handler which succeeds; got %d events", n)
+	}
+}
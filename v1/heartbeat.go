@@ -0,0 +1,207 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The maximum time spent pinging a check-in URL.
+const pingTimeout = 10 * time.Second
+
+// CheckInStatus describes the state of a monitored job when it checks in.
+type CheckInStatus string
+
+const (
+	CheckInOK     CheckInStatus = "ok"     // the job is alive
+	CheckInMissed CheckInStatus = "missed" // the job failed to check in within its interval
+)
+
+// CheckIn describes a heartbeat of a monitored job; see Alerter.Heartbeat.
+type CheckIn struct {
+	Name     string
+	Status   CheckInStatus
+	Time     time.Time
+	Interval time.Duration // the interval the job is expected to check in at
+}
+
+// CheckInner is implemented by backends which monitor heartbeats, such as
+// those produced by PingURL, so that a service which detects jobs that stop
+// checking in is notified independently of the alerter. Every backend which
+// implements it is checked in with.
+type CheckInner interface {
+	CheckIn(c *CheckIn) error
+}
+
+// Heartbeat is a dead man's switch for a recurring job, which must check in
+// at least once every interval; see Alerter.Heartbeat.
+type Heartbeat struct {
+	alerter  *Alerter
+	name     string
+	interval time.Duration
+
+	mu      sync.Mutex
+	last    time.Time
+	timer   *time.Timer
+	missed  bool
+	stopped bool
+}
+
+// Heartbeat registers a job which must check in, via Beat, at least once
+// every interval. Every check-in is forwarded to the Sentry client, as a
+// check-in of the Sentry cron monitor the name identifies, and to every
+// backend which monitors heartbeats; see CheckInner. If a full interval
+// elapses without a check-in an error is reported, once, with the reference
+// "heartbeat:<name>", which is resolved when the job checks in again.
+//
+//	hb := a.Heartbeat("nightly-export", 25*time.Hour)
+//	for range ticker.C {
+//		export()
+//		hb.Beat()
+//	}
+//
+// The interval begins when the heartbeat is registered. Heartbeats are
+// stopped when the alerter is closed.
+func (a *Alerter) Heartbeat(name string, interval time.Duration) *Heartbeat {
+	h := &Heartbeat{alerter: a, name: name, interval: interval, last: a.now()}
+	h.timer = time.AfterFunc(interval, h.expire)
+	a.OnClose(h.Stop)
+	return h
+}
+
+// Beat checks in, recording that the job is alive. Backends are checked in
+// with on the calling goroutine.
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	if h.stopped {
+		h.mu.Unlock()
+		return
+	}
+	now := h.alerter.now()
+	h.last = now
+	resumed := h.missed
+	h.missed = false
+	h.timer.Reset(h.interval)
+	h.mu.Unlock()
+
+	h.alerter.checkIn(&CheckIn{Name: h.name, Status: CheckInOK, Time: now, Interval: h.interval})
+	if resumed {
+		h.alerter.Resolve(h.ref())
+	}
+}
+
+// Stop stops monitoring the job.
+func (h *Heartbeat) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+	h.timer.Stop()
+}
+
+func (h *Heartbeat) ref() string {
+	return "heartbeat:" + h.name
+}
+
+// expire reports that the job failed to check in within its interval.
+func (h *Heartbeat) expire() {
+	h.mu.Lock()
+	if h.stopped || h.missed {
+		h.mu.Unlock()
+		return
+	}
+	h.missed = true
+	last := h.last
+	h.mu.Unlock()
+
+	now := h.alerter.now()
+	err := fmt.Errorf("Heartbeat %s missed: no check-in since %s", h.name, last.Format(time.RFC3339))
+	h.alerter.report(err,
+		WithRef(h.ref()),
+		WithFingerprint("heartbeat", h.name),
+		WithTagsAny(Tags{"heartbeat": h.name}),
+		WithExtra(map[string]interface{}{"heartbeat_interval": h.interval.String(), "heartbeat_last": last.Format(time.RFC3339)}),
+	)
+	for _, b := range h.alerter.checkInners() {
+		if err := b.CheckIn(&CheckIn{Name: h.name, Status: CheckInMissed, Time: now, Interval: h.interval}); err != nil {
+			h.alerter.notify(err)
+			h.alerter.countDeliveryError(fmt.Sprintf("%T", b), err)
+		}
+	}
+}
+
+// checkIn forwards a check-in to the Sentry client and every backend which
+// monitors heartbeats.
+func (a *Alerter) checkIn(c *CheckIn) {
+	if a.sentry != nil && !a.closed.Load() {
+		minutes := max(int64(c.Interval/time.Minute), 1)
+		a.sentry.CaptureCheckIn(
+			&sentry.CheckIn{MonitorSlug: c.Name, Status: sentry.CheckInStatusOK},
+			&sentry.MonitorConfig{Schedule: sentry.IntervalSchedule(minutes, sentry.MonitorScheduleUnitMinute), CheckInMargin: 1},
+			nil,
+		)
+	}
+	for _, b := range a.checkInners() {
+		if err := b.CheckIn(c); err != nil {
+			a.notify(err)
+			a.countDeliveryError(fmt.Sprintf("%T", b), err)
+		}
+	}
+}
+
+// checkInners produces the backends which monitor heartbeats.
+func (a *Alerter) checkInners() []CheckInner {
+	var res []CheckInner
+	for _, b := range a.backends {
+		if c, ok := b.(CheckInner); ok {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// PingURL produces a backend which requests a URL for every successful
+// check-in, in the manner of Healthchecks.io and similar services, which
+// alert independently when pings stop. The placeholder "{name}" in the URL
+// is replaced with the name of the heartbeat, e.g.,
+//
+//	alert.PingURL("https://hc-ping.com/" + pingKey + "/{name}")
+//
+// The backend skips alerts; see ErrSkipped.
+func PingURL(u string) Backend {
+	return pingBackend{url: u}
+}
+
+type pingBackend struct {
+	url string
+}
+
+func (b pingBackend) Capture(*Event) error {
+	return ErrSkipped
+}
+
+func (b pingBackend) CheckIn(c *CheckIn) error {
+	if c.Status != CheckInOK {
+		return nil
+	}
+	cxt, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(cxt, http.MethodGet, strings.ReplaceAll(b.url, "{name}", url.PathEscape(c.Name)), nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Could not ping check-in URL: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		return fmt.Errorf("Check-in URL responded with status: %s", rsp.Status)
+	}
+	return nil
+}
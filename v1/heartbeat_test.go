@@ -0,0 +1,164 @@
+package alert
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// monitor is a backend which records the check-ins and the references it
+// resolves.
+type monitor struct {
+	resolver
+	checkIns []*CheckIn
+}
+
+func (m *monitor) CheckIn(c *CheckIn) error {
+	m.Lock()
+	defer m.Unlock()
+	m.checkIns = append(m.checkIns, c)
+	return nil
+}
+
+func (m *monitor) CheckIns() []*CheckIn {
+	m.Lock()
+	defer m.Unlock()
+	return append([]*CheckIn(nil), m.checkIns...)
+}
+
+func TestHeartbeat(t *testing.T) {
+	c, m := newClock(), &monitor{}
+	a, err := New(Config{Clock: c.Now, Backends: []Backend{m}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	// the timer never fires during the test; it is expired explicitly
+	h := a.Heartbeat("export", time.Hour)
+	c.Advance(time.Minute)
+	h.Beat()
+
+	checkIns := m.CheckIns()
+	if len(checkIns) != 1 {
+		t.Fatalf("Expected 1 check-in; got %d", len(checkIns))
+	}
+	if v := checkIns[0]; v.Name != "export" || v.Status != CheckInOK || !v.Time.Equal(c.Now()) || v.Interval != time.Hour {
+		t.Errorf("Unexpected check-in: %+v", v)
+	}
+	if len(m.Events()) != 0 || len(m.resolved) != 0 {
+		t.Errorf("Expected nothing to be reported or resolved while the job checks in")
+	}
+}
+
+func TestHeartbeatExpire(t *testing.T) {
+	c, m := newClock(), &monitor{}
+	a, err := New(Config{Clock: c.Now, Backends: []Backend{m}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	h := a.Heartbeat("export", time.Hour)
+	c.Advance(time.Hour)
+	h.expire()
+	h.expire()
+
+	events := m.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected a missed heartbeat to be reported once; got %d", len(events))
+	}
+	e := events[0]
+	if e.Ref != "heartbeat:export" || e.Level != LevelError || e.Tags["heartbeat"] != "export" {
+		t.Errorf("Unexpected alert: %+v", e)
+	}
+	if v := e.Extra["heartbeat_interval"]; v != "1h0m0s" {
+		t.Errorf("Expected the interval to be described; got %v", v)
+	}
+	if checkIns := m.CheckIns(); len(checkIns) != 1 || checkIns[0].Status != CheckInMissed {
+		t.Errorf("Expected a missed check-in; got %+v", checkIns)
+	}
+
+	// the job resumes, so the alert is resolved, once
+	h.Beat()
+	h.Beat()
+	if len(m.resolved) != 1 || m.resolved[0] != "heartbeat:export" {
+		t.Errorf("Expected the alert to be resolved when the job resumes; got %v", m.resolved)
+	}
+
+	// and another missed interval is reported again
+	h.expire()
+	if n := len(m.Events()); n != 2 {
+		t.Errorf("Expected the heartbeat to be reported again; got %d alerts", n)
+	}
+}
+
+func TestHeartbeatStop(t *testing.T) {
+	m := &monitor{}
+	a, err := New(Config{Backends: []Backend{m}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := a.Heartbeat("export", time.Hour)
+	a.Close()
+
+	h.Beat()
+	h.expire()
+	if len(m.CheckIns()) != 0 || len(m.Events()) != 0 {
+		t.Error("Expected a heartbeat to be stopped when the alerter is closed")
+	}
+}
+
+func TestHeartbeatTimer(t *testing.T) {
+	m := &monitor{}
+	a, err := New(Config{Backends: []Backend{m}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Heartbeat("export", 10*time.Millisecond)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(m.Events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if len(m.Events()) != 1 {
+		t.Error("Expected a missed heartbeat to be reported when the interval elapses")
+	}
+}
+
+func TestPingURL(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, req.URL.Path)
+	}))
+	defer srv.Close()
+
+	a, err := New(Config{Backends: []Backend{PingURL(srv.URL + "/key/{name}")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	h := a.Heartbeat("nightly export", time.Hour)
+	h.Beat()
+	mu.Lock()
+	if len(paths) != 1 || paths[0] != "/key/nightly export" {
+		t.Errorf("Expected the URL for the heartbeat to be pinged; got %v", paths)
+	}
+	mu.Unlock()
+
+	// the backend does not deliver alerts, so none is considered delivered
+	if id := a.Error(errors.New("Failed")); id != nil {
+		t.Errorf("Expected an alert delivered only to a ping backend to have no ID; got %v", *id)
+	}
+	if err := PingURL(srv.URL).Capture(&Event{}); !errors.Is(err, ErrSkipped) {
+		t.Errorf("Expected the ping backend to skip alerts; got %v", err)
+	}
+}
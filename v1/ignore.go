@@ -0,0 +1,57 @@
+package alert
+
+import (
+	"errors"
+	"regexp"
+	"syscall"
+)
+
+// Ignore determines whether an error should be discarded rather than
+// reported. See Config.Ignore.
+type Ignore func(err error) bool
+
+// IgnoreIs ignores errors which match any of the targets, as determined by
+// errors.Is. Unlike a Sentinel, which may instead change the level matching
+// errors are reported at, this only discards them.
+func IgnoreIs(targets ...error) Ignore {
+	return func(err error) bool {
+		for _, e := range targets {
+			if errors.Is(err, e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// IgnoreType ignores errors with an error of type T in their chain, as
+// determined by errors.As.
+func IgnoreType[T error]() Ignore {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// IgnoreMessage ignores errors with a message that matches the expression.
+func IgnoreMessage(expr *regexp.Regexp) Ignore {
+	return func(err error) bool {
+		return expr.MatchString(err.Error())
+	}
+}
+
+// IgnoreConnReset ignores errors which result from the peer closing a
+// connection, which are typically the fault of the client.
+func IgnoreConnReset() Ignore {
+	return IgnoreIs(syscall.ECONNRESET, syscall.EPIPE)
+}
+
+// ignored determines whether any of the rules ignores the error.
+func ignored(rules []Ignore, err error) bool {
+	for _, e := range rules {
+		if e(err) {
+			return true
+		}
+	}
+	return false
+}
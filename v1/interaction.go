@@ -0,0 +1,164 @@
+package alert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// The maximum number of bytes of a request or response body captured by an
+// InteractionError.
+const maxInteractionBody = 4096
+
+// Query parameters and headers whose names contain any of these substrings
+// are redacted from captured interactions.
+var sensitiveNames = []string{"auth", "token", "secret", "password", "passwd", "key", "signature", "session", "cookie", "credential"}
+
+// InteractionError is an error which arises from an outbound HTTP request and
+// captures the request that was sent and the response that was received, so
+// that the interaction can be reconstructed. The interaction is attached to
+// the alert as the extra section "http".
+//
+// Captured interactions are scrubbed: credentials in the URL and the values
+// of sensitive query parameters and headers are redacted, and bodies are
+// truncated.
+type InteractionError struct {
+	Err error
+	rsp *http.Response
+
+	reqBody, rspBody []byte
+}
+
+// NewInteractionError creates an InteractionError which describes the
+// exchange that produced the response, which must carry the request that
+// produced it, as responses produced by http.Client do.
+//
+// The beginning of the response body is captured and the body is replaced
+// so that it may still be read in its entirety afterwards. The request body
+// is only captured if it can be read again, via Request.GetBody.
+func NewInteractionError(rsp *http.Response, err error) *InteractionError {
+	e := &InteractionError{Err: err, rsp: rsp}
+	if rsp.Body != nil {
+		prefix, _ := io.ReadAll(io.LimitReader(rsp.Body, maxInteractionBody+1))
+		rsp.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), rsp.Body), rsp.Body}
+		e.rspBody = prefix
+	}
+	if req := rsp.Request; req != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			e.reqBody, _ = io.ReadAll(io.LimitReader(body, maxInteractionBody+1))
+			body.Close()
+		}
+	}
+	return e
+}
+
+func (e *InteractionError) Error() string {
+	msg := fmt.Sprintf("HTTP %d", e.rsp.StatusCode)
+	if req := e.rsp.Request; req != nil {
+		msg = fmt.Sprintf("%s %s: %s", req.Method, scrubURL(req.URL), msg)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *InteractionError) Unwrap() error {
+	return e.Err
+}
+
+// Response produces the response received, which makes an InteractionError a
+// ResponseError.
+func (e *InteractionError) Response() *http.Response {
+	return e.rsp
+}
+
+// interaction produces the extra section which describes the exchange.
+func (e *InteractionError) interaction() map[string]interface{} {
+	res := make(map[string]interface{})
+	if req := e.rsp.Request; req != nil {
+		r := map[string]interface{}{
+			"method":  req.Method,
+			"url":     scrubURL(req.URL),
+			"headers": scrubHeaders(req.Header),
+		}
+		if len(e.reqBody) > 0 {
+			r["body"] = truncate(string(e.reqBody), maxInteractionBody)
+		}
+		res["request"] = r
+	}
+	r := map[string]interface{}{
+		"status":  e.rsp.StatusCode,
+		"headers": scrubHeaders(e.rsp.Header),
+	}
+	if len(e.rspBody) > 0 {
+		r["body"] = truncate(string(e.rspBody), maxInteractionBody)
+	}
+	res["response"] = r
+	return res
+}
+
+// interactionFromError searches the error chain for an InteractionError.
+func interactionFromError(err error) (*InteractionError, bool) {
+	var e *InteractionError
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func sensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := sensitiveHeaders[name]; ok {
+		return true
+	}
+	for _, e := range sensitiveNames {
+		if strings.Contains(name, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubURL produces the URL without credentials and with the values of
+// sensitive query parameters redacted.
+func scrubURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	c.User = nil
+	if c.RawQuery != "" {
+		q := c.Query()
+		for k, v := range q {
+			if sensitiveName(k) {
+				for i := range v {
+					v[i] = redacted
+				}
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+func scrubHeaders(hdr http.Header) map[string]string {
+	res := make(map[string]string, len(hdr))
+	for k, v := range hdr {
+		if sensitiveName(k) {
+			res[k] = redacted
+		} else {
+			res[k] = strings.Join(v, ", ")
+		}
+	}
+	return res
+}
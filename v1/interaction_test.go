@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInteractionError(t *testing.T) {
+	body := strings.Repeat("x", 2*maxInteractionBody)
+	srv := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		rsp.Header().Set("Set-Cookie", "session=abc")
+		rsp.Header().Set("Retry-After", "30")
+		rsp.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(rsp, body)
+	}))
+	defer srv.Close()
+
+	u := strings.Replace(srv.URL, "http://", "http://user:pass@", 1) + "/charge?page=2&api_key=abc"
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(`{"amount":100}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer abc")
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rsp.Body.Close()
+
+	ierr := NewInteractionError(rsp, errors.New("Service unavailable"))
+	if data, err := io.ReadAll(rsp.Body); err != nil || string(data) != body {
+		t.Errorf("Expected the response body to be readable in its entirety; got %d bytes: %v", len(data), err)
+	}
+
+	a, tr := newAlerter(t, Config{})
+	a.Error(ierr)
+	interaction, ok := tr.Event(t).Extra["http"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected the interaction in extra; got %v", tr.Event(t).Extra)
+	}
+	sent, _ := interaction["request"].(map[string]interface{})
+	recv, _ := interaction["response"].(map[string]interface{})
+	if v, want := sent["url"], srv.URL+"/charge?api_key=%5Bredacted%5D&page=2"; v != want {
+		t.Errorf("Expected the URL without credentials %q; got %q", want, v)
+	}
+	if v := sent["method"]; v != http.MethodPost {
+		t.Errorf("Expected the method %s; got %v", http.MethodPost, v)
+	}
+	if v := sent["body"]; v != `{"amount":100}` {
+		t.Errorf("Expected the request body; got %v", v)
+	}
+	hdr, _ := sent["headers"].(map[string]string)
+	if hdr["Authorization"] != redacted || hdr["Content-Type"] != "application/json" {
+		t.Errorf("Expected sensitive request headers to be redacted; got %v", hdr)
+	}
+	if v := recv["status"]; v != http.StatusServiceUnavailable {
+		t.Errorf("Expected the status %d; got %v", http.StatusServiceUnavailable, v)
+	}
+	if v, _ := recv["body"].(string); v != body[:maxInteractionBody]+"... (truncated)" {
+		t.Errorf("Expected the response body truncated to %d bytes; got %d", maxInteractionBody, len(v))
+	}
+	hdr, _ = recv["headers"].(map[string]string)
+	if hdr["Set-Cookie"] != redacted || hdr["Retry-After"] != "30" {
+		t.Errorf("Expected sensitive response headers to be redacted; got %v", hdr)
+	}
+}
@@ -0,0 +1,123 @@
+package alert
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// slogLevel maps a Sentry level to the equivalent slog level.
+func slogLevel(lvl sentry.Level) slog.Level {
+	switch lvl {
+	case sentry.LevelDebug:
+		return slog.LevelDebug
+	case sentry.LevelInfo:
+		return slog.LevelInfo
+	case sentry.LevelWarning:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
+
+// sentryLevel maps a slog level to the equivalent Sentry level. Levels above
+// slog.LevelError are fatal.
+func sentryLevel(lvl slog.Level) sentry.Level {
+	switch {
+	case lvl < slog.LevelInfo:
+		return sentry.LevelDebug
+	case lvl < slog.LevelWarn:
+		return sentry.LevelInfo
+	case lvl < slog.LevelError:
+		return sentry.LevelWarning
+	case lvl == slog.LevelError:
+		return sentry.LevelError
+	default:
+		return sentry.LevelFatal
+	}
+}
+
+// levelRank orders Sentry levels by severity. Unknown levels, including the
+// empty level, rank below all others.
+func levelRank(lvl sentry.Level) int {
+	switch lvl {
+	case sentry.LevelDebug:
+		return 1
+	case sentry.LevelInfo:
+		return 2
+	case sentry.LevelWarning:
+		return 3
+	case sentry.LevelError:
+		return 4
+	case sentry.LevelFatal:
+		return 5
+	default:
+		return 0
+	}
+}
+
+// atLeast determines whether the level is at least as severe as the minimum.
+func atLeast(lvl, min sentry.Level) bool {
+	return levelRank(lvl) >= levelRank(min)
+}
+
+// Level is the severity of an alert. It is the Sentry level, so levels may be
+// used interchangeably with the Sentry API.
+type Level = sentry.Level
+
+const (
+	LevelDebug   = sentry.LevelDebug
+	LevelInfo    = sentry.LevelInfo
+	LevelWarning = sentry.LevelWarning
+	LevelError   = sentry.LevelError
+	LevelFatal   = sentry.LevelFatal
+)
+
+// Report reports an error at the specified level, which is also the level it
+// is logged at. Levels set via the options take precedence.
+func (a *Alerter) Report(lvl Level, err error, opts ...Option) *sentry.EventID {
+	return a.report(err, append([]Option{WithLevel(lvl)}, opts...)...)
+}
+
+// Warn is equivalent to Warning.
+func (a *Alerter) Warn(err error, opts ...Option) *sentry.EventID {
+	return a.Report(LevelWarning, err, opts...)
+}
+
+func (a *Alerter) Warnf(f string, args ...interface{}) {
+	a.Warn(fmt.Errorf(f, args...))
+}
+
+// Fatal reports an error at the fatal level. Unlike log.Fatal it does not
+// exit the process.
+func (a *Alerter) Fatal(err error, opts ...Option) *sentry.EventID {
+	return a.Report(LevelFatal, err, opts...)
+}
+
+func (a *Alerter) Fatalf(f string, args ...interface{}) {
+	a.Fatal(fmt.Errorf(f, args...))
+}
+
+func Report(lvl Level, err error, opts ...Option) *sentry.EventID {
+	if n := notifier(); n != nil {
+		return n.Report(lvl, err, opts...)
+	}
+	return nil
+}
+
+func Warn(err error, opts ...Option) *sentry.EventID {
+	return Report(LevelWarning, err, opts...)
+}
+
+func Warnf(f string, args ...interface{}) {
+	Report(LevelWarning, fmt.Errorf(f, args...))
+}
+
+func Fatal(err error, opts ...Option) *sentry.EventID {
+	return Report(LevelFatal, err, opts...)
+}
+
+func Fatalf(f string, args ...interface{}) {
+	Report(LevelFatal, fmt.Errorf(f, args...))
+}
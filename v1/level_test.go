@@ -0,0 +1,67 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The level helpers, as methods and as package functions.
+var levelHelpers = []struct {
+	name   string
+	method func(a *Alerter, err error) *sentry.EventID
+	shared func(err error) *sentry.EventID
+}{
+	{"Error", func(a *Alerter, err error) *sentry.EventID { return a.Error(err) }, func(err error) *sentry.EventID { return Error(err) }},
+	{"Warning", func(a *Alerter, err error) *sentry.EventID { return a.Warning(err) }, func(err error) *sentry.EventID { return Warning(err) }},
+	{"Warn", func(a *Alerter, err error) *sentry.EventID { return a.Warn(err) }, func(err error) *sentry.EventID { return Warn(err) }},
+	{"Info", func(a *Alerter, err error) *sentry.EventID { return a.Info(err) }, func(err error) *sentry.EventID { return Info(err) }},
+	{"Fatal", func(a *Alerter, err error) *sentry.EventID { return a.Fatal(err) }, func(err error) *sentry.EventID { return Fatal(err) }},
+	{"Message", func(a *Alerter, err error) *sentry.EventID { return a.Message(err.Error()) }, func(err error) *sentry.EventID { return Message(err.Error()) }},
+}
+
+func TestLevelHelperIDs(t *testing.T) {
+	for _, e := range levelHelpers {
+		t.Run(e.name, func(t *testing.T) {
+			log, recs := newLogger()
+			a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log})
+			id := e.method(a, errors.New("Failed"))
+			if id == nil || *id != tr.Event(t).EventID {
+				t.Fatalf("Expected the identifier of the event; got %v", id)
+			}
+			if v := recs.Record(t)["sentry_id"]; v != string(*id) {
+				t.Errorf("Expected the alert to be logged with its event; got %v", v)
+			}
+
+			defer SetDefault(a)()
+			if id := e.shared(errors.New("Failed")); id == nil || *id != tr.Events()[1].EventID {
+				t.Errorf("Expected the package function to produce the identifier of the event; got %v", id)
+			}
+		})
+	}
+}
+
+func TestLevelHelperNotDelivered(t *testing.T) {
+	for _, e := range levelHelpers {
+		t.Run(e.name, func(t *testing.T) {
+			log, recs := newLogger()
+			a, err := New(Config{Verbose: Bool(true), Logger: log})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer a.Close()
+			if id := e.method(a, errors.New("Failed")); id != nil {
+				t.Errorf("Expected no identifier for an alert which was not delivered; got %v", *id)
+			}
+			if v, ok := recs.Record(t)["sentry_id"]; ok {
+				t.Errorf("Expected the alert not to be logged with an event; got %v", v)
+			}
+		})
+	}
+
+	defer SetDefault(nil)()
+	if id := Warning(errors.New("Failed")); id != nil {
+		t.Errorf("Expected no identifier without a shared alerter; got %v", *id)
+	}
+}
@@ -0,0 +1,167 @@
+package alert
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// The default number of log records retained for a request.
+const defaultLogBufferLimit = 100
+
+type logBufferKey struct{}
+
+// logBuffer retains the most recent log records produced while handling a
+// request.
+type logBuffer struct {
+	sync.Mutex
+	limit   int
+	records []map[string]interface{}
+	dropped int
+}
+
+func (b *logBuffer) append(rec map[string]interface{}) {
+	b.Lock()
+	defer b.Unlock()
+	if len(b.records) >= b.limit {
+		b.records = b.records[1:]
+		b.dropped++
+	}
+	b.records = append(b.records, rec)
+}
+
+// snapshot produces a copy of the retained records and the number of records
+// that were discarded to make room for them.
+func (b *logBuffer) snapshot() ([]map[string]interface{}, int) {
+	b.Lock()
+	defer b.Unlock()
+	return append([]map[string]interface{}(nil), b.records...), b.dropped
+}
+
+// WithLogBuffer produces a context in which the log records handled by a
+// LogHandler are retained, up to the specified limit, so they can be attached
+// to alerts raised for the request the context belongs to. The oldest records
+// are discarded once the limit is reached. If the limit is not positive a
+// default limit is used.
+//
+// This is typically called by middleware, which replaces the context of
+// each request it handles with the result. See also Config.RequestLogs.
+func WithLogBuffer(cxt context.Context, limit int) context.Context {
+	if limit <= 0 {
+		limit = defaultLogBufferLimit
+	}
+	return context.WithValue(cxt, logBufferKey{}, &logBuffer{limit: limit})
+}
+
+func logBufferFromContext(cxt context.Context) *logBuffer {
+	b, _ := cxt.Value(logBufferKey{}).(*logBuffer)
+	return b
+}
+
+// requestLogs produces the log records retained in a context, if any, as
+// extra.
+func requestLogs(cxt context.Context) (map[string]interface{}, bool) {
+	b := logBufferFromContext(cxt)
+	if b == nil {
+		return nil, false
+	}
+	recs, dropped := b.snapshot()
+	if len(recs) == 0 {
+		return nil, false
+	}
+	res := map[string]interface{}{"records": recs}
+	if dropped > 0 {
+		res["dropped"] = dropped
+	}
+	return res, true
+}
+
+// LogHandler is a slog.Handler which retains the records it handles in the
+// log buffer of their context, if there is one, before passing them on to
+// the handler it wraps. See WithLogBuffer.
+type LogHandler struct {
+	next   slog.Handler
+	attrs  []slog.Attr
+	prefix string
+}
+
+// NewLogHandler creates a LogHandler which wraps the specified handler.
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{next: next}
+}
+
+func (h *LogHandler) Enabled(cxt context.Context, lvl slog.Level) bool {
+	return h.next.Enabled(cxt, lvl)
+}
+
+func (h *LogHandler) Handle(cxt context.Context, rec slog.Record) error {
+	if b := logBufferFromContext(cxt); b != nil {
+		b.append(h.record(rec))
+	}
+	return h.next.Handle(cxt, rec)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	d := *h
+	d.next = h.next.WithAttrs(attrs)
+	d.attrs = append(append([]slog.Attr(nil), h.attrs...), qualify(h.prefix, attrs)...)
+	return &d
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	d := *h
+	d.next = h.next.WithGroup(name)
+	d.prefix = h.prefix + name + "."
+	return &d
+}
+
+// record produces the representation of a log record that is retained.
+// Grouped attributes are flattened, their keys qualified by their groups.
+func (h *LogHandler) record(rec slog.Record) map[string]interface{} {
+	res := map[string]interface{}{
+		"level":   rec.Level.String(),
+		"message": rec.Message,
+	}
+	if !rec.Time.IsZero() {
+		res["time"] = rec.Time.Format(time.RFC3339Nano)
+	}
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, qualify(h.prefix, []slog.Attr{a})...)
+		return true
+	})
+	if len(attrs) > 0 {
+		m := make(map[string]interface{}, len(attrs))
+		for _, a := range attrs {
+			m[a.Key] = a.Value.Resolve().Any()
+		}
+		res["attrs"] = m
+	}
+	return res
+}
+
+// qualify flattens attributes, prefixing their keys with the specified
+// prefix and the names of the groups they belong to.
+func qualify(prefix string, attrs []slog.Attr) []slog.Attr {
+	var res []slog.Attr
+	for _, a := range attrs {
+		v := a.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			p := prefix
+			if a.Key != "" {
+				p += a.Key + "."
+			}
+			res = append(res, qualify(p, v.Group())...)
+			continue
+		}
+		if a.Key == "" {
+			continue
+		}
+		res = append(res, slog.Attr{Key: prefix + a.Key, Value: v})
+	}
+	return res
+}
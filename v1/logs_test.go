@@ -0,0 +1,47 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+func TestRequestLogs(t *testing.T) {
+	log := slog.New(NewLogHandler(slog.NewTextHandler(io.Discard, nil)))
+	cxt := WithLogBuffer(context.Background(), 2)
+
+	log.InfoContext(context.Background(), "Unrelated")
+	log.InfoContext(cxt, "Received request")
+	log.With("request", "r1").WithGroup("db").InfoContext(cxt, "Querying", "table", "users")
+	log.WarnContext(cxt, "Query failed", "token", "xyz")
+
+	for _, enabled := range []bool{false, true} {
+		a, tr := newAlerter(t, Config{RequestLogs: enabled})
+		a.Error(errors.New("Failed"), WithContext(cxt))
+
+		logs, ok := tr.Event(t).Extra["logs"].(map[string]interface{})
+		if ok != enabled {
+			t.Fatalf("Expected request logs to be attached: %v; got %v", enabled, logs)
+		}
+		if !enabled {
+			continue
+		}
+		recs, _ := logs["records"].([]map[string]interface{})
+		for _, e := range recs {
+			delete(e, "time")
+		}
+		expect := []map[string]interface{}{
+			{"level": "INFO", "message": "Querying", "attrs": map[string]interface{}{"request": "r1", "db.table": "users"}},
+			{"level": "WARN", "message": "Query failed", "attrs": map[string]interface{}{"token": redacted}},
+		}
+		if !reflect.DeepEqual(recs, expect) {
+			t.Errorf("Expected the most recent records of the request, scrubbed: %v; got %v", expect, recs)
+		}
+		if v := logs["dropped"]; v != 1 {
+			t.Errorf("Expected 1 record to have been dropped; got %v", v)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package alert
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+// message is the error an alert which is not an error is reported as. It is
+// reported as a message event, which has no exception.
+type message string
+
+func (m message) Error() string {
+	return string(m)
+}
+
+// Message reports an alert which does not arise from an error, such as the
+// result of a periodic check. It is reported as a message event, which has
+// no exception or stack, at the info level unless a level is set via the
+// options. Options apply as they do for Error, and like Error it produces the
+// identifier of the event that was captured, if any.
+func (a *Alerter) Message(msg string, opts ...Option) *sentry.EventID {
+	return a.report(message(msg), append([]Option{WithLevel(sentry.LevelInfo)}, opts...)...)
+}
+
+// ReportMessage reports an alert which does not arise from an error, in the
+// manner of Message, at the specified level. Levels set via the options take
+// precedence.
+func (a *Alerter) ReportMessage(lvl Level, msg string, opts ...Option) *sentry.EventID {
+	return a.report(message(msg), append([]Option{WithLevel(lvl)}, opts...)...)
+}
+
+func ReportMessage(lvl Level, msg string, opts ...Option) *sentry.EventID {
+	if n := notifier(); n != nil {
+		return n.ReportMessage(lvl, msg, opts...)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package alert
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bww/go-ident/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+// Outcome describes what became of an alert.
+type Outcome string
+
+const (
+	OutcomeSent     Outcome = "sent"     // the alert was reported
+	OutcomeDeduped  Outcome = "deduped"  // the alert was suppressed as a repeat of a recent one
+	OutcomeSampled  Outcome = "sampled"  // the alert was discarded by sampling
+	OutcomeIgnored  Outcome = "ignored"  // the alert was discarded by a condition or rule
+	OutcomeDropped  Outcome = "dropped"  // the alert could not be delivered
+	OutcomeLogged   Outcome = "logged"   // no client is configured, so the alert was only logged
+	OutcomeDigested Outcome = "digested" // the alert was collected into a digest
+)
+
+// AlertMetric describes an alert for the purpose of collecting metrics.
+type AlertMetric struct {
+	Outcome   Outcome
+	Component string
+	Channel   ident.Ident
+	Level     sentry.Level
+}
+
+// Metrics collects metrics describing the operation of an alerter, which is
+// useful for understanding how much is being reported or suppressed, and why.
+type Metrics interface {
+	// CountAlert is invoked once for every alert that is raised.
+	CountAlert(m AlertMetric)
+}
+
+// DeliveryMetric describes a failure to deliver an alert to a destination,
+// which is the type of a backend, e.g., "*slack.Backend"; "queue", for
+// alerts dropped because the async queue was full; or "slack", for alerts
+// which could not be posted via Config.Slack.
+type DeliveryMetric struct {
+	Destination string
+	Err         error
+}
+
+// DeliveryMetrics may be implemented by Metrics to also collect metrics
+// describing the delivery of alerts, which is useful for determining whether
+// the alerter itself is healthy.
+type DeliveryMetrics interface {
+	// CountDeliveryError is invoked once for every failure to deliver an
+	// alert to a destination.
+	CountDeliveryError(m DeliveryMetric)
+	// ObserveQueueDepth is invoked with the number of alerts which are queued
+	// or being delivered whenever it changes, when delivery is asynchronous.
+	ObserveQueueDepth(n int)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) CountAlert(AlertMetric) {}
+
+// count records an alert in the alerter's own statistics and in the
+// configured metrics.
+func (a *Alerter) count(m AlertMetric) {
+	if m.Channel.IsZero() {
+		m.Channel = a.channel
+	}
+	a.stats.Count(m)
+	a.metrics.CountAlert(m)
+}
+
+// countDeliveryError records a delivery failure in the configured metrics,
+// if they collect it.
+func (a *Alerter) countDeliveryError(dest string, err error) {
+	if m, ok := a.metrics.(DeliveryMetrics); ok {
+		m.CountDeliveryError(DeliveryMetric{Destination: dest, Err: err})
+	}
+}
+
+// observeQueueDepth records the depth of the async queue in the configured
+// metrics, if they collect it.
+func (a *Alerter) observeQueueDepth(n int64) {
+	if m, ok := a.metrics.(DeliveryMetrics); ok {
+		m.ObserveQueueDepth(int(n))
+	}
+}
+
+// The outcomes counted by stats, in the order they are summarized.
+var outcomes = []Outcome{OutcomeSent, OutcomeLogged, OutcomeDigested, OutcomeDeduped, OutcomeSampled, OutcomeIgnored, OutcomeDropped}
+
+// The levels counted by stats, in the order they are summarized.
+var summaryLevels = []sentry.Level{sentry.LevelFatal, sentry.LevelError, sentry.LevelWarning, sentry.LevelInfo, sentry.LevelDebug}
+
+// stats counts the alerts raised by an alerter by level and by outcome. It
+// is updated for every alert, so it is counted without locking.
+type stats struct {
+	levels   [6]atomic.Int64 // by levelRank
+	outcomes [7]atomic.Int64 // by index in outcomes
+}
+
+func newStats() *stats {
+	return &stats{}
+}
+
+func (s *stats) Count(m AlertMetric) {
+	if i := slices.Index(outcomes, m.Outcome); i >= 0 {
+		s.outcomes[i].Add(1)
+	}
+	if m.Outcome != OutcomeIgnored {
+		s.levels[levelRank(m.Level)].Add(1)
+	}
+}
+
+// Summary describes the alerts counted so far on a single line, e.g.:
+//
+//	3 alerts (2 error, 1 warning); 2 sent, 1 deduped
+func (s *stats) Summary() string {
+	var total int64
+	var levels []string
+	for _, l := range summaryLevels {
+		if n := s.levels[levelRank(l)].Load(); n > 0 {
+			total += n
+			levels = append(levels, fmt.Sprintf("%d %s", n, l))
+		}
+	}
+	var counts []string
+	for i, o := range outcomes {
+		if n := s.outcomes[i].Load(); n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, o))
+		}
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d alerts", total)
+	if len(levels) > 0 {
+		fmt.Fprintf(b, " (%s)", strings.Join(levels, ", "))
+	}
+	if len(counts) > 0 {
+		fmt.Fprintf(b, "; %s", strings.Join(counts, ", "))
+	}
+	return b.String()
+}
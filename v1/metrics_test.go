@@ -0,0 +1,61 @@
+package alert
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestMetricsOutcomes(t *testing.T) {
+	m := &metrics{}
+	a, _ := newAlerter(t, Config{
+		Component: "api",
+		Metrics:   m,
+		Dedup:     Dedup{Window: time.Hour},
+		Ignore:    []Ignore{IgnoreMessage(regexp.MustCompile("^ignored$"))},
+	})
+	a.Error(errors.New("Failed"))
+	a.Error(errors.New("Failed"))
+	a.Warning(errors.New("Failed in billing"), WithComponent("billing"))
+	a.Error(errors.New("ignored"))
+
+	want := []AlertMetric{
+		{Outcome: OutcomeSent, Component: "api", Level: sentry.LevelError},
+		{Outcome: OutcomeDeduped, Component: "api", Level: sentry.LevelError},
+		{Outcome: OutcomeSent, Component: "billing", Level: sentry.LevelWarning},
+		{Outcome: OutcomeIgnored, Component: "api", Level: sentry.LevelError},
+	}
+	if !slices.Equal(m.alerts, want) {
+		t.Errorf("Expected the metrics %+v; got %+v", want, m.alerts)
+	}
+}
+
+func TestMetricsLogged(t *testing.T) {
+	m := &metrics{}
+	a, err := New(Config{Metrics: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	a.Error(errors.New("Failed"))
+
+	if v := m.Outcomes(); !slices.Equal(v, []Outcome{OutcomeLogged}) {
+		t.Errorf("Expected an alerter without a client to count alerts as logged; got %v", v)
+	}
+}
+
+func TestStatsSummary(t *testing.T) {
+	s := newStats()
+	s.Count(AlertMetric{Outcome: OutcomeSent, Level: sentry.LevelError})
+	s.Count(AlertMetric{Outcome: OutcomeDeduped, Level: sentry.LevelError})
+	s.Count(AlertMetric{Outcome: OutcomeSent, Level: sentry.LevelWarning})
+	s.Count(AlertMetric{Outcome: OutcomeIgnored, Level: sentry.LevelError})
+
+	if v, want := s.Summary(), "3 alerts (2 error, 1 warning); 2 sent, 1 deduped, 1 ignored"; v != want {
+		t.Errorf("Expected the summary %q; got %q", want, v)
+	}
+}
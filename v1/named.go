@@ -0,0 +1,28 @@
+package alert
+
+import (
+	"slices"
+)
+
+// Named derives an alerter which reports alerts for the specified component,
+// in the manner of WithComponent, with the provided options applied to every
+// alert it raises before the caller's own, so that options provided by the
+// caller take precedence. Tags provided via the options are merged with
+// those of the alerter rather than replaced by the caller's.
+//
+// The derived alerter shares everything else with this one, including its
+// clients, the errors it has seen recently, and its lifecycle: closing either
+// closes both. An empty component retains the component of this alerter.
+func (a *Alerter) Named(component string, opts ...Option) *Alerter {
+	child := *a
+	if component != "" {
+		child.component = component
+	}
+	if t := newContext(opts).Tags; len(t) > 0 {
+		child.tags = make(Tags, len(a.tags)+len(t))
+		merge(child.tags, a.tags)
+		merge(child.tags, t)
+	}
+	child.options = append(slices.Clip(a.options), opts...)
+	return &child
+}
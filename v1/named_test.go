@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}, Component: "api", Tags: Tags{"region": "us-east-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	db := a.Named("db", WithTags(Tags{"pool": "primary"}), WithLevel(LevelWarning))
+	db.Error(errors.New("Slow query"), WithTags(Tags{"table": "users"}))
+	db.Error(errors.New("Deadlock"), WithLevel(LevelError))
+	a.Error(errors.New("Failed"))
+
+	events := b.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 alerts; got %d", len(events))
+	}
+	e := events[0]
+	if e.Component != "db" || e.Level != LevelWarning {
+		t.Errorf("Expected the component and options of the derived alerter; got %q at %s", e.Component, e.Level)
+	}
+	for k, want := range map[string]string{"region": "us-east-1", "pool": "primary", "table": "users"} {
+		if v := e.Tags[k]; v != want {
+			t.Errorf("Expected tag %s=%s; got %q", k, want, v)
+		}
+	}
+	if e := events[1]; e.Level != LevelError {
+		t.Errorf("Expected the caller's options to take precedence; got %s", e.Level)
+	}
+	if e := events[2]; e.Component != "api" || e.Level != LevelError || e.Tags["pool"] != "" {
+		t.Errorf("Expected the alerter itself to be unaffected; got %q at %s with %v", e.Component, e.Level, e.Tags)
+	}
+}
+
+func TestNamedNested(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}, Component: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Named("db", WithTags(Tags{"pool": "primary"})).Named("", WithTags(Tags{"shard": "3"})).Error(errors.New("Slow query"))
+	e := b.Events()[0]
+	if e.Component != "db" {
+		t.Errorf("Expected an empty component to retain that of the parent; got %q", e.Component)
+	}
+	if e.Tags["pool"] != "primary" || e.Tags["shard"] != "3" {
+		t.Errorf("Expected the tags of every ancestor; got %v", e.Tags)
+	}
+}
+
+func TestNamedClose(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	child := a.Named("db")
+	if err := child.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if id := a.Error(errors.New("Failed")); id != nil || len(tr.Events()) != 0 {
+		t.Error("Expected closing a derived alerter to close its parent")
+	}
+}
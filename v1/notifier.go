@@ -0,0 +1,67 @@
+package alert
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Notifier raises alerts. It is implemented by *Alerter and by test doubles
+// such as alerttest.Recorder; see SetDefault.
+type Notifier interface {
+	Report(lvl Level, err error, opts ...Option) *sentry.EventID
+	Error(err error, opts ...Option) *sentry.EventID
+	Errorf(f string, args ...interface{})
+	Warning(err error, opts ...Option) *sentry.EventID
+	Warningf(f string, args ...interface{})
+	Info(err error, opts ...Option) *sentry.EventID
+	Infof(f string, args ...interface{})
+	Message(msg string, opts ...Option) *sentry.EventID
+	ReportMessage(lvl Level, msg string, opts ...Option) *sentry.EventID
+	Timeout(op string, elapsed time.Duration, opts ...Option)
+}
+
+var _ Notifier = (*Alerter)(nil)
+
+// override replaces the shared alerter as the target of the package-level
+// functions which raise alerts, if set. Like the shared alerter, it is read
+// without the lock.
+var override atomic.Pointer[Notifier]
+
+// SetDefault directs the package-level functions which raise alerts, such as
+// Error and Message, to the provided notifier in place of the shared alerter,
+// which is intended for tests. A function which restores the previous target
+// is returned:
+//
+//	rec := alerttest.NewRecorder()
+//	defer alert.SetDefault(rec)()
+//
+// Providing nil restores the shared alerter. Other package-level functions,
+// such as CaptureSync and Flush, always use the shared alerter.
+func SetDefault(n Notifier) func() {
+	lock.Lock()
+	defer lock.Unlock()
+	var next *Notifier
+	if n != nil {
+		next = &n
+	}
+	prev := override.Swap(next)
+	return func() {
+		lock.Lock()
+		defer lock.Unlock()
+		override.Store(prev)
+	}
+}
+
+// notifier produces the target of the package-level functions which raise
+// alerts, if there is one.
+func notifier() Notifier {
+	if n := override.Load(); n != nil {
+		return *n
+	}
+	if a := shared.Load(); a != nil {
+		return a
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetDefault(t *testing.T) {
+	shared, str := newAlerter(t, Config{})
+	defer Set(Set(shared))
+	first, ftr := newAlerter(t, Config{})
+	second, ntr := newAlerter(t, Config{})
+
+	restoreFirst := SetDefault(first)
+	restoreSecond := SetDefault(second)
+	Error(errors.New("Second"))
+	restoreSecond()
+	Errorf("First")
+	restoreFirst()
+	Warning(errors.New("Shared"))
+
+	for name, e := range map[string]struct {
+		tr     *transport
+		expect string
+	}{
+		"shared": {str, "Shared"},
+		"first":  {ftr, "First"},
+		"second": {ntr, "Second"},
+	} {
+		event := e.tr.Event(t)
+		msg := event.Message
+		if n := len(event.Exception); n > 0 {
+			msg = event.Exception[n-1].Value
+		}
+		if msg != e.expect {
+			t.Errorf("Expected the %s notifier to receive %q; got %q", name, e.expect, msg)
+		}
+	}
+
+	restore := SetDefault(first)
+	SetDefault(nil)
+	Error(errors.New("Shared again"))
+	restore()
+	if n := len(str.Events()); n != 2 {
+		t.Errorf("Expected providing nil to restore the shared alerter; got %d events", n)
+	}
+}
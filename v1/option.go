@@ -1,15 +1,112 @@
 package alert
 
 import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/bww/go-ident/v1"
 	"github.com/bww/go-router/v2"
+	"github.com/bww/go-util/v1/debug"
+	"github.com/getsentry/sentry-go"
 )
 
 type Option func(c Context) Context
 
+// newContext produces the context described by applying the options, in
+// order, to an empty context.
+func newContext(opts []Option) Context {
+	var c Context
+	for _, o := range opts {
+		c = o(c)
+	}
+	return c
+}
+
+// Context describes an alert, as configured by the options it is raised
+// with. It is not to be confused with a context.Context, which it may carry
+// as Ctx.
 type Context struct {
+	Ctx     context.Context
 	Request *router.Request
 	Tags    Tags
 	Extra   map[string]interface{}
+	Ref     string
+	Origin  string
+
+	Duration     time.Duration
+	Mechanism    *sentry.Mechanism
+	Condition    func() bool
+	Fingerprint  []string
+	GroupingHash string
+	Artifacts    map[string]string
+	Attachments  []*sentry.Attachment
+	BodyHash     bool
+	Runbook      string
+	Component    string
+	ParentTrace  *TraceParent
+	Span         *sentry.Span
+	Frames       []debug.Frame
+	Repanic      bool
+	CallPath     string
+	CallerStack  bool
+	Breadcrumbs  []sentry.Breadcrumb
+	Deadline     time.Time
+	Diff         *Diff
+	ProcessInfo  bool
+	Flags        map[string]bool
+	Priority     Priority
+	User         *sentry.User
+	Channel      ident.Ident
+
+	// Level overrides the severity of the alert. SentryLevel and LogLevel
+	// override it in turn, for Sentry and for the log, respectively. See
+	// WithLevel, WithSentryLevel, and WithLogLevel.
+	Level       sentry.Level
+	SentryLevel sentry.Level
+	LogLevel    *slog.Level
+
+	delivery *delivery // see CaptureSync
+}
+
+// goContext produces the context.Context the alert was raised in: that
+// provided via WithContext, otherwise that of the attached request, and
+// otherwise the background context.
+func (c Context) goContext() context.Context {
+	switch {
+	case c.Ctx != nil:
+		return c.Ctx
+	case c.Request != nil:
+		return c.Request.Context()
+	default:
+		return context.Background()
+	}
+}
+
+// sentryLevel produces the level the alert is reported to Sentry at, given
+// the level the alerter would otherwise have used.
+func (c Context) sentryLevel(dflt sentry.Level) sentry.Level {
+	if c.SentryLevel != "" {
+		return c.SentryLevel
+	}
+	if c.Level != "" {
+		return c.Level
+	}
+	return dflt
+}
+
+// logLevel produces the level the alert is logged at, given the level the
+// alerter would otherwise have used for both sinks.
+func (c Context) logLevel(dflt sentry.Level) slog.Level {
+	if c.LogLevel != nil {
+		return *c.LogLevel
+	}
+	if c.Level != "" {
+		return slogLevel(c.Level)
+	}
+	return slogLevel(dflt)
 }
 
 func WithRequest(req *router.Request) Option {
@@ -26,9 +123,407 @@ func WithTags(tags Tags) Option {
 	}
 }
 
+// mergeTags adds tags to those already set on the context, rather than
+// replacing them as WithTags does. The caller's map is not modified.
+func mergeTags(tags Tags) Option {
+	return func(c Context) Context {
+		merged := make(Tags, len(c.Tags)+len(tags))
+		for k, v := range c.Tags {
+			merged[k] = v
+		}
+		for k, v := range tags {
+			merged[k] = v
+		}
+		c.Tags = merged
+		return c
+	}
+}
+
+// mergeExtra adds extras to those already set on the context, rather than
+// replacing them as WithExtra does.
+func mergeExtra(extra map[string]interface{}) Option {
+	return func(c Context) Context {
+		merged := make(map[string]interface{}, len(c.Extra)+len(extra))
+		merge(merged, c.Extra)
+		merge(merged, extra)
+		c.Extra = merged
+		return c
+	}
+}
+
 func WithExtra(extra map[string]interface{}) Option {
 	return func(c Context) Context {
 		c.Extra = extra
 		return c
 	}
 }
+
+// WithRef overrides the reference that would otherwise be derived from the
+// error chain via errutil.Refstr. This is useful when an alert should be
+// correlated with an identifier from an external system, e.g., a job ID.
+func WithRef(ref string) Option {
+	return func(c Context) Context {
+		c.Ref = ref
+		return c
+	}
+}
+
+// WithDuration attaches the elapsed time of the operation that failed, e.g.,
+// the time spent handling a request, as the tag "duration_ms".
+func WithDuration(d time.Duration) Option {
+	return func(c Context) Context {
+		c.Duration = d
+		return c
+	}
+}
+
+// WithMechanism describes the mechanism by which the error was produced,
+// which is attached to the outermost exception of the event. When no
+// mechanism is provided, errors are described as "generic" and handled.
+func WithMechanism(typ, desc string) Option {
+	return func(c Context) Context {
+		mech := defaultMechanism()
+		mech.Type = typ
+		mech.Description = desc
+		c.Mechanism = mech
+		return c
+	}
+}
+
+// WithCondition makes the alert conditional on the provided predicate, which
+// is evaluated when the alert is raised. If it returns false the alert is
+// discarded entirely; it is neither reported to Sentry nor logged.
+func WithCondition(fn func() bool) Option {
+	return func(c Context) Context {
+		c.Condition = fn
+		return c
+	}
+}
+
+// WithLevel sets the level the alert is reported to Sentry and logged at. It
+// takes precedence over the level implied by the method used to report the
+// alert, e.g., Warning, and over the level the alerter would otherwise have
+// chosen for the error. WithSentryLevel and WithLogLevel take precedence over
+// it, for their respective sinks.
+func WithLevel(lvl sentry.Level) Option {
+	return func(c Context) Context {
+		c.Level = lvl
+		return c
+	}
+}
+
+// WithSentryLevel sets the level the alert is reported to Sentry at, without
+// affecting the level it is logged at. It takes precedence over the level
+// the alerter would otherwise have chosen for the error.
+func WithSentryLevel(lvl sentry.Level) Option {
+	return func(c Context) Context {
+		c.SentryLevel = lvl
+		return c
+	}
+}
+
+// WithLogLevel sets the level the alert is logged at, without affecting the
+// level it is reported to Sentry at. It takes precedence over the level the
+// alerter would otherwise have chosen for the error.
+func WithLogLevel(lvl slog.Level) Option {
+	return func(c Context) Context {
+		c.LogLevel = &lvl
+		return c
+	}
+}
+
+// WithOrigin tags the alert with the logical entry point the error arose
+// from, e.g., "worker" or "webhook", which is distinct from the component
+// that reports it.
+func WithOrigin(name string) Option {
+	return func(c Context) Context {
+		c.Origin = name
+		return c
+	}
+}
+
+// WithArtifactURL links an artifact related to the alert which is stored
+// elsewhere, e.g., a screenshot or HTML snapshot, under the provided label.
+// Only the URL is attached; the artifact itself is not retrieved. This option
+// may be used more than once to link several artifacts.
+func WithArtifactURL(label, url string) Option {
+	return func(c Context) Context {
+		artifacts := make(map[string]string, len(c.Artifacts)+1)
+		for k, v := range c.Artifacts {
+			artifacts[k] = v
+		}
+		artifacts[label] = url
+		c.Artifacts = artifacts
+		return c
+	}
+}
+
+// WithAttachment attaches a payload to the alert, e.g., the body which could
+// not be decoded, which is uploaded to Sentry as an attachment and delivered
+// to backends with the alert. Only the name and size of the payload are
+// logged. This option may be used more than once to attach several payloads.
+func WithAttachment(name, contentType string, data []byte) Option {
+	return func(c Context) Context {
+		c.Attachments = append(slices.Clip(c.Attachments), &sentry.Attachment{
+			Filename:    name,
+			ContentType: contentType,
+			Payload:     data,
+		})
+		return c
+	}
+}
+
+// WithBodyHash tags the alert with the SHA-256 hash of the body of the
+// attached request as "body_hash", which allows for errors caused by
+// identical payloads to be correlated without reporting the payload itself.
+//
+// The body can only be read if it has been buffered such that the request's
+// GetBody function is set; otherwise this option has no effect.
+func WithBodyHash() Option {
+	return func(c Context) Context {
+		c.BodyHash = true
+		return c
+	}
+}
+
+// WithRunbook links the runbook which describes how to remediate the error
+// that is being reported.
+func WithRunbook(url string) Option {
+	return func(c Context) Context {
+		c.Runbook = url
+		return c
+	}
+}
+
+// WithComponent attributes the alert to the named component rather than to
+// the component of the alerter. The policy for that component applies.
+func WithComponent(name string) Option {
+	return func(c Context) Context {
+		c.Component = name
+		return c
+	}
+}
+
+// WithParentTrace links the alert to the trace and span that the work which
+// raised it descends from, which preserves causality across asynchronous
+// boundaries, e.g., between a request and a job it enqueued. The event is
+// reported as part of the parent's trace, in a span of its own.
+func WithParentTrace(traceID, spanID string) Option {
+	return func(c Context) Context {
+		c.ParentTrace = &TraceParent{TraceID: traceID, SpanID: spanID}
+		return c
+	}
+}
+
+// WithDeadline bounds the time CaptureSync waits for the alert to be
+// delivered to the provided deadline, e.g., that of the job reporting it.
+// It has no effect on alerts which are not reported synchronously.
+func WithDeadline(t time.Time) Option {
+	return func(c Context) Context {
+		c.Deadline = t
+		return c
+	}
+}
+
+// WithDiff describes an error which represents a mismatch between an
+// expected and an actual value, such as a failed invariant. A rendering of
+// each value, to which the scrubber is applied, and a comparison of them are
+// attached as the extra section "diff". Renderings of large values are
+// truncated.
+func WithDiff(expected, actual interface{}) Option {
+	return func(c Context) Context {
+		c.Diff = &Diff{Expected: expected, Actual: actual}
+		return c
+	}
+}
+
+// WithProcessInfo attaches the time the alerter was created, which is
+// generally when the process started, as "started_at" and the time elapsed
+// since as "uptime_seconds". This helps to identify failures which relate to
+// the age of a process.
+func WithProcessInfo() Option {
+	return func(c Context) Context {
+		c.ProcessInfo = true
+		return c
+	}
+}
+
+// WithTagsAny tags the alert with the entries of any map with string keys,
+// e.g., a map[string]string or map[string]int, which saves converting it to
+// Tags first. Unlike WithTags, these tags are added to any that are already
+// set. If the value is not such a map, this option has no effect.
+func WithTagsAny(m interface{}) Option {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return func(c Context) Context { return c }
+	}
+	tags := make(Tags, v.Len())
+	for it := v.MapRange(); it.Next(); {
+		tags[it.Key().String()] = it.Value().Interface()
+	}
+	return mergeTags(tags)
+}
+
+// WithFlags attaches a snapshot of the feature flags in effect when the
+// alert was raised, as the context "flags", which helps to reproduce errors
+// that only occur with certain flags enabled.
+func WithFlags(flags map[string]bool) Option {
+	return func(c Context) Context {
+		c.Flags = flags
+		return c
+	}
+}
+
+// WithPriority sets the priority of the alert, which determines how urgently
+// paging sinks page for it, without affecting its level. See Priority.
+func WithPriority(p Priority) Option {
+	return func(c Context) Context {
+		c.Priority = p
+		return c
+	}
+}
+
+// WithSpan links the alert to the active span, typically that of a Sentry
+// transaction, in which it is raised, so that the event appears under the
+// transaction in Sentry. The span of an attached request's context is used
+// when none is provided. A trace provided via WithParentTrace takes
+// precedence.
+func WithSpan(span *sentry.Span) Option {
+	return func(c Context) Context {
+		c.Span = span
+		return c
+	}
+}
+
+// WithFrames attaches the provided stack to the outermost exception of the
+// event in place of any stack derived from the error, e.g., for errors which
+// are proxied from another process that reported its own stack.
+func WithFrames(frames []debug.Frame) Option {
+	return func(c Context) Context {
+		c.Frames = frames
+		return c
+	}
+}
+
+// WithFingerprint sets the fingerprint Sentry groups the event by, e.g., to
+// separate errors which Sentry's default grouping would otherwise combine.
+// Sentry's default grouping may be extended by including the part
+// "{{ default }}". When no fingerprint is provided, that described by the
+// error applies if the error, or any error it wraps, has a method
+// Fingerprint() []string; otherwise the default grouping applies.
+func WithFingerprint(parts ...string) Option {
+	return func(c Context) Context {
+		c.Fingerprint = parts
+		return c
+	}
+}
+
+// WithGroupingHash groups the event by exactly the provided hash, for callers
+// which compute their own stable grouping. It overrides any other
+// contribution to the fingerprint of the event, including Sentry's default
+// grouping.
+func WithGroupingHash(hash string) Option {
+	return func(c Context) Context {
+		c.GroupingHash = hash
+		return c
+	}
+}
+
+// WithRepanic resumes a panic once it has been recovered and reported by
+// Recover, so that it crashes the process, or is recovered further up the
+// stack, as it otherwise would have been.
+func WithRepanic() Option {
+	return func(c Context) Context {
+		c.Repanic = true
+		return c
+	}
+}
+
+// WithCallPath tags the alert as "call_path" with the names of the innermost
+// functions on the stack where the option is created, up to the specified
+// depth, which is a convenient thing to search and group by when a full
+// stack is unnecessary.
+func WithCallPath(depth int) Option {
+	path := callPath(depth)
+	return func(c Context) Context {
+		c.CallPath = path
+		return c
+	}
+}
+
+// WithStack attaches the stack where the alert is reported to the outermost
+// exception of the event, when none of its exceptions carry a stack of their
+// own; see Config.CaptureCallerStack.
+func WithStack() Option {
+	return func(c Context) Context {
+		c.CallerStack = true
+		return c
+	}
+}
+
+// WithBreadcrumbs attaches a trail of breadcrumbs which describe what led up
+// to the alert, e.g., the steps of the request that raised it. They follow
+// any breadcrumbs recorded via AddBreadcrumb.
+func WithBreadcrumbs(crumbs []sentry.Breadcrumb) Option {
+	return func(c Context) Context {
+		c.Breadcrumbs = crumbs
+		return c
+	}
+}
+
+// WithChannel overrides the channel of the alerter for the alert, which
+// determines the routes it is delivered via; see Route.
+func WithChannel(id ident.Ident) Option {
+	return func(c Context) Context {
+		c.Channel = id
+		return c
+	}
+}
+
+// WithUser identifies the user the alert concerns, such as the account a
+// request was authenticated as. The user is attached to the event and logged
+// as "user". Empty fields are omitted.
+func WithUser(id, email, username string) Option {
+	return func(c Context) Context {
+		c.User = &sentry.User{ID: id, Email: email, Username: username}
+		return c
+	}
+}
+
+// WithContext provides the context.Context the alert is raised in, from which
+// trace identifiers are derived: those of the active Sentry span, if any, then
+// those of the configured Tracer, and otherwise the values stored under
+// TraceIDKey and SpanIDKey. Work the alerter does in the background on behalf
+// of the alert, such as posting it to Slack, does not outlive the context.
+// When no context is provided, that of the attached request is used.
+func WithContext(cxt context.Context) Option {
+	return func(c Context) Context {
+		c.Ctx = cxt
+		return c
+	}
+}
+
+// userAttrs produces the fields of a user which are logged.
+func userAttrs(u *sentry.User) map[string]interface{} {
+	m := make(map[string]interface{})
+	if u.ID != "" {
+		m["id"] = u.ID
+	}
+	if u.Email != "" {
+		m["email"] = u.Email
+	}
+	if u.Username != "" {
+		m["username"] = u.Username
+	}
+	return m
+}
+
+// attachmentAttrs describes attachments, but not their payloads, as they are
+// logged.
+func attachmentAttrs(atts []*sentry.Attachment) []map[string]interface{} {
+	res := make([]map[string]interface{}, len(atts))
+	for i, e := range atts {
+		res[i] = map[string]interface{}{"name": e.Filename, "content_type": e.ContentType, "size": len(e.Payload)}
+	}
+	return res
+}
@@ -0,0 +1,346 @@
+package alert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/bww/go-util/v1/debug"
+	errutil "github.com/bww/go-util/v1/errors"
+)
+
+func TestWithRef(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	err := errutil.Reference(errors.New("Job failed"))
+
+	a.Error(err)
+	a.Error(err, WithRef("job-1234"))
+
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected two events; got %d", len(events))
+	}
+	if v, want := events[0].Tags["ref"], err.Reference(); v != want {
+		t.Errorf("Expected the computed ref %q; got %q", want, v)
+	}
+	if v := events[1].Tags["ref"]; v != "job-1234" {
+		t.Errorf("Expected the ref to be overridden; got %q", v)
+	}
+}
+
+func TestWithMechanism(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Failed"), WithMechanism("queue", "Job handler"))
+
+	event := tr.Event(t)
+	if len(event.Exception) == 0 {
+		t.Fatal("Expected an exception")
+	}
+	mech := event.Exception[len(event.Exception)-1].Mechanism
+	if mech == nil {
+		t.Fatal("Expected a mechanism")
+	}
+	if mech.Type != "queue" || mech.Description != "Job handler" {
+		t.Errorf("Expected the mechanism queue: Job handler; got %s: %s", mech.Type, mech.Description)
+	}
+	if mech.Handled == nil || !*mech.Handled {
+		t.Error("Expected the error to be described as handled")
+	}
+}
+
+func TestDefaultMechanism(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Failed"))
+
+	event := tr.Event(t)
+	if mech := event.Exception[len(event.Exception)-1].Mechanism; mech == nil || mech.Type != "generic" {
+		t.Errorf("Expected the generic mechanism; got %+v", mech)
+	}
+}
+
+func TestWithCondition(t *testing.T) {
+	for _, pass := range []bool{true, false} {
+		buf := &bytes.Buffer{}
+		a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: slog.New(slog.NewTextHandler(buf, nil))})
+		var evaluated int
+		a.Error(errors.New("Failed"), WithCondition(func() bool {
+			evaluated++
+			return pass
+		}))
+
+		if evaluated != 1 {
+			t.Errorf("Expected the condition to be evaluated once; got %d", evaluated)
+		}
+		if n := len(tr.Events()); pass && n != 1 {
+			t.Errorf("Expected the alert to be reported when the condition passes; got %d events", n)
+		} else if !pass && n != 0 {
+			t.Errorf("Expected the alert to be discarded when the condition fails; got %d events", n)
+		}
+		if logged := buf.Len() > 0; logged != pass {
+			t.Errorf("Expected the alert to be logged only when the condition passes; condition %v, logged %v", pass, logged)
+		}
+	}
+}
+
+func TestSentryLevelIndependentOfLogLevel(t *testing.T) {
+	log, recs := newLogger()
+	a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log})
+	a.Error(errors.New("Failed"), WithSentryLevel(sentry.LevelFatal), WithLogLevel(slog.LevelWarn))
+
+	if v := tr.Event(t).Level; v != sentry.LevelFatal {
+		t.Errorf("Expected the event at %s; got %s", sentry.LevelFatal, v)
+	}
+	rec := recs.Record(t)
+	if v := rec["level"]; v != "WARN" {
+		t.Errorf("Expected the alert to be logged at WARN; got %v", v)
+	}
+	if v := rec["alert"]; v != string(sentry.LevelFatal) {
+		t.Errorf("Expected the record to describe the level of the event; got %v", v)
+	}
+}
+
+func TestSentryLevelPrecedence(t *testing.T) {
+	log, recs := newLogger()
+	a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log})
+	a.Error(errors.New("Failed"), WithLevel(sentry.LevelWarning), WithSentryLevel(sentry.LevelInfo))
+
+	if v := tr.Event(t).Level; v != sentry.LevelInfo {
+		t.Errorf("Expected WithSentryLevel to take precedence over WithLevel for the event; got %s", v)
+	}
+	if v := recs.Record(t)["level"]; v != "WARN" {
+		t.Errorf("Expected WithLevel to apply to the log record; got %v", v)
+	}
+}
+
+func TestWithOrigin(t *testing.T) {
+	a, tr := newAlerter(t, Config{Component: "billing"})
+	a.Error(errors.New("Failed"), WithOrigin("webhook"))
+
+	event := tr.Event(t)
+	if v := event.Tags["origin"]; v != "webhook" {
+		t.Errorf("Expected origin webhook; got %q", v)
+	}
+	if v := event.Tags["component"]; v != "billing" {
+		t.Errorf("Expected the component to be unaffected; got %q", v)
+	}
+}
+
+func TestWithArtifactURL(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Render failed"),
+		WithArtifactURL("screenshot", "https://artifacts.example.com/1.png"),
+		WithArtifactURL("snapshot", "https://artifacts.example.com/1.html"))
+
+	artifacts, ok := tr.Event(t).Extra["artifacts"].(map[string]string)
+	if !ok {
+		t.Fatalf("Expected the artifacts in extra; got %#v", tr.Event(t).Extra["artifacts"])
+	}
+	if v := artifacts["screenshot"]; v != "https://artifacts.example.com/1.png" {
+		t.Errorf("Expected the screenshot URL; got %q", v)
+	}
+	if v := artifacts["snapshot"]; v != "https://artifacts.example.com/1.html" {
+		t.Errorf("Expected the snapshot URL; got %q", v)
+	}
+}
+
+func TestWithRunbook(t *testing.T) {
+	errPayment := errors.New("Payment declined")
+	a, tr := newAlerter(t, Config{RunbookResolver: func(err error) string {
+		if errors.Is(err, errPayment) {
+			return "https://runbooks.example.com/payments"
+		}
+		return ""
+	}})
+	a.Error(errors.New("Failed"), WithRunbook("https://runbooks.example.com/failed"))
+	a.Error(errPayment)
+	a.Error(errPayment, WithRunbook("https://runbooks.example.com/override"))
+	a.Error(errors.New("Unknown"))
+
+	events := tr.Events()
+	if len(events) != 4 {
+		t.Fatalf("Expected four events; got %d", len(events))
+	}
+	for i, want := range []string{"https://runbooks.example.com/failed", "https://runbooks.example.com/payments", "https://runbooks.example.com/override", ""} {
+		if v := events[i].Tags["runbook"]; v != want {
+			t.Errorf("Expected event %d to be tagged with the runbook %q; got %q", i, want, v)
+		}
+		if v, _ := events[i].Extra["runbook"].(string); v != want {
+			t.Errorf("Expected event %d to link the runbook %q in extra; got %q", i, want, v)
+		}
+	}
+}
+
+func TestWithProcessInfo(t *testing.T) {
+	c := newClock()
+	started := c.Now()
+	a, tr := newAlerter(t, Config{Clock: c.Now})
+
+	c.Advance(90 * time.Second)
+	a.Error(errors.New("Failed"), WithProcessInfo())
+	c.Advance(time.Hour)
+	a.Error(errors.New("Failed"), WithProcessInfo())
+	a.Error(errors.New("Failed"))
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected three events; got %d", len(events))
+	}
+	for i, want := range []int64{90, 3690} {
+		if v := events[i].Extra["uptime_seconds"]; v != want {
+			t.Errorf("Expected event %d to have an uptime of %d seconds; got %v", i, want, v)
+		}
+		if v := events[i].Extra["started_at"]; v != started.Format(time.RFC3339) {
+			t.Errorf("Expected event %d to note when the process started; got %v", i, v)
+		}
+	}
+	if _, ok := events[2].Extra["uptime_seconds"]; ok {
+		t.Error("Expected no process information unless it is requested")
+	}
+}
+
+func TestProcessInfoConfig(t *testing.T) {
+	a, tr := newAlerter(t, Config{ProcessInfo: true})
+	a.Error(errors.New("Failed"))
+	if _, ok := tr.Event(t).Extra["uptime_seconds"]; !ok {
+		t.Error("Expected process information on every alert")
+	}
+}
+
+func TestWithTagsAny(t *testing.T) {
+	type label string
+	tests := []struct {
+		name   string
+		m      interface{}
+		expect map[string]string
+	}{
+		{"strings", map[string]string{"region": "eu", "tier": "web"}, map[string]string{"region": "eu", "tier": "web"}},
+		{"ints", map[string]int{"shard": 3, "attempt": 2}, map[string]string{"shard": "3", "attempt": "2"}},
+		{"string keys", map[label]bool{"canary": true}, map[string]string{"canary": "true"}},
+		{"not a map", []string{"region"}, nil},
+		{"other keys", map[int]string{1: "one"}, nil},
+		{"nil", nil, nil},
+	}
+	for _, e := range tests {
+		t.Run(e.name, func(t *testing.T) {
+			ctx := ResolveContext(WithTags(Tags{"existing": "yes"}), WithTagsAny(e.m))
+			if v := ctx.Tags["existing"]; v != "yes" {
+				t.Errorf("Expected the tags to be added to those already set; got %v", ctx.Tags)
+			}
+			if len(ctx.Tags) != len(e.expect)+1 {
+				t.Errorf("Expected %d tags; got %v", len(e.expect)+1, ctx.Tags)
+			}
+			for k, want := range e.expect {
+				if v := fmt.Sprint(ctx.Tags[k]); v != want {
+					t.Errorf("Expected %s=%s; got %s", k, want, v)
+				}
+			}
+		})
+	}
+}
+
+func TestConsistentLevels(t *testing.T) {
+	for _, consistent := range []bool{false, true} {
+		log, recs := newLogger()
+		a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log, ConsistentLevels: consistent})
+		a.Error(errors.New("Failed"), WithSentryLevel(sentry.LevelFatal), WithLogLevel(slog.LevelInfo))
+
+		if v := tr.Event(t).Level; v != sentry.LevelFatal {
+			t.Errorf("Expected the event at %s; got %s", sentry.LevelFatal, v)
+		}
+		want := "INFO"
+		if consistent {
+			want = slogLevel(sentry.LevelFatal).String()
+		}
+		if v := recs.Record(t)["level"]; v != want {
+			t.Errorf("Expected the alert to be logged at %s with consistent levels %v; got %v", want, consistent, v)
+		}
+	}
+}
+
+func TestWithFrames(t *testing.T) {
+	frames := []debug.Frame{
+		{Name: "worker.(*Job).Run", File: "worker/job.go", Line: 42},
+		{Name: "worker.Loop", File: "worker/loop.go", Line: 17},
+	}
+	a, tr := newAlerter(t, Config{})
+	a.Error(fmt.Errorf("Proxied: %w", errors.New("Job failed")), WithFrames(frames))
+
+	event := tr.Event(t)
+	outer := event.Exception[len(event.Exception)-1]
+	if outer.Stacktrace == nil || len(outer.Stacktrace.Frames) != len(frames) {
+		t.Fatalf("Expected the supplied frames on the outermost exception; got %+v", outer.Stacktrace)
+	}
+	// Sentry expects the innermost frame last
+	for i, e := range frames {
+		if c := outer.Stacktrace.Frames[len(frames)-i-1]; c.Function != e.Name || c.Filename != e.File || c.Lineno != e.Line {
+			t.Errorf("Expected frame %d to be %+v; got %+v", i, e, c)
+		}
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	tests := []struct {
+		report func(*Alerter, error, ...Option) *sentry.EventID
+		opts   []Option
+		expect sentry.Level
+	}{
+		{(*Alerter).Error, nil, sentry.LevelError},
+		{(*Alerter).Error, []Option{WithLevel(sentry.LevelInfo)}, sentry.LevelInfo},
+		{(*Alerter).Warning, nil, sentry.LevelWarning},
+		{(*Alerter).Warning, []Option{WithLevel(sentry.LevelFatal)}, sentry.LevelFatal},
+		{(*Alerter).Info, []Option{WithLevel(sentry.LevelError)}, sentry.LevelError},
+	}
+	for i, e := range tests {
+		log, recs := newLogger()
+		a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log})
+		e.report(a, errors.New("Failed"), e.opts...)
+		if v := tr.Event(t).Level; v != e.expect {
+			t.Errorf("#%d: Expected the event at %s; got %s", i, e.expect, v)
+		}
+		if v, want := recs.Record(t)["level"], slogLevel(e.expect).String(); v != want {
+			t.Errorf("#%d: Expected the alert to be logged at %s; got %v", i, want, v)
+		}
+	}
+}
+
+func TestWithGroupingHash(t *testing.T) {
+	const hash = "5d41402abc4b2a76b9719d911017c592"
+	tests := [][]Option{
+		{WithGroupingHash(hash)},
+		{WithFingerprint("payments", "declined"), WithGroupingHash(hash)},
+		{WithGroupingHash(hash), WithFingerprint("payments", "declined")},
+	}
+	for i, opts := range tests {
+		a, tr := newAlerter(t, Config{})
+		a.Error(errors.New("Failed"), opts...)
+		if v := tr.Event(t).Fingerprint; len(v) != 1 || v[0] != hash {
+			t.Errorf("#%d: Expected the fingerprint [%s]; got %v", i, hash, v)
+		}
+	}
+}
+
+func TestWithFingerprint(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Rate limited"), WithFingerprint("billing", "rate-limit"))
+	a.Error(errors.New("Timed out"), WithFingerprint("billing", "timeout"))
+	a.Error(errors.New("Failed"))
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events; got %d", len(events))
+	}
+	for i, want := range [][]string{{"billing", "rate-limit"}, {"billing", "timeout"}} {
+		if v := events[i].Fingerprint; !slices.Equal(v, want) {
+			t.Errorf("Expected the fingerprint %v; got %v", want, v)
+		}
+	}
+	if v := events[2].Fingerprint; v != nil {
+		t.Errorf("Expected no fingerprint when none is provided, so Sentry's default grouping applies; got %v", v)
+	}
+}
@@ -0,0 +1,224 @@
+// Package pagerduty provides an alert backend which pages via the PagerDuty
+// Events API v2.
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+)
+
+// The default maximum time spent sending an event.
+const defaultTimeout = 10 * time.Second
+
+// The endpoint events are sent to by default.
+const eventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// The maximum length of the summary of an event, in bytes.
+const maxSummaryLength = 1024
+
+var ErrNoRoutingKey = errors.New("PagerDuty backend requires a routing key")
+
+// Config configures a PagerDuty backend.
+type Config struct {
+	RoutingKey string // the integration key of the service paged
+	// Source identifies the system alerts are raised by; by default, the
+	// "host" tag of the alert or else the hostname is used.
+	Source string
+	// MinLevel is the least severe level of the alerts that page; by default,
+	// only errors and fatal alerts do.
+	MinLevel alert.Level
+
+	URL     string        // defaults to the Events API v2 endpoint
+	Timeout time.Duration // defaults to 10 seconds
+	Client  *http.Client  // defaults to http.DefaultClient
+}
+
+// Backend triggers PagerDuty incidents for alerts and resolves them via
+// alert.Resolve. Incidents are deduplicated by the reference of the alert,
+// so repeated alerts for the same reference are grouped into one incident,
+// which resolving the reference resolves. Alerts without a reference are
+// deduplicated by their fingerprint or, failing that, their message, and
+// cannot be resolved this way.
+//
+// Events are sent on the goroutine which delivers them, so alerters using
+// this backend should generally deliver asynchronously; see
+// alert.Config.Async.
+type Backend struct {
+	routingKey string
+	source     string
+	minLevel   alert.Level
+	url        string
+	timeout    time.Duration
+	client     *http.Client
+}
+
+var (
+	_ alert.Backend  = (*Backend)(nil)
+	_ alert.Resolver = (*Backend)(nil)
+)
+
+func New(conf Config) (*Backend, error) {
+	if conf.RoutingKey == "" {
+		return nil, ErrNoRoutingKey
+	}
+	b := &Backend{
+		routingKey: conf.RoutingKey,
+		source:     conf.Source,
+		minLevel:   conf.MinLevel,
+		url:        conf.URL,
+		timeout:    conf.Timeout,
+		client:     conf.Client,
+	}
+	if b.minLevel == "" {
+		b.minLevel = alert.LevelError
+	}
+	if b.url == "" {
+		b.url = eventsURL
+	}
+	if b.timeout <= 0 {
+		b.timeout = defaultTimeout
+	}
+	if b.client == nil {
+		b.client = http.DefaultClient
+	}
+	return b, nil
+}
+
+type payload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type event struct {
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key,omitempty"`
+	Payload     *payload `json:"payload,omitempty"`
+}
+
+func (b *Backend) Capture(e *alert.Event) error {
+	if !e.AtLeast(b.minLevel) {
+		return alert.ErrSkipped
+	}
+
+	source := b.source
+	if source == "" {
+		source = e.Tags["host"]
+	}
+	if source == "" {
+		source, _ = os.Hostname()
+	}
+
+	details := map[string]interface{}{"id": e.ID}
+	if len(e.Tags) > 0 {
+		details["tags"] = e.Tags
+	}
+	if len(e.Exception) > 0 {
+		chain := make([]string, 0, len(e.Exception))
+		for i := len(e.Exception) - 1; i >= 0; i-- {
+			chain = append(chain, fmt.Sprintf("%s: %s", e.Exception[i].Type, e.Exception[i].Value))
+		}
+		details["chain"] = chain
+	}
+
+	return b.send(event{
+		RoutingKey:  b.routingKey,
+		EventAction: "trigger",
+		DedupKey:    dedupKey(e),
+		Payload: &payload{
+			Summary:       truncate(e.Message, maxSummaryLength),
+			Source:        source,
+			Severity:      severity(e.Priority),
+			Timestamp:     e.Time.UTC().Format(time.RFC3339),
+			Component:     e.Component,
+			CustomDetails: details,
+		},
+	})
+}
+
+// Resolve resolves the incident for alerts with the specified reference.
+func (b *Backend) Resolve(ref string) error {
+	if ref == "" {
+		return errors.New("Cannot resolve an empty reference")
+	}
+	return b.send(event{
+		RoutingKey:  b.routingKey,
+		EventAction: "resolve",
+		DedupKey:    ref,
+	})
+}
+
+func (b *Backend) send(ev event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	cxt, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(cxt, http.MethodPost, b.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Could not send PagerDuty event: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		return fmt.Errorf("PagerDuty responded with status: %s: %s", rsp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// dedupKey produces the key incidents for an alert are deduplicated by.
+func dedupKey(e *alert.Event) string {
+	switch {
+	case e.Ref != "":
+		return e.Ref
+	case len(e.Fingerprint) > 0:
+		return strings.Join(e.Fingerprint, "/")
+	default:
+		return truncate(e.Message, 255)
+	}
+}
+
+// severity maps the priority of an alert to a PagerDuty severity. Unless it
+// was set via alert.WithPriority, the priority is derived from the level, so
+// fatal alerts are critical, errors are errors, and so on.
+func severity(p alert.Priority) string {
+	switch p {
+	case alert.PriorityUrgent:
+		return "critical"
+	case alert.PriorityHigh:
+		return "error"
+	case alert.PriorityNormal:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
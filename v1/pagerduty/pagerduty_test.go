@@ -0,0 +1,85 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bww/go-alert/v1"
+)
+
+// server records the events posted to it.
+type server struct {
+	sync.Mutex
+	events []event
+}
+
+func (s *server) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+	var ev event
+	if err := json.NewDecoder(req.Body).Decode(&ev); err != nil {
+		http.Error(rsp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.events = append(s.events, ev)
+	rsp.WriteHeader(http.StatusAccepted)
+}
+
+func TestPriority(t *testing.T) {
+	s := &server{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	b, err := New(Config{RoutingKey: "key", Source: "test", MinLevel: alert.LevelWarning, URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := alert.New(alert.Config{Backends: []alert.Backend{b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Warning(errors.New("Disk almost full"), alert.WithPriority(alert.PriorityUrgent))
+	a.Error(errors.New("Failed"))
+	a.Warning(errors.New("Slow"))
+
+	s.Lock()
+	defer s.Unlock()
+	if len(s.events) != 3 {
+		t.Fatalf("Expected 3 events; got %d", len(s.events))
+	}
+	for i, want := range []string{"critical", "error", "warning"} {
+		if v := s.events[i].Payload.Severity; v != want {
+			t.Errorf("Expected event %d to page with severity %s; got %s", i, want, v)
+		}
+	}
+	tags, _ := s.events[0].Payload.CustomDetails["tags"].(map[string]interface{})
+	if v := tags["priority"]; v != "urgent" {
+		t.Errorf("Expected the alert to be tagged with its priority; got %v", tags)
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+	s := &server{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	b, err := New(Config{RoutingKey: "key", URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Capture(&alert.Event{Level: alert.LevelWarning, Message: "Slow"}); !errors.Is(err, alert.ErrSkipped) {
+		t.Errorf("Expected an alert below the minimum level to be skipped; got %v", err)
+	}
+	if err := b.Capture(&alert.Event{Level: alert.LevelError, Message: "Failed"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.events); n != 1 {
+		t.Errorf("Expected only the error to page; got %d events", n)
+	}
+}
@@ -0,0 +1,241 @@
+package alert
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bww/go-router/v2"
+	"github.com/bww/go-util/v1/debug"
+	"github.com/getsentry/sentry-go"
+)
+
+// The time to wait for a panic to be delivered before the process exits.
+const panicFlushTimeout = 5 * time.Second
+
+// exit terminates the process; it is replaced in tests.
+var exit = os.Exit
+
+// panicError describes a recovered panic and the stack of the goroutine that
+// panicked.
+type panicError struct {
+	val    interface{}
+	frames []debug.Frame
+}
+
+func newPanicError(val interface{}) *panicError {
+	return &panicError{val: val, frames: debug.Stacktrace()}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.val)
+}
+
+func (e *panicError) Unwrap() error {
+	err, _ := e.val.(error)
+	return err
+}
+
+func (e *panicError) Frames() []debug.Frame {
+	return e.frames
+}
+
+// reportPanic reports a recovered panic as a fatal, unhandled error.
+func (a *Alerter) reportPanic(err *panicError, opts ...Option) {
+	opts = append([]Option{WithLevel(sentry.LevelFatal), withPanicMechanism()}, opts...)
+	a.report(err, opts...)
+}
+
+func withPanicMechanism() Option {
+	return func(c Context) Context {
+		handled := false
+		c.Mechanism = &sentry.Mechanism{Type: "panic", Handled: &handled}
+		return c
+	}
+}
+
+// RunMain runs the main function of a program and exits. If the function
+// panics, the panic is reported and delivered and the process exits with
+// status 2, as it would have had the panic not been recovered. If the
+// function returns an error it is reported and delivered and the process
+// exits with status 1. Otherwise the process exits with status 0.
+//
+// Only panics in the goroutine that calls RunMain are recovered. There is no
+// way to recover a panic in another goroutine from outside it, so goroutines
+// must recover their own panics explicitly.
+func (a *Alerter) RunMain(fn func() error) {
+	status := 0
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				a.reportPanic(newPanicError(r))
+				a.Flush(panicFlushTimeout)
+				status = 2
+			}
+		}()
+		if err := fn(); err != nil {
+			a.Error(err)
+			a.Flush(panicFlushTimeout)
+			status = 1
+		}
+	}()
+	exit(status)
+}
+
+// RunMain runs the main function of a program via the shared alerter, which
+// must be initialized, and exits. See Alerter.RunMain.
+func RunMain(fn func() error) {
+	a := Default()
+	if a == nil {
+		panic(ErrUnavailable)
+	}
+	a.RunMain(fn)
+}
+
+// InstallGlobalPanicHandler reports a panic in the goroutine that defers it
+// via the shared alerter, if it is initialized, and waits for it to be
+// delivered before the panic resumes. It is intended to be deferred as the
+// first statement of main:
+//
+//	func main() {
+//		alert.Init(conf)
+//		defer alert.InstallGlobalPanicHandler()
+//		...
+//	}
+//
+// The panic is not recovered: it continues once it has been reported, so the
+// process crashes as it otherwise would have. As with RunMain, only panics in
+// the goroutine which defers this are reported; others must recover their
+// own panics explicitly.
+func InstallGlobalPanicHandler() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if a := Default(); a != nil {
+		a.reportPanic(newPanicError(r))
+		a.Flush(panicFlushTimeout)
+	}
+	panic(r)
+}
+
+// Recover recovers a panic, if there is one, and reports it as a fatal,
+// unhandled error, attaching the stack of the goroutine that panicked. It
+// must be deferred directly:
+//
+//	defer a.Recover()
+//
+// A recovered value which is not an error is wrapped in one. The panic is
+// not resumed unless WithRepanic is provided.
+func (a *Alerter) Recover(opts ...Option) {
+	if r := recover(); r != nil {
+		a.recovered(r, opts...)
+	}
+}
+
+// ReportPanic reports a value the caller has recovered from a panic, in the
+// same manner as Recover, for callers which must do more once a panic is
+// recovered, e.g., answer the request it interrupted. It must be invoked by
+// the deferred function which recovered the value, so that the stack of the
+// goroutine that panicked is still available.
+func (a *Alerter) ReportPanic(r interface{}, opts ...Option) {
+	a.recovered(r, opts...)
+}
+
+// recovered reports a recovered panic value and resumes the panic if the
+// options so direct, in which case the report is delivered first, since the
+// process may be about to crash.
+func (a *Alerter) recovered(r interface{}, opts ...Option) {
+	a.reportPanic(newPanicError(r), opts...)
+	if newContext(opts).Repanic {
+		a.Flush(panicFlushTimeout)
+		panic(r)
+	}
+}
+
+// Recover recovers a panic, if there is one, and reports it via the shared
+// alerter. It must be deferred directly. See Alerter.Recover.
+func Recover(opts ...Option) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if a := Default(); a != nil {
+		a.recovered(r, opts...)
+	} else if newContext(opts).Repanic {
+		panic(r)
+	}
+}
+
+// RecoverHandler wraps a handler such that a panic while serving a request
+// is recovered and reported, with the request and the time spent handling it
+// attached, and the request is answered with 500 Internal Server Error.
+// Panics with http.ErrAbortHandler, which deliberately abort a request, are
+// resumed.
+func (a *Alerter) RecoverHandler(next http.Handler, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := a.now()
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			a.recovered(v, append([]Option{WithRequest((*router.Request)(r)), WithDuration(a.now().Sub(start))}, opts...)...)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RecoverHandler wraps a handler such that panics while serving requests are
+// reported via the shared alerter. See Alerter.RecoverHandler.
+func RecoverHandler(next http.Handler, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a := Default(); a != nil {
+			a.RecoverHandler(next, opts...).ServeHTTP(w, r)
+		} else {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Middleware produces router middleware which recovers a panic while
+// handling a request in the manner of RecoverHandler, answering the request
+// with 500 Internal Server Error. Errors returned by handlers are not
+// reported; see Handler for that.
+func (a *Alerter) Middleware(opts ...Option) router.Middle {
+	return router.MiddleFunc(func(h router.Handler) router.Handler {
+		return func(req *router.Request, cxt router.Context) (rsp *router.Response, err error) {
+			start := a.now()
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				a.recovered(v, append([]Option{WithRequest(req), WithDuration(a.now().Sub(start))}, opts...)...)
+				rsp, err = router.NewResponse(http.StatusInternalServerError).SetString("text/plain", http.StatusText(http.StatusInternalServerError))
+			}()
+			return h(req, cxt)
+		}
+	})
+}
+
+// Middleware produces router middleware which recovers panics while handling
+// requests and reports them via the shared alerter. See Alerter.Middleware.
+func Middleware(opts ...Option) router.Middle {
+	return router.MiddleFunc(func(h router.Handler) router.Handler {
+		return func(req *router.Request, cxt router.Context) (*router.Response, error) {
+			if a := Default(); a != nil {
+				return a.Middleware(opts...).Wrap(h)(req, cxt)
+			}
+			return h(req, cxt)
+		}
+	})
+}
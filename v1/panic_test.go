@@ -0,0 +1,107 @@
+package alert
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bww/go-router/v2"
+	"github.com/getsentry/sentry-go"
+)
+
+func TestMiddlewareDuration(t *testing.T) {
+	c := newClock()
+	a, tr := newAlerter(t, Config{Clock: c.Now})
+	h := a.Middleware().Wrap(func(req *router.Request, cxt router.Context) (*router.Response, error) {
+		c.Advance(2 * time.Second)
+		panic("Failed")
+	})
+
+	rsp, err := h(newRequest(t, "GET", "https://example.com/", nil), router.Context{})
+	if err != nil || rsp == nil || rsp.Status != 500 {
+		t.Fatalf("Expected the panic to be answered with 500; got %v, %v", rsp, err)
+	}
+	if v := tr.Event(t).Tags["duration_ms"]; v != "2000" {
+		t.Errorf("Expected duration_ms 2000; got %q", v)
+	}
+}
+
+func TestRecoverHandlerDuration(t *testing.T) {
+	c := newClock()
+	a, tr := newAlerter(t, Config{Clock: c.Now})
+	h := a.RecoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Advance(time.Second)
+		panic("Failed")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "https://example.com/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected the panic to be answered with 500; got %d", w.Code)
+	}
+	if v := tr.Event(t).Tags["duration_ms"]; v != "1000" {
+		t.Errorf("Expected duration_ms 1000; got %q", v)
+	}
+}
+
+func TestRunMain(t *testing.T) {
+	var status int
+	exit = func(code int) { status = code }
+	defer func() { exit = os.Exit }()
+
+	tests := []struct {
+		fn     func() error
+		status int
+		level  sentry.Level
+	}{
+		{func() error { panic("Failed") }, 2, sentry.LevelFatal},
+		{func() error { return errors.New("Failed") }, 1, sentry.LevelError},
+		{func() error { return nil }, 0, ""},
+	}
+	for _, e := range tests {
+		a, tr := newAlerter(t, Config{})
+		status = -1
+		a.RunMain(e.fn)
+		if status != e.status {
+			t.Errorf("Expected exit status %d; got %d", e.status, status)
+		}
+		events := tr.Events()
+		if e.level == "" {
+			if len(events) != 0 || tr.flushes != 0 {
+				t.Errorf("Expected nothing to be reported or flushed; got %d events", len(events))
+			}
+			continue
+		}
+		if len(events) != 1 || tr.flushes != 1 {
+			t.Fatalf("Expected one event to be reported and flushed before exiting; got %d events, %d flushes", len(events), tr.flushes)
+		}
+		if v := events[0].Level; v != e.level {
+			t.Errorf("Expected the event at %s; got %s", e.level, v)
+		}
+	}
+}
+
+func TestInstallGlobalPanicHandler(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	defer Set(Set(a))
+
+	var r interface{}
+	func() {
+		defer func() { r = recover() }()
+		defer InstallGlobalPanicHandler()
+		panic("Failed")
+	}()
+	if r != "Failed" {
+		t.Errorf("Expected the panic to resume once reported; got %v", r)
+	}
+	event := tr.Event(t)
+	if e := event.Exception[len(event.Exception)-1]; e.Mechanism == nil || e.Mechanism.Type != "panic" || *e.Mechanism.Handled {
+		t.Errorf("Expected the panic to be reported as unhandled; got %+v", e.Mechanism)
+	}
+	if tr.flushes != 1 {
+		t.Errorf("Expected the panic to be flushed before resuming; got %d flushes", tr.flushes)
+	}
+}
@@ -0,0 +1,36 @@
+package alert
+
+import (
+	"github.com/getsentry/sentry-go"
+)
+
+// Priority describes how urgently an alert demands attention, which is not
+// necessarily the same as how severe it is: a warning may be urgent in one
+// context and not another. Priority determines the urgency of pages sent by
+// paging sinks; it does not affect the level an alert is reported at.
+//
+// The priority of an alert is tagged as "priority". Unless it is set via
+// WithPriority it is derived from the level of the alert.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// priorityForLevel produces the default priority of an alert at the
+// specified level.
+func priorityForLevel(lvl sentry.Level) Priority {
+	switch lvl {
+	case sentry.LevelFatal:
+		return PriorityUrgent
+	case sentry.LevelError:
+		return PriorityHigh
+	case sentry.LevelWarning:
+		return PriorityNormal
+	default:
+		return PriorityLow
+	}
+}
@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Quota is implemented by errors which arise because a quota, typically one
+// imposed by a third party, has been exhausted. Quota errors are reported as
+// warnings by default, with the state of the quota attached.
+type Quota interface {
+	error
+	QuotaLimit() int
+	QuotaRemaining() int
+	QuotaReset() time.Time // when the quota resets, or the zero time if unknown
+}
+
+// QuotaError is a Quota error which may wrap an underlying cause.
+type QuotaError struct {
+	Resource  string // the name of the quota or the resource it limits
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Err       error
+}
+
+func (e *QuotaError) Error() string {
+	msg := fmt.Sprintf("Quota exhausted: %s (%d of %d remaining)", e.Resource, e.Remaining, e.Limit)
+	if !e.Reset.IsZero() {
+		msg += fmt.Sprintf(", resets at %s", e.Reset.Format(time.RFC3339))
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *QuotaError) Unwrap() error         { return e.Err }
+func (e *QuotaError) QuotaLimit() int       { return e.Limit }
+func (e *QuotaError) QuotaRemaining() int   { return e.Remaining }
+func (e *QuotaError) QuotaReset() time.Time { return e.Reset }
+
+// quotaFromError searches the error chain for a Quota error.
+func quotaFromError(err error) (Quota, bool) {
+	var q Quota
+	if errors.As(err, &q) {
+		return q, true
+	}
+	return nil, false
+}
+
+// quotaExtra produces the extra section which describes the state of the
+// quota.
+func quotaExtra(q Quota) map[string]interface{} {
+	extra := map[string]interface{}{
+		"limit":     q.QuotaLimit(),
+		"remaining": q.QuotaRemaining(),
+	}
+	if r := q.QuotaReset(); !r.IsZero() {
+		extra["reset"] = r.Format(time.RFC3339)
+	}
+	return extra
+}
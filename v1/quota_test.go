@@ -0,0 +1,51 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestQuotaError(t *testing.T) {
+	reset := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		err    error
+		tag    string
+		expect map[string]interface{}
+	}{
+		{
+			fmt.Errorf("Could not send: %w", &QuotaError{Resource: "messages", Limit: 100, Reset: reset, Err: errors.New("429 Too Many Requests")}),
+			"true",
+			map[string]interface{}{"limit": 100, "remaining": 0, "reset": "2024-01-01T12:00:00Z"},
+		},
+		{
+			&QuotaError{Resource: "messages", Limit: 100, Remaining: 3},
+			"false",
+			map[string]interface{}{"limit": 100, "remaining": 3},
+		},
+	}
+	for _, e := range tests {
+		a, tr := newAlerter(t, Config{})
+		a.Error(e.err)
+		event := tr.Event(t)
+		if event.Level != sentry.LevelWarning {
+			t.Errorf("Expected a quota error to be reported at %s; got %s", sentry.LevelWarning, event.Level)
+		}
+		if v := event.Tags["quota_exhausted"]; v != e.tag {
+			t.Errorf("Expected the quota_exhausted tag %q; got %q", e.tag, v)
+		}
+		if v := event.Extra["quota"]; !reflect.DeepEqual(v, e.expect) {
+			t.Errorf("Expected the quota %v; got %v", e.expect, v)
+		}
+	}
+
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Failed"))
+	if _, ok := tr.Event(t).Tags["quota_exhausted"]; ok {
+		t.Error("Expected the quota_exhausted tag only on quota errors")
+	}
+}
@@ -0,0 +1,136 @@
+package alert
+
+import (
+	"hash/maphash"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The default number of distinct errors tracked by the recent buffer.
+const defaultRecentLimit = 4096
+
+// occurrence describes the history of an error in the recent buffer.
+type occurrence struct {
+	First time.Time
+	Last  time.Time
+	Count int
+
+	// Suppressed counts the occurrences which have not been reported since
+	// the error was last reported.
+	Suppressed int
+
+	window time.Duration // the current backoff window
+	next   time.Time     // the earliest time the error may be reported again
+
+	dedupStart time.Time // when the current dedup window began
+	dedupSent  int       // the number of reports in the current dedup window
+
+	escalations []escalationWindow // the current window of each escalation
+
+	fatals []time.Time // the most recent fatal alerts; see crashloopKey
+	looped time.Time   // when a crash loop was last reported
+}
+
+// The number of shards the recent buffer is partitioned into, so that alerts
+// for different errors rarely contend for the same lock.
+const recentShards = 16
+
+// recent records the errors an alerter has reported, keyed by fingerprint.
+// This bookkeeping is kept in memory and is therefore scoped to the lifetime
+// of the process: a restarted process has no memory of errors seen before it.
+//
+// The number of tracked errors is bounded. Errors are partitioned into shards
+// by fingerprint, each of which holds an equal share of the limit. When a
+// shard is full, the error in it that was least recently seen is evicted to
+// make room; should it occur again it is treated as though it had never been
+// seen. The occurrences of an evicted error which were suppressed are retained
+// until the next rollup, so that they are still accounted for.
+type recent struct {
+	seed   maphash.Seed
+	shards []recentShard
+}
+
+type recentShard struct {
+	sync.Mutex
+	limit   int
+	entries map[string]*occurrence
+	evicted []Rollup // evicted entries with suppressed occurrences; see Rollup
+}
+
+func newRecent(limit int) *recent {
+	return newRecentShards(limit, recentShards)
+}
+
+// newRecentShards creates a recent buffer partitioned into the specified
+// number of shards.
+func newRecentShards(limit, shards int) *recent {
+	if limit <= 0 {
+		limit = defaultRecentLimit
+	}
+	r := &recent{seed: maphash.MakeSeed(), shards: make([]recentShard, shards)}
+	for i := range r.shards {
+		r.shards[i] = recentShard{
+			limit:   max(limit/shards, 1),
+			entries: make(map[string]*occurrence),
+		}
+	}
+	return r
+}
+
+// Observe records an occurrence of the error identified by key at the
+// specified time and returns its updated history.
+//
+// If an update function is provided it is invoked with the entry for the
+// error under the lock of its shard, after its count has been incremented but
+// before the time it was last seen has been updated. This allows for policy
+// decisions that depend on the entry's history to be made atomically.
+func (r *recent) Observe(key string, now time.Time, update func(e *occurrence, now time.Time)) occurrence {
+	s := &r.shards[maphash.String(r.seed, key)%uint64(len(r.shards))]
+	s.Lock()
+	defer s.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		if len(s.entries) >= s.limit {
+			s.evict()
+		}
+		e = &occurrence{First: now, Last: now}
+		s.entries[key] = e
+	}
+	e.Count++
+	if update != nil {
+		update(e, now)
+	}
+	e.Last = now
+	return *e
+}
+
+// evict removes the least recently seen entry. The caller must hold the lock.
+func (s *recentShard) evict() {
+	var (
+		oldest string
+		last   time.Time
+	)
+	for k, e := range s.entries {
+		if oldest == "" || e.Last.Before(last) {
+			oldest, last = k, e.Last
+		}
+	}
+	if e := s.entries[oldest]; e.Suppressed > 0 && len(s.evicted) < s.limit {
+		s.evicted = append(s.evicted, e.rollup(oldest))
+	}
+	delete(s.entries, oldest)
+}
+
+// fingerprint produces the key which identifies an error in the recent
+// buffer: its reference, if it has one, and its message.
+func fingerprint(ref string, err error) string {
+	return ref + "\x00" + err.Error()
+}
+
+// splitFingerprint produces the reference and message a fingerprint was
+// produced from.
+func splitFingerprint(key string) (string, string) {
+	ref, msg, _ := strings.Cut(key, "\x00")
+	return ref, msg
+}
@@ -0,0 +1,50 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFirstSeen(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Failed"))
+	a.Error(errors.New("Failed"))
+	a.Error(errors.New("Failed differently"))
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected three events; got %d", len(events))
+	}
+	for i, want := range []string{"true", "false", "true"} {
+		if v := events[i].Tags["first_seen"]; v != want {
+			t.Errorf("Expected event %d to have first_seen %s; got %q", i, want, v)
+		}
+	}
+}
+
+// BenchmarkError reports distinct errors from many goroutines, comparing an
+// unsharded recent buffer, in which every alert contends for the same lock,
+// with the sharded one.
+func BenchmarkError(b *testing.B) {
+	errs := make([]error, 64)
+	for i := range errs {
+		errs[i] = fmt.Errorf("Failed %d", i)
+	}
+	for _, shards := range []int{1, recentShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			// only the first occurrence of each error is sent, so the client
+			// doesn't dominate
+			a, _ := newAlerter(b, Config{FirstOnly: true, RollupInterval: -1})
+			a.recent = newRecentShards(defaultRecentLimit, shards)
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := int(next.Add(1)); pb.Next(); i++ {
+					a.Error(errs[i%len(errs)])
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package alert
+
+import (
+	"regexp"
+)
+
+// secretPatterns match secrets which commonly appear in error messages. The
+// first and second submatches of each, if any, are preserved as the prefix
+// and suffix of the secret, which is masked.
+var secretPatterns = []*regexp.Regexp{
+	// credentials in URLs, e.g., connection strings
+	regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://[^:/@\s]*:)[^@\s]+(@)`),
+	// bearer and basic authorization
+	regexp.MustCompile(`(?i)(\b(?:bearer|basic)\s+)[a-zA-Z0-9._~+/=-]+`),
+	// assignments to sensitive names, e.g., "password=hunter2" or "token: abc"
+	regexp.MustCompile(`(?i)(\b[a-z_-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[=:]\s*["']?)[^\s"'&,;]+`),
+	// JSON web tokens
+	regexp.MustCompile(`()\beyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`),
+	// AWS access key IDs
+	regexp.MustCompile(`()\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`),
+}
+
+// DefaultMessageRedactor masks secrets which commonly appear in error
+// messages, such as credentials in URLs, authorization tokens, and values
+// assigned to names like "password" or "api_key".
+func DefaultMessageRedactor(msg string) string {
+	for _, p := range secretPatterns {
+		msg = p.ReplaceAllString(msg, "${1}"+redacted+"${2}")
+	}
+	return msg
+}
@@ -0,0 +1,598 @@
+package alert
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bww/go-ident/v1"
+	"github.com/bww/go-router/v2"
+	errutil "github.com/bww/go-util/v1/errors"
+	"github.com/getsentry/sentry-go"
+)
+
+// raised describes an alert as report resolves it, stage by stage.
+type raised struct {
+	err    error
+	search error // err, or an acyclic equivalent of it, for searching its chain
+	cyclic bool
+	orig   []Option // the options provided by the caller
+	opts   []Option // the options of the alerter followed by those of the caller
+	cxt    Context
+
+	component string
+	policy    ComponentPolicy
+	ref       string
+	channel   ident.Ident
+
+	// resolved by resolveLevel
+	lvl      sentry.Level // the level derived from the error; see level
+	grpc     *grpcStatus
+	quota    Quota
+	priority Priority
+	rate     float64
+	looping  bool
+	fatals   int
+
+	// resolved by suppress
+	outcome     Outcome
+	key         string
+	occ         occurrence
+	suppressed  int
+	escalation  Escalation
+	escalations int
+	escalating  bool
+
+	// resolved by resolveFields
+	tags    Tags
+	extra   map[string]interface{}
+	logOnly map[string]interface{} // attributes which are only logged
+}
+
+// level produces the level the alert is reported at, which the options may
+// override.
+func (r *raised) level() sentry.Level {
+	return r.cxt.sentryLevel(r.lvl)
+}
+
+// report reports an error and produces the identifier of the event that was
+// captured, if one was. The alert is processed in stages: its level is
+// resolved, then whether it is suppressed, then its tags and extra, and, if it
+// is sent, its event is built and delivered.
+func (a *Alerter) report(err error, opts ...Option) *sentry.EventID {
+	r := a.raise(err, opts)
+	if r.cxt.Condition != nil && !r.cxt.Condition() {
+		a.count(AlertMetric{Outcome: OutcomeIgnored, Component: r.component, Level: r.cxt.sentryLevel(sentry.LevelError)})
+		return nil
+	}
+
+	h, unavailable := a.scope()
+	logging := (a.verbose || unavailable) && a.log != nil
+
+	if !a.resolveLevel(r) {
+		a.count(AlertMetric{Outcome: OutcomeIgnored, Component: r.component, Level: r.level()})
+		return nil
+	}
+	a.suppress(r, h, unavailable)
+	a.resolveFields(r, h)
+
+	var id *sentry.EventID
+	if r.outcome == OutcomeDigested {
+		a.digests.Add(r.key, err, r.level(), r.extra, append(slices.Clip(r.opts), WithComponent(r.component)), a.now())
+	}
+	if r.outcome == OutcomeSent {
+		event := a.buildEvent(r, h.Scope())
+		id = a.dispatchEvent(r, h, event)
+	}
+	a.count(AlertMetric{Outcome: r.outcome, Component: r.component, Channel: r.channel, Level: r.level()})
+	if logging {
+		a.logAlert(r, id)
+	}
+	a.followUp(r)
+	return id
+}
+
+// raise resolves the options of an alert and the attributes which do not
+// depend on its level.
+func (a *Alerter) raise(err error, opts []Option) *raised {
+	r := &raised{err: err, search: err, orig: opts, opts: opts}
+	if len(a.options) > 0 {
+		r.opts = append(slices.Clip(a.options), opts...)
+	}
+	r.cxt = newContext(r.opts).inherit()
+
+	r.component = a.component
+	if r.cxt.Component != "" {
+		r.component = r.cxt.Component
+	}
+	r.policy = a.components[r.component]
+
+	// the chain is searched via an acyclic equivalent, since errors.Is and
+	// errors.As never terminate when searching a chain with a cycle
+	r.cyclic = hasCycle(err)
+	if r.cyclic {
+		r.search = acyclic(err)
+	}
+
+	r.ref = r.cxt.Ref
+	if r.ref == "" {
+		r.ref = errutil.Refstr(r.search)
+	}
+	r.channel = a.channel
+	if !r.cxt.Channel.IsZero() {
+		r.channel = r.cxt.Channel
+	}
+	return r
+}
+
+// scope produces a hub for an alert, on which its scope is set, or nil if
+// nothing can be delivered, in which case the alert is logged. If Sentry is
+// unavailable the alert is reported as unavailable, so that it is logged
+// regardless of verbosity.
+func (a *Alerter) scope() (*sentry.Hub, bool) {
+	if (a.sentry == nil && len(a.tee) == 0 && len(a.backends) == 0 && len(a.routes) == 0) || a.closed.Load() {
+		return nil, false
+	}
+	h := a.hub.Clone()
+	if a.sentry != nil && h.Client() == nil {
+		// the client was unbound from the hub out from under us; nothing
+		// can be delivered to Sentry, so make sure the alert is at least logged
+		a.notify(fmt.Errorf("%w: Sentry hub has no client", ErrUnavailable))
+		if len(a.tee) == 0 {
+			h = nil
+		}
+		return h, true
+	}
+	return h, false
+}
+
+// resolveLevel resolves the level of an alert from its error, the ignored
+// errors, and the sentinels, and then the attributes which depend on it. It
+// reports false if the alert is ignored.
+func (a *Alerter) resolveLevel(r *raised) bool {
+	r.lvl = sentry.LevelError
+	if st, ok := grpcStatusFromError(r.err); ok {
+		r.grpc = &st
+		r.lvl = st.Code.Level()
+	}
+	if q, ok := quotaFromError(r.search); ok {
+		r.quota = q
+		r.lvl = sentry.LevelWarning
+	}
+	if ignored(a.ignore, r.search) {
+		return false
+	}
+	if sentinel, ok := matchSentinel(a.sentinels, r.search); ok {
+		if sentinel.Ignore {
+			return false
+		}
+		if sentinel.Level != "" {
+			r.lvl = sentinel.Level
+		}
+	}
+
+	if _, ok := r.err.(CrashloopError); !ok && a.crashloop.enabled() && r.level() == sentry.LevelFatal {
+		r.fatals, r.looping = a.crashloop.observe(a.recent, a.now())
+	}
+
+	r.priority = r.cxt.Priority
+	if r.priority == "" {
+		r.priority = priorityForLevel(r.level())
+	}
+	r.rate = a.rateFor(&Event{
+		Time:      a.now(),
+		Level:     r.level(),
+		Message:   errorTitle(r.err),
+		Err:       r.err,
+		Ref:       r.ref,
+		Component: r.component,
+		Priority:  r.priority,
+		Channel:   r.channel,
+	}, r.policy)
+	if _, ok := r.err.(DigestError); ok {
+		r.rate = 1 // its occurrences were sampled already
+	}
+	return true
+}
+
+// suppress resolves the outcome of an alert: whether it is sent, or is
+// logged, dropped, ignored, or sampled instead, or is suppressed as a
+// duplicate, via FirstOnly, Backoff, or Dedup, or collected into a digest. The
+// occurrence of the alert is recorded regardless, and any escalation it
+// triggers is resolved.
+func (a *Alerter) suppress(r *raised, h *sentry.Hub, unavailable bool) {
+	r.outcome = OutcomeSent
+	switch {
+	case h == nil && !unavailable:
+		r.outcome = OutcomeLogged
+	case h == nil:
+		r.outcome = OutcomeDropped
+	case r.policy.MinLevel != "" && !atLeast(r.level(), r.policy.MinLevel):
+		r.outcome = OutcomeIgnored
+	case !sample(r.rate):
+		r.outcome = OutcomeSampled
+	}
+
+	_, isDigest := r.err.(DigestError)
+	r.key = fingerprint(r.ref, r.err)
+	r.occ = a.recent.Observe(r.key, a.now(), func(e *occurrence, now time.Time) {
+		if _, ok := r.err.(EscalationError); !ok && len(a.escalations) > 0 {
+			r.escalation, r.escalations, r.escalating = escalate(a.escalations, e, r.level(), now)
+		}
+		if _, ok := r.err.(suppressedError); ok || r.outcome != OutcomeSent {
+			return
+		}
+		if a.digest.enabled() && !isDigest && a.digest.admit(r.level()) {
+			r.outcome = OutcomeDigested
+		} else if a.firstOnly {
+			if e.Count > 1 {
+				e.Suppressed++
+				r.outcome = OutcomeDeduped
+			}
+		} else if a.backoff.enabled() {
+			if a.backoff.admit(e, now) {
+				r.suppressed, e.Suppressed = e.Suppressed, 0
+			} else {
+				r.outcome = OutcomeDeduped
+			}
+		} else if a.dedup.enabled() {
+			if a.dedup.admit(e, now) {
+				r.suppressed, e.Suppressed = e.Suppressed, 0
+			} else {
+				r.outcome = OutcomeDeduped
+			}
+		}
+	})
+}
+
+// resolveFields resolves the tags and extra of an alert, and sets its scope on
+// the hub, if there is one. They are resolved once, here, and the same values
+// are reported to Sentry and logged. Where several sources contribute a value
+// for the same key, the value from the later source in the following order
+// takes precedence:
+//
+//  1. the tags of the alerter, including its host, and then the default tags
+//     of the component's policy;
+//  2. tags and extra derived from the error, its response, or its status;
+//  3. tags derived from the request;
+//  4. tags and extra provided by the caller via WithTags and WithExtra;
+//  5. tags and extra set by options which describe specific attributes of
+//     the alert, such as WithOrigin or WithRunbook;
+//  6. the tags reserved by the alerter: component, ref, first_seen, and
+//     cycle_detected.
+//
+// Tags and extra are logged together as the attributes of the log record.
+// If a key is present in both, the tag is logged.
+func (a *Alerter) resolveFields(r *raised, h *sentry.Hub) {
+	cxt := r.cxt
+	tags := make(Tags)
+	extra := make(map[string]interface{})
+	logOnly := make(map[string]interface{})
+
+	// 1. alerter and component defaults
+	merge(tags, a.tags)
+	merge(tags, r.policy.Tags)
+
+	// 2. derived from the error
+	promoteErrutil(r.err, tags, extra)
+	if rsp := responseFromError(r.search); rsp != nil {
+		responseTags(rsp, a.responseHeaders, tags)
+	}
+	if ie, ok := interactionFromError(r.search); ok {
+		extra["http"] = ie.interaction()
+	}
+	if st := r.grpc; st != nil {
+		tags["grpc_code"] = st.Code.String()
+		if len(st.Details) > 0 {
+			extra["grpc_details"] = st.Details
+		}
+	}
+	if q := r.quota; q != nil {
+		tags["quota_exhausted"] = q.QuotaRemaining() <= 0
+		extra["quota"] = quotaExtra(q)
+	}
+
+	// the deployment and build, which events carry in their own fields
+	if a.environment != "" {
+		logOnly["environment"] = a.environment
+	}
+	if a.build.release != "" {
+		logOnly["release"] = a.build.release
+	}
+	if a.build.revision != "" {
+		logOnly["revision"] = a.build.revision
+	}
+	if h != nil && a.build.context != nil {
+		h.Scope().SetContext("build", a.build.context)
+	}
+
+	// 3. derived from the request
+	if req := cxt.Request; req != nil {
+		if h != nil {
+			h.Scope().SetRequest(a.request((*http.Request)(req)))
+			if body, ok := a.requestBody(req); ok {
+				h.Scope().SetRequestBody(body)
+			}
+			h.Scope().SetUser(sentry.User{IPAddress: req.OriginAddr()})
+		}
+		logOnly["request"] = fmt.Sprintf("%s %s", req.Method, req.URL.String())
+		if match := router.MatchFromContext(req.Context()); match != nil {
+			for k, v := range match.Vars {
+				tags[paramTagPrefix+k] = v
+			}
+		}
+		if cxt.BodyHash {
+			if sum, ok := bodyHash(req); ok {
+				tags["body_hash"] = sum
+			}
+		}
+
+	}
+
+	// 4. provided by the caller
+	merge(tags, cxt.Tags)
+	merge(extra, cxt.Extra)
+
+	// 5. specific attributes
+	if cxt.Origin != "" {
+		tags["origin"] = cxt.Origin
+	}
+	if cxt.Duration > 0 {
+		tags["duration_ms"] = cxt.Duration.Milliseconds()
+	}
+	if cxt.CallPath != "" {
+		tags["call_path"] = cxt.CallPath
+	}
+	runbook := cxt.Runbook
+	if runbook == "" && a.runbooks != nil {
+		runbook = a.runbooks(r.search)
+	}
+	if runbook != "" {
+		tags["runbook"] = runbook
+		extra["runbook"] = runbook
+	}
+	if len(cxt.Artifacts) > 0 {
+		extra["artifacts"] = cxt.Artifacts
+	}
+	if len(cxt.Attachments) > 0 {
+		logOnly["attachments"] = attachmentAttrs(cxt.Attachments)
+	}
+	if m := cxt.Diff; m != nil {
+		extra["diff"] = m.extra(a.scrubber)
+	}
+	flags := cxt.Flags
+	if flags == nil && a.flags != nil {
+		flags = a.flags(cxt.goContext())
+	}
+	if len(flags) > 0 {
+		scrubbed := scrubFlags(a.scrubber, flags)
+		if h != nil {
+			h.Scope().SetContext("flags", flagsContext(scrubbed))
+		}
+		logOnly["flags"] = scrubbed
+	}
+	if u := cxt.User; u != nil {
+		if h != nil {
+			h.Scope().SetUser(*u)
+		}
+		logOnly["user"] = userAttrs(u)
+	}
+	if cxt.ProcessInfo || a.processInfo {
+		extra["started_at"] = a.started.Format(time.RFC3339)
+		extra["uptime_seconds"] = int64(a.now().Sub(a.started).Seconds())
+	}
+	span := cxt.Span
+	if span == nil {
+		span = sentry.SpanFromContext(cxt.goContext())
+	}
+	traceID, _ := cxt.goContext().Value(TraceIDKey).(string)
+	spanID, _ := cxt.goContext().Value(SpanIDKey).(string)
+	if p := cxt.ParentTrace; p != nil {
+		if h != nil {
+			h.Scope().SetContext("trace", p.traceContext())
+		}
+		logOnly["trace_id"] = p.TraceID
+		logOnly["parent_span_id"] = p.SpanID
+	} else if span != nil {
+		if h != nil {
+			h.Scope().SetContext("trace", spanContext(span))
+		}
+		logOnly["trace_id"] = span.TraceID.String()
+		logOnly["span_id"] = span.SpanID.String()
+	} else if p, ok := a.traceFromContext(cxt.goContext()); ok {
+		if h != nil {
+			h.Scope().SetContext("trace", sentry.Context{"trace_id": p.TraceID, "span_id": p.SpanID})
+		}
+		tags["trace_id"] = p.TraceID
+		tags["span_id"] = p.SpanID
+	} else if traceID != "" {
+		tags["trace_id"] = traceID
+		if spanID != "" {
+			logOnly["span_id"] = spanID
+		}
+	}
+	tags["priority"] = string(r.priority)
+	if r.outcome == OutcomeSent && r.rate < 1 {
+		tags["sample_rate"] = r.rate
+	}
+	if r.suppressed > 0 {
+		extra["suppressed"] = r.suppressed
+	}
+	if a.firstOnly {
+		tags["occurrences"] = r.occ.Count
+	}
+
+	// 6. reserved
+	if r.component != "" {
+		tags["component"] = r.component
+	}
+	if r.ref != "" {
+		tags["ref"] = r.ref
+	}
+	tags["first_seen"] = r.occ.Count == 1
+	if r.cyclic {
+		tags["cycle_detected"] = true
+	}
+
+	r.tags = scrubFields(a.scrubber, tags)
+	r.extra = scrubFields(a.scrubber, extra)
+	r.logOnly = scrubFields(a.scrubber, logOnly)
+	if h != nil {
+		s := h.Scope()
+		for k, v := range r.tags {
+			s.SetTag(k, fmt.Sprint(v))
+		}
+	}
+	if len(r.extra) == 0 {
+		r.extra = nil
+	}
+}
+
+// buildEvent builds the event which is sent for an alert, adding its
+// attachments and breadcrumbs to its scope.
+func (a *Alerter) buildEvent(r *raised, s *sentry.Scope) *sentry.Event {
+	cxt := r.cxt
+	for _, e := range cxt.Attachments {
+		s.AddAttachment(e)
+	}
+	crumbs := append(a.breadcrumbs.Drain(), contextBreadcrumbs(cxt.goContext())...)
+	for _, c := range append(crumbs, cxt.Breadcrumbs...) {
+		s.AddBreadcrumb(&c, maxBreadcrumbs)
+	}
+	event := a.eventFromError(r.err, r.level(), r.extra)
+	event.Environment = a.environment
+	event.Release = a.build.release
+	if a.requestLogs {
+		// the records are already in the log, so they are only reported
+		if logs, ok := requestLogs(cxt.goContext()); ok {
+			withLogs := scrubFields(a.scrubber, map[string]interface{}{"logs": logs})
+			merge(withLogs, event.Extra)
+			event.Extra = withLogs
+		}
+	}
+	if n := len(event.Exception); n > 0 {
+		mech := cxt.Mechanism
+		if mech == nil {
+			mech = defaultMechanism()
+		}
+		event.Exception[n-1].Mechanism = groupMechanism(mech, event.Exception[n-1].Mechanism)
+		if len(cxt.Frames) > 0 {
+			event.Exception[n-1].Stacktrace = convertStacktrace(cxt.Frames)
+		} else if (cxt.CallerStack || a.callerStack) && !hasStacktrace(event.Exception) {
+			event.Exception[n-1].Stacktrace = callerStacktrace()
+		}
+	}
+	if _, ok := r.err.(message); ok {
+		event.Message = r.err.Error()
+		event.Exception = nil
+	} else if !a.warningExceptions && !atLeast(event.Level, sentry.LevelError) {
+		// less severe alerts are reported in the form of a message
+		if event.Message == "" {
+			event.Message = r.err.Error()
+		}
+		event.Exception = nil
+	}
+	event.Fingerprint = cxt.Fingerprint
+	if len(event.Fingerprint) == 0 {
+		event.Fingerprint, _ = errorFingerprint(r.err)
+	}
+	if cxt.GroupingHash != "" {
+		event.Fingerprint = []string{cxt.GroupingHash}
+	}
+	event.Message = a.redact(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = a.redact(event.Exception[i].Value)
+	}
+	if a.callerTransaction && cxt.Request == nil {
+		event.Transaction = callerFunction()
+	}
+	return event
+}
+
+// dispatchEvent delivers the event for an alert, after BeforeSend, if any, has
+// had a chance to alter or drop it: on the caller's goroutine for CaptureSync,
+// via the async queue if there is one, or else directly. It produces the
+// identifier of the event, if it was captured. When the event is delivered on
+// the spot the outcome of the alert is updated to reflect whether it was.
+func (a *Alerter) dispatchEvent(r *raised, h *sentry.Hub, event *sentry.Event) *sentry.EventID {
+	var ev *Event
+	if len(a.backends) > 0 || len(a.routes) > 0 || a.beforeSend != nil {
+		event.EventID = newEventID()
+		ev = a.backendEvent(event, r.err, r.ref, r.component, r.priority, r.tags, r.cxt.Request)
+		ev.Channel = r.channel
+		ev.Environment = a.environment
+		ev.Release = a.build.release
+		ev.User = r.cxt.User
+		ev.Attachments = r.cxt.Attachments
+	}
+	if a.beforeSend != nil {
+		if ev = a.beforeSend(ev); ev == nil {
+			r.outcome = OutcomeIgnored
+			return nil
+		}
+		applyEvent(h.Scope(), event, ev, r.tags)
+	}
+	switch {
+	case r.cxt.delivery != nil:
+		*r.cxt.delivery = a.send(h, event, r.err, ev)
+		a.deadLetter(ev, r.cxt.delivery.failed...)
+		r.outcome = r.cxt.delivery.outcome()
+		return r.cxt.delivery.id
+	case a.async != nil:
+		if event.EventID == "" {
+			event.EventID = newEventID()
+		}
+		eid := event.EventID
+		a.async.Enqueue(a, dispatch{hub: h, event: event, err: r.err, alert: ev})
+		return &eid
+	default:
+		d := a.send(h, event, r.err, ev)
+		a.deliveryFailed(ev, d.failed, 0)
+		r.outcome = d.outcome()
+		return d.id
+	}
+}
+
+// logAlert logs an alert with its tags and extra, and the identifier of its
+// event, if it was captured.
+func (a *Alerter) logAlert(r *raised, id *sentry.EventID) {
+	attrs := r.logOnly
+	merge(attrs, r.extra)
+	merge(attrs, r.tags)
+	if id != nil {
+		attrs["sentry_id"] = string(*id)
+	}
+	rec := append([]slog.Attr{slog.String("alert", string(r.level()))}, attrsFromMap(attrs)...)
+	logLevel := r.cxt.logLevel(r.lvl)
+	if a.consistentLevels {
+		logLevel = slogLevel(r.level())
+	}
+	a.log.LogAttrs(alertLogContext, logLevel, a.redact(r.err.Error()), a.replaceAttrs(rec)...)
+}
+
+// followUp performs what follows from an alert once it is reported: recording
+// it on the tracer, posting it to Slack, and reporting any crashloop or
+// escalation it triggered.
+func (a *Alerter) followUp(r *raised) {
+	cxt := r.cxt
+	if a.tracer != nil && r.outcome != OutcomeIgnored {
+		if _, ok := r.err.(message); !ok {
+			a.tracer.RecordError(cxt.goContext(), r.err)
+		}
+	}
+	if a.slack != nil && (r.outcome == OutcomeSent || r.outcome == OutcomeLogged) && cxt.goContext().Err() == nil {
+		a.postSlack(cxt.goContext(), SlackMessage{
+			Channel: r.channel,
+			Level:   r.level(),
+			Text:    slackText(r.level(), a.redact(errorTitle(r.err)), r.tags),
+		})
+	}
+	if r.looping {
+		a.reportCrashloop(r.fatals, WithComponent(r.component))
+	}
+	if r.escalating {
+		a.reportEscalation(r.escalation, r.err, r.escalations, r.orig...)
+	}
+}
@@ -0,0 +1,341 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bww/go-router/v2"
+	"github.com/bww/go-router/v2/path"
+	"github.com/getsentry/sentry-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newRequest produces a request as it is provided to a handler, which matched
+// a route with the specified variables, if any.
+func newRequest(tb testing.TB, method, url string, vars path.Vars) *router.Request {
+	tb.Helper()
+	req, err := router.NewRequest(method, url, nil)
+	if err != nil {
+		tb.Fatalf("Could not create request: %v", err)
+	}
+	if vars != nil {
+		cxt := router.NewMatchContext(req.Context(), &router.Match{Method: method, Vars: vars})
+		req = (*router.Request)((*http.Request)(req).WithContext(cxt))
+	}
+	return req
+}
+
+func TestRouteParamTags(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+
+	req := newRequest(t, "GET", "https://example.com/users/123/posts/abc", path.Vars{"user": "123", "post": "abc"})
+	a.Error(errors.New("Not found"), WithRequest(req))
+
+	tags := tr.Event(t).Tags
+	if v := tags["param.user"]; v != "123" {
+		t.Errorf("Expected param.user to be tagged 123; got %q", v)
+	}
+	if v := tags["param.post"]; v != "abc" {
+		t.Errorf("Expected param.post to be tagged abc; got %q", v)
+	}
+}
+
+func TestRouteParamTagsWithoutMatch(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+
+	a.Error(errors.New("Not found"), WithRequest(newRequest(t, "GET", "https://example.com/users/123", nil)))
+
+	for k := range tr.Event(t).Tags {
+		if strings.HasPrefix(k, paramTagPrefix) {
+			t.Errorf("Expected no route parameters to be tagged; got %s", k)
+		}
+	}
+}
+
+func TestClientlessHub(t *testing.T) {
+	log, recs := newLogger()
+	var notified []error
+	m := &metrics{}
+	a, tr := newAlerter(t, Config{Logger: log, Verbose: Bool(false), Metrics: m, OnError: func(err error) { notified = append(notified, err) }})
+	a.hub.BindClient(nil)
+
+	if id := a.Error(errors.New("Failed")); id != nil {
+		t.Errorf("Expected no event to be captured; got %s", *id)
+	}
+	if n := len(tr.Events()); n != 0 {
+		t.Errorf("Expected no events; got %d", n)
+	}
+	if len(notified) != 1 || !errors.Is(notified[0], ErrUnavailable) {
+		t.Errorf("Expected the alerter to report that Sentry is unavailable; got %v", notified)
+	}
+	if v := recs.Record(t)["msg"]; v != "Failed" {
+		t.Errorf("Expected the alert to be logged regardless of verbosity; got %v", v)
+	}
+	if v := m.Outcomes(); !slices.Equal(v, []Outcome{OutcomeDropped}) {
+		t.Errorf("Expected the alert to be counted as dropped; got %v", v)
+	}
+}
+
+func TestClientlessHubWithTee(t *testing.T) {
+	tee, ttr := newClient(t)
+	a, _ := newAlerter(t, Config{Tee: []Client{tee}})
+	a.hub.BindClient(nil)
+
+	a.Error(errors.New("Failed"))
+	if n := len(ttr.Events()); n != 1 {
+		t.Errorf("Expected the tee client to receive the event; got %d events", n)
+	}
+}
+
+func TestFieldsConsistentAcrossSinks(t *testing.T) {
+	log, recs := newLogger()
+	a, tr := newAlerter(t, Config{
+		Verbose:    Bool(true),
+		Logger:     log,
+		Hostname:   "web-1",
+		Component:  "api",
+		Tags:       Tags{"region": "us", "tier": "web"},
+		Components: map[string]ComponentPolicy{"api": {Tags: Tags{"tier": "api", "team": "platform"}}},
+	})
+	req := newRequest(t, "GET", "https://example.com/users/123", path.Vars{"user": "123"})
+	a.Error(errors.New("Failed"),
+		WithRequest(req),
+		WithTags(Tags{"region": "eu", "component": "spoofed", "param.user": "456"}),
+		WithExtra(map[string]interface{}{"attempt": 3}),
+		WithOrigin("webhook"))
+
+	event := tr.Event(t)
+	want := map[string]string{
+		"host":       "web-1",
+		"region":     "eu",       // the caller's tags take precedence over the alerter's
+		"tier":       "api",      // the component's over the alerter's
+		"team":       "platform", // the component's
+		"param.user": "456",      // the caller's over the request's
+		"component":  "api",      // reserved
+		"origin":     "webhook",
+	}
+	for k, v := range want {
+		if event.Tags[k] != v {
+			t.Errorf("Expected the event to be tagged %s=%s; got %q", k, v, event.Tags[k])
+		}
+	}
+
+	// every tag and extra is logged, with the value reported to Sentry
+	rec := recs.Record(t)
+	for k, v := range event.Tags {
+		if fmt.Sprint(rec[k]) != v {
+			t.Errorf("Expected %s to be logged as %q, as it was reported; got %v", k, v, rec[k])
+		}
+	}
+	if v := rec["attempt"]; v != float64(3) {
+		t.Errorf("Expected the extra to be logged; got %v", v)
+	}
+	recs.Lock()
+	line := recs.String()
+	recs.Unlock()
+	for k := range event.Tags {
+		if n := strings.Count(line, fmt.Sprintf("%q:", k)); n != 1 {
+			t.Errorf("Expected %s to be logged once; got %d", k, n)
+		}
+	}
+}
+
+func TestResolveLevel(t *testing.T) {
+	a, _ := newAlerter(t, Config{
+		Ignore:    []Ignore{IgnoreIs(errIgnored)},
+		Sentinels: append([]Sentinel{{Err: errSentinel, Level: sentry.LevelDebug}}, DefaultSentinels...),
+	})
+	tests := []struct {
+		name   string
+		err    error
+		opts   []Option
+		level  sentry.Level
+		ignore bool
+	}{
+		{"default", errors.New("Failed"), nil, sentry.LevelError, false},
+		{"grpc", status.Error(codes.NotFound, "Missing"), nil, sentry.LevelWarning, false},
+		{"quota", &QuotaError{Resource: "api"}, nil, sentry.LevelWarning, false},
+		{"sentinel", fmt.Errorf("Canceled: %w", context.Canceled), nil, sentry.LevelInfo, false},
+		{"custom sentinel", errSentinel, nil, sentry.LevelDebug, false},
+		{"option", context.Canceled, []Option{WithLevel(sentry.LevelFatal)}, sentry.LevelFatal, false},
+		{"ignored", fmt.Errorf("Wrapped: %w", errIgnored), nil, "", true},
+		{"ignored sentinel", http.ErrServerClosed, nil, "", true},
+	}
+	for _, e := range tests {
+		r := a.raise(e.err, e.opts)
+		if ok := a.resolveLevel(r); ok == e.ignore {
+			t.Errorf("%s: expected ignored to be %v", e.name, e.ignore)
+			continue
+		}
+		if !e.ignore && r.level() != e.level {
+			t.Errorf("%s: expected the level %s; got %s", e.name, e.level, r.level())
+		}
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	c := newClock()
+	a, _ := newAlerter(t, Config{
+		Clock:      c.Now,
+		Dedup:      Dedup{Window: time.Minute},
+		Components: map[string]ComponentPolicy{"quiet": {MinLevel: sentry.LevelError}},
+	})
+	outcome := func(err error, h *sentry.Hub, unavailable bool, opts ...Option) Outcome {
+		r := a.raise(err, opts)
+		a.resolveLevel(r)
+		a.suppress(r, h, unavailable)
+		return r.outcome
+	}
+	h := a.hub.Clone()
+
+	if v := outcome(errors.New("A"), nil, false); v != OutcomeLogged {
+		t.Errorf("Expected an alert with no hub to be logged; got %s", v)
+	}
+	if v := outcome(errors.New("B"), nil, true); v != OutcomeDropped {
+		t.Errorf("Expected an alert when Sentry is unavailable to be dropped; got %s", v)
+	}
+	if v := outcome(errors.New("C"), h, false, WithComponent("quiet"), WithLevel(sentry.LevelWarning)); v != OutcomeIgnored {
+		t.Errorf("Expected an alert below the component's minimum level to be ignored; got %s", v)
+	}
+	if v := outcome(errors.New("D"), h, false); v != OutcomeSent {
+		t.Errorf("Expected the first occurrence to be sent; got %s", v)
+	}
+	if v := outcome(errors.New("D"), h, false); v != OutcomeDeduped {
+		t.Errorf("Expected a repeat within the window to be deduped; got %s", v)
+	}
+	c.Advance(2 * time.Minute)
+	if v := outcome(errors.New("D"), h, false); v != OutcomeSent {
+		t.Errorf("Expected a repeat after the window to be sent; got %s", v)
+	}
+}
+
+func TestResolveFields(t *testing.T) {
+	a, _ := newAlerter(t, Config{Tags: Tags{"region": "us"}})
+	r := a.raise(errors.New("Failed"), []Option{WithTags(Tags{"region": "eu", "ref": "spoofed", "password": "hunter2"}), WithRef("abc")})
+	a.resolveLevel(r)
+	a.suppress(r, nil, false)
+	a.resolveFields(r, nil)
+
+	if v := r.tags["region"]; v != "eu" {
+		t.Errorf("Expected the caller's tag to take precedence; got %v", v)
+	}
+	if v := r.tags["ref"]; v != "abc" {
+		t.Errorf("Expected the reserved tag to take precedence; got %v", v)
+	}
+	if v, ok := r.tags["password"]; ok && v == "hunter2" {
+		t.Error("Expected the tags to be scrubbed")
+	}
+	if r.extra != nil {
+		t.Errorf("Expected no extra; got %v", r.extra)
+	}
+}
+
+func TestBuildEvent(t *testing.T) {
+	a, _ := newAlerter(t, Config{Environment: "staging", Release: "v1.2.3"})
+	r := a.raise(errors.New("Failed"), []Option{WithFingerprint("a", "b"), WithSentryLevel(sentry.LevelWarning)})
+	a.resolveLevel(r)
+	a.suppress(r, nil, false)
+	a.resolveFields(r, nil)
+	event := a.buildEvent(r, sentry.NewScope())
+
+	if event.Environment != "staging" || event.Release != "v1.2.3" {
+		t.Errorf("Expected the environment and release; got %q and %q", event.Environment, event.Release)
+	}
+	if event.Level != sentry.LevelWarning || event.Message != "Failed" || len(event.Exception) != 0 {
+		t.Errorf("Expected a warning message; got %s %q with %d exceptions", event.Level, event.Message, len(event.Exception))
+	}
+	if !slices.Equal(event.Fingerprint, []string{"a", "b"}) {
+		t.Errorf("Expected the fingerprint provided; got %v", event.Fingerprint)
+	}
+}
+
+func TestDispatchEventDroppedByBeforeSend(t *testing.T) {
+	a, tr := newAlerter(t, Config{BeforeSend: func(e *Event) *Event { return nil }})
+	r := a.raise(errors.New("Failed"), nil)
+	h := a.hub.Clone()
+	a.resolveLevel(r)
+	a.suppress(r, h, false)
+	a.resolveFields(r, h)
+
+	if id := a.dispatchEvent(r, h, a.buildEvent(r, h.Scope())); id != nil {
+		t.Errorf("Expected no event to be captured; got %s", *id)
+	}
+	if r.outcome != OutcomeIgnored {
+		t.Errorf("Expected the alert to be ignored; got %s", r.outcome)
+	}
+	if n := len(tr.Events()); n != 0 {
+		t.Errorf("Expected no events; got %d", n)
+	}
+}
+
+var errSentinel = errors.New("sentinel")
+
+func TestWarningsAsMessages(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Warning(fmt.Errorf("Degraded: %w", errors.New("slow upstream")))
+	a.Info(errors.New("Recovered"))
+	a.Error(errors.New("Failed"))
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected three events; got %d", len(events))
+	}
+	for i, want := range []string{"Degraded: slow upstream", "Recovered"} {
+		if events[i].Message != want || len(events[i].Exception) != 0 {
+			t.Errorf("Expected event %d to be a message %q with no exception; got %q with %d exceptions", i, want, events[i].Message, len(events[i].Exception))
+		}
+	}
+	if len(events[2].Exception) == 0 {
+		t.Error("Expected an error to be reported with its exceptions")
+	}
+}
+
+func TestWarningExceptions(t *testing.T) {
+	a, tr := newAlerter(t, Config{WarningExceptions: true})
+	a.Warning(errors.New("Degraded"))
+
+	if event := tr.Event(t); len(event.Exception) == 0 {
+		t.Error("Expected the warning to be reported with its exceptions")
+	}
+}
+
+func TestDefaultTags(t *testing.T) {
+	log, recs := newLogger()
+	us, utr := newAlerter(t, Config{Verbose: Bool(true), Logger: log, Tags: Tags{"region": "us", "build": "abc123"}})
+	eu, etr := newAlerter(t, Config{Tags: Tags{"region": "eu"}})
+	plain, ptr := newAlerter(t, Config{})
+
+	us.Error(errors.New("Failed"), WithTags(Tags{"region": "us-east-1", "tenant": "acme"}))
+	eu.Error(errors.New("Failed"))
+	plain.Error(errors.New("Failed"))
+
+	tags := utr.Event(t).Tags
+	if v := tags["region"]; v != "us-east-1" {
+		t.Errorf("Expected the per-call value to override the default; got %q", v)
+	}
+	if tags["build"] != "abc123" || tags["tenant"] != "acme" {
+		t.Errorf("Expected both the defaults and the per-call tags; got %v", tags)
+	}
+	rec := recs.Record(t)
+	if rec["region"] != "us-east-1" || rec["build"] != "abc123" {
+		t.Errorf("Expected the same tags to be logged; got %v", rec)
+	}
+
+	// the defaults of one alerter don't bleed into another
+	if tags := etr.Event(t).Tags; tags["region"] != "eu" || tags["build"] != "" {
+		t.Errorf("Expected only the defaults of its own alerter; got %v", tags)
+	}
+	if tags := ptr.Event(t).Tags; tags["region"] != "" || tags["build"] != "" {
+		t.Errorf("Expected no default tags; got %v", tags)
+	}
+	if e := sentry.CurrentHub().Scope().ApplyToEvent(&sentry.Event{}, nil); e.Tags["region"] != "" {
+		t.Errorf("Expected the defaults not to be applied to the global hub; got %v", e.Tags)
+	}
+}
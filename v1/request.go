@@ -0,0 +1,118 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/bww/go-router/v2"
+)
+
+// RequestCapture describes what is captured of the requests attached to
+// alerts via WithRequest, beyond their method, URL, and origin. The zero
+// value captures the headers Sentry captures by default and no body.
+type RequestCapture struct {
+	// Headers, if set, lists the only headers which are captured, matched
+	// case-insensitively.
+	Headers []string
+	// RedactHeaders lists headers whose values are redacted, in addition to
+	// any redacted by the scrubber when Config.ScrubHeaders is set.
+	RedactHeaders []string
+	// Body is the maximum number of bytes of the body which are captured; by
+	// default, none are. The body is captured only if it can be read again,
+	// via http.Request.GetBody, so capturing it never consumes it; see
+	// BufferBody for requests received by a server. Bodies which are JSON
+	// objects have the scrubber applied to their fields; JSON bodies which
+	// are truncated, and so cannot be scrubbed, are not captured at all.
+	// BodyRedactor is applied to every body.
+	Body int
+	// BodyRedactor is applied to captured bodies to mask any secrets they
+	// contain. When nil, DefaultMessageRedactor is used.
+	BodyRedactor func(string) string
+}
+
+// headers produces a shallow copy of the request with only the selected
+// headers, and with those which are redacted masked. The request itself is
+// returned if neither applies.
+func (c RequestCapture) headers(req *http.Request) *http.Request {
+	if len(c.Headers) == 0 && len(c.RedactHeaders) == 0 {
+		return req
+	}
+	dup := *req
+	dup.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		if len(c.Headers) > 0 && !containsFold(c.Headers, k) {
+			continue
+		}
+		if containsFold(c.RedactHeaders, k) {
+			v = []string{redacted}
+		}
+		dup.Header[k] = v
+	}
+	return &dup
+}
+
+// containsFold determines whether the list contains the string, ignoring
+// case.
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// request produces the request which is attached to an alert, with the
+// selected headers and the scrubber applied to them if so configured.
+func (a *Alerter) request(req *http.Request) *http.Request {
+	req = a.requestCapture.headers(req)
+	if a.scrubHeaders {
+		req = scrubRequest(a.scrubber, req)
+	}
+	return req
+}
+
+// requestBody produces the captured body of the request, if its body is
+// captured and can be read again.
+func (a *Alerter) requestBody(req *router.Request) ([]byte, bool) {
+	c := a.requestCapture
+	if c.Body <= 0 || req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, int64(c.Body)+1))
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	truncated := len(data) > c.Body
+	if truncated && isJSON(req.Header.Get("Content-Type")) {
+		return nil, false // its fields cannot be scrubbed
+	} else if truncated {
+		data = data[:c.Body]
+	} else if isJSON(req.Header.Get("Content-Type")) {
+		var fields map[string]interface{}
+		if json.Unmarshal(data, &fields) == nil {
+			if scrubbed, err := json.Marshal(scrubFields(a.scrubber, fields)); err == nil {
+				data = scrubbed
+			}
+		}
+	}
+	redact := c.BodyRedactor
+	if redact == nil {
+		redact = DefaultMessageRedactor
+	}
+	return []byte(redact(string(data))), true
+}
+
+// isJSON determines whether the content type describes JSON.
+func isJSON(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (t == "application/json" || strings.HasSuffix(t, "+json"))
+}
@@ -0,0 +1,135 @@
+package alert
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bww/go-router/v2"
+)
+
+// bodyRequest produces a request with a body which can be read again.
+func bodyRequest(t *testing.T, contentType, body string) *router.Request {
+	t.Helper()
+	req, err := router.NewRequest("POST", "https://example.com/orders", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req
+}
+
+// captured produces the alert delivered for an error raised with the
+// request, as it is captured under the configuration.
+func captured(t *testing.T, conf Config, req *router.Request) *Event {
+	t.Helper()
+	b := &backend{}
+	conf.Backends = []Backend{b}
+	a, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	a.Error(errors.New("Invalid order"), WithRequest(req))
+	events := b.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected one alert; got %d", len(events))
+	}
+	return events[0]
+}
+
+func TestRequestCaptureHeaders(t *testing.T) {
+	req := bodyRequest(t, "text/plain", "")
+	req.Header.Set("X-Request-Id", "abc")
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("User-Agent", "test")
+
+	e := captured(t, Config{}, req)
+	if len(e.Request.Header) != 4 || e.RequestBody != nil {
+		t.Errorf("Expected every header and no body to be captured by default; got %v, %q", e.Request.Header, e.RequestBody)
+	}
+
+	e = captured(t, Config{RequestCapture: RequestCapture{
+		Headers:       []string{"x-request-id", "X-TENANT"},
+		RedactHeaders: []string{"x-tenant"},
+	}}, req)
+	if v := e.Request.Header; len(v) != 2 || v.Get("X-Request-Id") != "abc" || v.Get("X-Tenant") != redacted {
+		t.Errorf("Expected only the selected headers, with those redacted masked; got %v", v)
+	}
+	if v := req.Header.Get("X-Tenant"); v != "acme" {
+		t.Errorf("Expected the request itself not to be modified; got %q", v)
+	}
+}
+
+func TestRequestCaptureScrubHeaders(t *testing.T) {
+	req := bodyRequest(t, "text/plain", "")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Request-Id", "abc")
+
+	e := captured(t, Config{ScrubHeaders: true}, req)
+	if v := e.Request.Header; v.Get("Authorization") != redacted || v.Get("X-Request-Id") != "abc" {
+		t.Errorf("Expected the scrubber to be applied to headers; got %v", v)
+	}
+}
+
+func TestRequestCaptureBody(t *testing.T) {
+	conf := Config{RequestCapture: RequestCapture{Body: 64}}
+	for _, e := range []struct {
+		name        string
+		contentType string
+		body        string
+		expect      string
+	}{
+		{"text", "text/plain", "Order 1 of 2", "Order 1 of 2"},
+		{"truncated", "text/plain", strings.Repeat("x", 100), strings.Repeat("x", 64)},
+		{"redacted", "text/plain", "password=hunter2", "password=" + redacted},
+		{"json", "application/json", `{"id":1,"password":"hunter2"}`, `{"id":1,"password":"[redacted]"}`},
+		{"nested", "application/vnd.order+json", `{"card":{"token":"tok_123"}}`, `{"card":{"token":"[redacted]"}}`},
+		{"array", "application/json", `[1,2,3]`, `[1,2,3]`},
+		{"truncated json", "application/json", `{"id":1,"password":"` + strings.Repeat("x", 64) + `"}`, ""},
+	} {
+		got := captured(t, conf, bodyRequest(t, e.contentType, e.body)).RequestBody
+		if string(got) != e.expect {
+			t.Errorf("%s: Expected the body %q; got %q", e.name, e.expect, got)
+		}
+	}
+}
+
+func TestRequestCaptureUnbuffered(t *testing.T) {
+	req, err := router.NewRequest("POST", "https://example.com/orders", io.NopCloser(strings.NewReader("body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := captured(t, Config{RequestCapture: RequestCapture{Body: 64}}, req).RequestBody; v != nil {
+		t.Errorf("Expected no body to be captured which cannot be read again; got %q", v)
+	}
+}
+
+func TestRequestCaptureBufferBody(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}, RequestCapture: RequestCapture{Body: 64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	h := BufferBody(1024).Wrap(func(req *router.Request, cxt router.Context) (*router.Response, error) {
+		io.ReadAll(req.Body) // the handler consumes the body
+		a.Error(errors.New("Invalid order"), WithRequest(req))
+		return nil, nil
+	})
+	hreq, err := http.NewRequest("POST", "https://example.com/orders", io.NopCloser(strings.NewReader("Order 1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h((*router.Request)(hreq), router.Context{}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := b.Events()
+	if len(events) != 1 || string(events[0].RequestBody) != "Order 1" {
+		t.Errorf("Expected a buffered body to be captured after it is consumed; got %v", events)
+	}
+}
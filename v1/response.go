@@ -0,0 +1,78 @@
+package alert
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Headers of outbound responses are tagged with this prefix, e.g., the
+// header Retry-After becomes the tag "header.retry-after".
+const headerTagPrefix = "header."
+
+const redacted = "[redacted]"
+
+// DefaultResponseHeaders lists the response headers that are tagged by
+// default, which are helpful when diagnosing throttling by a third party.
+// A trailing '*' matches any header with the preceding prefix.
+var DefaultResponseHeaders = []string{
+	"Retry-After",
+	"X-RateLimit-*",
+	"RateLimit-*",
+}
+
+// sensitiveHeaders are never reported verbatim, even if allowed.
+var sensitiveHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"cookie":              {},
+	"set-cookie":          {},
+	"x-api-key":           {},
+}
+
+// ResponseError is implemented by errors which arise from an outbound HTTP
+// request and carry the response that was received.
+type ResponseError interface {
+	error
+	Response() *http.Response
+}
+
+// responseFromError searches the error chain for a ResponseError and
+// produces its response, if any.
+func responseFromError(err error) *http.Response {
+	var rerr ResponseError
+	if errors.As(err, &rerr) {
+		return rerr.Response()
+	}
+	return nil
+}
+
+// responseTags tags the status of the response and those of its headers
+// which match the allow-list. Values of sensitive headers are redacted.
+func responseTags(rsp *http.Response, allow []string, tags Tags) {
+	tags["http_status"] = rsp.StatusCode
+	for k, v := range rsp.Header {
+		if len(v) < 1 || !matchHeader(allow, k) {
+			continue
+		}
+		name := strings.ToLower(k)
+		if _, ok := sensitiveHeaders[name]; ok {
+			tags[headerTagPrefix+name] = redacted
+		} else {
+			tags[headerTagPrefix+name] = strings.Join(v, ", ")
+		}
+	}
+}
+
+func matchHeader(allow []string, name string) bool {
+	for _, e := range allow {
+		if p, ok := strings.CutSuffix(e, "*"); ok {
+			if len(name) >= len(p) && strings.EqualFold(name[:len(p)], p) {
+				return true
+			}
+		} else if strings.EqualFold(name, e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package alert
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// responseError is an error arising from an outbound request.
+type responseError struct {
+	rsp *http.Response
+}
+
+func (e responseError) Error() string            { return fmt.Sprintf("Request failed: %d", e.rsp.StatusCode) }
+func (e responseError) Response() *http.Response { return e.rsp }
+
+func TestResponseHeaders(t *testing.T) {
+	rsp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{
+		"Retry-After":           {"30"},
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Request-Id":          {"abc"},
+		"Set-Cookie":            {"session=secret"},
+	}}
+	err := fmt.Errorf("Could not sync: %w", responseError{rsp})
+
+	t.Run("default", func(t *testing.T) {
+		a, tr := newAlerter(t, Config{})
+		a.Error(err)
+
+		tags := tr.Event(t).Tags
+		for k, want := range map[string]string{"http_status": "429", "header.retry-after": "30", "header.x-ratelimit-remaining": "0"} {
+			if v := tags[k]; v != want {
+				t.Errorf("Expected %s to be tagged %q; got %q", k, want, v)
+			}
+		}
+		for _, k := range []string{"header.x-request-id", "header.set-cookie"} {
+			if v, ok := tags[k]; ok {
+				t.Errorf("Expected %s not to be tagged; got %q", k, v)
+			}
+		}
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		a, tr := newAlerter(t, Config{ResponseHeaders: []string{"X-Request-Id", "Set-Cookie"}})
+		a.Error(err)
+
+		tags := tr.Event(t).Tags
+		if v := tags["header.x-request-id"]; v != "abc" {
+			t.Errorf("Expected the allowed header to be tagged; got %q", v)
+		}
+		if v := tags["header.set-cookie"]; v != redacted {
+			t.Errorf("Expected the sensitive header to be redacted; got %q", v)
+		}
+		if _, ok := tags["header.retry-after"]; ok {
+			t.Error("Expected only the allowed headers to be tagged")
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"sort"
+	"time"
+)
+
+// The default interval at which the occurrences suppressed by FirstOnly are
+// summarized.
+const defaultRollupInterval = time.Hour
+
+// Rollup summarizes the occurrences of an error which were not reported
+// individually, e.g., because only the first occurrence of each error is
+// reported (see Config.FirstOnly).
+type Rollup struct {
+	Ref     string
+	Message string
+	First   time.Time // when the error was first seen
+	Last    time.Time // when the error was last seen
+	Count   int       // the number of occurrences since the error was first seen
+	Since   int       // the number of occurrences since the previous rollup
+}
+
+// Rollup produces a summary of every tracked error that has occurred without
+// being reported since the previous rollup, ordered by when each was first
+// seen. Producing a rollup resets the count of unreported occurrences.
+//
+// This is intended to be called periodically and its result logged or
+// reported as appropriate.
+func (a *Alerter) Rollup() []Rollup {
+	return a.recent.Rollup()
+}
+
+// Rollup produces a summary of the entries with suppressed occurrences and
+// resets them.
+func (r *recent) Rollup() []Rollup {
+	var res []Rollup
+	for i := range r.shards {
+		res = r.shards[i].rollup(res)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].First.Before(res[j].First)
+	})
+	return res
+}
+
+// rollup appends a summary of the entries in the shard with suppressed
+// occurrences, including those which have been evicted, to res and resets
+// them.
+func (s *recentShard) rollup(res []Rollup) []Rollup {
+	s.Lock()
+	defer s.Unlock()
+	res = append(res, s.evicted...)
+	s.evicted = nil
+	for k, e := range s.entries {
+		if e.Suppressed == 0 {
+			continue
+		}
+		res = append(res, e.rollup(k))
+		e.Suppressed = 0
+	}
+	return res
+}
+
+// rollup summarizes the entry identified by key.
+func (e *occurrence) rollup(key string) Rollup {
+	ref, msg := splitFingerprint(key)
+	return Rollup{
+		Ref:     ref,
+		Message: msg,
+		First:   e.First,
+		Last:    e.Last,
+		Count:   e.Count,
+		Since:   e.Suppressed,
+	}
+}
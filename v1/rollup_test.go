@@ -0,0 +1,82 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"maps"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFirstOnly(t *testing.T) {
+	clock := newClock()
+	start := clock.Now()
+	a, tr := newAlerter(t, Config{FirstOnly: true, RollupInterval: -1, Clock: clock.Now})
+
+	for i := 0; i < 5; i++ {
+		a.Error(errors.New("Noisy"))
+		clock.Advance(time.Minute)
+	}
+	event := tr.Event(t)
+	if v := event.Tags["occurrences"]; v != "1" {
+		t.Errorf("Expected the first occurrence to be tagged as such; got %q", v)
+	}
+
+	expect := []Rollup{{Message: "Noisy", First: start, Last: start.Add(4 * time.Minute), Count: 5, Since: 4}}
+	if v := a.Rollup(); !reflect.DeepEqual(v, expect) {
+		t.Fatalf("Expected the rollup %+v; got %+v", expect, v)
+	}
+	if v := a.Rollup(); len(v) != 0 {
+		t.Errorf("Expected the rollup to reset the unreported occurrences; got %+v", v)
+	}
+
+	a.Error(errors.New("Noisy"))
+	a.reportSuppressed()
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected only the first occurrence and the rollup to be sent; got %d events", len(events))
+	}
+	summary, ok := events[1].Extra["suppressed"].([]map[string]interface{})
+	if !ok || len(summary) != 1 {
+		t.Fatalf("Expected the rollup to summarize one error; got %v", events[1].Extra["suppressed"])
+	}
+	if v := summary[0]; v["count"] != 6 || v["suppressed"] != 1 {
+		t.Errorf("Expected the rollup to report 6 occurrences, 1 since the last rollup; got %v", v)
+	}
+}
+
+func TestFirstOnlyEviction(t *testing.T) {
+	a, tr := newAlerter(t, Config{FirstOnly: true, RollupInterval: -1})
+	a.recent = newRecent(recentShards) // one error per shard
+
+	// find an error that shares a shard with the noisy one, so it evicts it
+	shard := func(msg string) uint64 {
+		return maphash.String(a.recent.seed, fingerprint("", errors.New(msg))) % uint64(len(a.recent.shards))
+	}
+	var other string
+	for i := 0; other == ""; i++ {
+		if m := fmt.Sprintf("Other %d", i); shard(m) == shard("Noisy") {
+			other = m
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		a.Error(errors.New("Noisy"))
+	}
+	a.Error(errors.New(other))
+	if v := a.Rollup(); len(v) != 1 || v[0].Message != "Noisy" || v[0].Count != 3 || v[0].Since != 2 {
+		t.Errorf("Expected the unreported occurrences of the evicted error to be retained; got %+v", v)
+	}
+
+	// having been forgotten, it is reported again
+	a.Error(errors.New("Noisy"))
+	counts := make(map[string]int)
+	for _, e := range tr.Events() {
+		counts[e.Exception[len(e.Exception)-1].Value]++
+	}
+	if want := map[string]int{"Noisy": 2, other: 1}; !maps.Equal(counts, want) {
+		t.Errorf("Expected the events %v; got %v", want, counts)
+	}
+}
@@ -0,0 +1,57 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bww/go-ident/v1"
+)
+
+func TestRoutes(t *testing.T) {
+	all, dbPager, paymentsPager, paymentsSlack := &backend{}, &backend{}, &backend{}, &backend{}
+	db, payments := ident.New(), ident.New()
+	a, err := New(Config{
+		Channel:  db,
+		Backends: []Backend{all},
+		Routes: []Route{
+			{Channel: db, Backends: []Backend{dbPager}},
+			{Channel: payments, Backends: []Backend{paymentsPager, paymentsSlack}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	a.Error(errors.New("Replica lag"))
+	a.Error(errors.New("Card declined"), WithChannel(payments))
+	a.Error(errors.New("Unrouted"), WithChannel(ident.New()))
+
+	if n := len(all.Events()); n != 3 {
+		t.Errorf("Expected every alert to be delivered to the backends regardless of channel; got %d", n)
+	}
+	if events := dbPager.Events(); len(events) != 1 || events[0].Message != "Replica lag" || events[0].Channel != db {
+		t.Errorf("Expected alerts for the channel of the alerter to be routed to it; got %v", events)
+	}
+	for _, b := range []*backend{paymentsPager, paymentsSlack} {
+		if events := b.Events(); len(events) != 1 || events[0].Message != "Card declined" || events[0].Channel != payments {
+			t.Errorf("Expected alerts for a channel to be routed to every backend of its route; got %v", events)
+		}
+	}
+}
+
+func TestRoutesResolve(t *testing.T) {
+	r := &resolver{}
+	a, err := New(Config{Routes: []Route{{Channel: ident.New(), Backends: []Backend{r}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if err := a.Resolve("job-1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.resolved) != 1 {
+		t.Errorf("Expected alerts to be resolved in the backends of every route; got %v", r.resolved)
+	}
+}
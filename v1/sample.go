@@ -0,0 +1,43 @@
+package alert
+
+// Sampler produces the proportion of alerts like the one described, between
+// 0 and 1, which are reported, so that errors which are frequent but of
+// little individual interest are reported as a representative stream rather
+// than being throttled. For example, to report 1% of timeouts:
+//
+//	Sampler: func(e *alert.Event) float64 {
+//		if errors.Is(e.Err, context.DeadlineExceeded) {
+//			return 0.01
+//		}
+//		return 1
+//	}
+//
+// The event describes the alert as it is known when it is sampled, which is
+// before its tags, extra, and exception are resolved. As with every other
+// sample rate, a rate of 0 or of 1 or more reports every alert; use
+// Config.Ignore to discard alerts entirely.
+type Sampler func(e *Event) float64
+
+// normalRate produces the proportion of alerts reported under a sample rate,
+// where a rate of 0 or of 1 or more reports every alert.
+func normalRate(rate float64) float64 {
+	if rate <= 0 || rate >= 1 {
+		return 1
+	}
+	return rate
+}
+
+// rateFor produces the effective sample rate of an alert, which is the
+// product of the alerter's rate, the rate for its level, that of its
+// component's policy, and that produced by the sampler, since each samples
+// independently. Fatal alerts are always reported.
+func (a *Alerter) rateFor(e *Event, policy ComponentPolicy) float64 {
+	if e.Level == LevelFatal {
+		return 1
+	}
+	rate := normalRate(a.sampleRate) * normalRate(a.sampleRates[e.Level]) * normalRate(policy.SampleRate)
+	if a.sampler != nil {
+		rate *= normalRate(a.sampler(e))
+	}
+	return rate
+}
@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestSampleRate(t *testing.T) {
+	log, recs := newLogger()
+	m := &metrics{}
+	a, tr := newAlerter(t, Config{SampleRate: 0.1, Verbose: Bool(true), Logger: log, Metrics: m})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				a.Error(errors.New("Failed"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(tr.Events()); n == 0 || n > 300 {
+		t.Errorf("Expected about a tenth of alerts to be reported at the rate 0.1; got %d in 1000", n)
+	}
+	if n := len(recs.Records(t)); n != 1000 {
+		t.Errorf("Expected every alert to be logged regardless; got %d records", n)
+	}
+	var sampled int
+	for _, o := range m.Outcomes() {
+		if o == OutcomeSampled {
+			sampled++
+		}
+	}
+	if n := len(tr.Events()); sampled+n != 1000 {
+		t.Errorf("Expected alerts which are not reported to be counted as sampled; got %d sampled and %d sent", sampled, n)
+	}
+}
+
+func TestSampleRateFatal(t *testing.T) {
+	a, tr := newAlerter(t, Config{SampleRate: 0.001})
+	for i := 0; i < 20; i++ {
+		a.Fatal(errors.New("Out of memory"))
+	}
+	if n := len(tr.Events()); n != 20 {
+		t.Errorf("Expected fatal alerts never to be sampled; got %d of 20", n)
+	}
+}
+
+func TestSampleRateDisabled(t *testing.T) {
+	for _, rate := range []float64{0, 1, 1.5, -1} {
+		a, tr := newAlerter(t, Config{SampleRate: rate})
+		for i := 0; i < 20; i++ {
+			a.Error(errors.New("Failed"))
+		}
+		if n := len(tr.Events()); n != 20 {
+			t.Errorf("Expected every alert to be reported at the rate %v; got %d of 20", rate, n)
+		}
+		if _, ok := tr.Events()[0].Tags["sample_rate"]; ok {
+			t.Errorf("Expected alerts not to be tagged with the rate %v", rate)
+		}
+	}
+}
+
+func TestSampleRates(t *testing.T) {
+	a, tr := newAlerter(t, Config{SampleRates: map[Level]float64{LevelWarning: 0.01, LevelFatal: 0.01}})
+	for i := 0; i < 100; i++ {
+		a.Warning(errors.New("Slow"))
+		a.Error(errors.New("Failed"))
+		a.Fatal(errors.New("Out of memory"))
+	}
+
+	counts := make(map[Level]int)
+	for _, e := range tr.Events() {
+		counts[e.Level]++
+		if e.Level == LevelWarning && e.Tags["sample_rate"] != "0.01" {
+			t.Errorf("Expected sampled warnings to be tagged with their rate; got %v", e.Tags)
+		}
+		if e.Level != LevelWarning {
+			if v, ok := e.Tags["sample_rate"]; ok {
+				t.Errorf("Expected alerts reported in full not to be tagged with a rate; got %q", v)
+			}
+		}
+	}
+	if counts[LevelWarning] > 10 {
+		t.Errorf("Expected about 1%% of warnings to be reported; got %d of 100", counts[LevelWarning])
+	}
+	if counts[LevelError] != 100 || counts[LevelFatal] != 100 {
+		t.Errorf("Expected every error and fatal alert to be reported; got %d and %d", counts[LevelError], counts[LevelFatal])
+	}
+}
+
+var errTimeout = errors.New("timeout")
+
+func TestSampler(t *testing.T) {
+	var seen []*Event
+	a, tr := newAlerter(t, Config{
+		SampleRate: 0.5,
+		Sampler: func(e *Event) float64 {
+			seen = append(seen, e)
+			if errors.Is(e.Err, errTimeout) {
+				return 0.5
+			}
+			return 1
+		},
+	})
+	for i := 0; i < 200; i++ {
+		a.Error(errTimeout, WithComponent("db"))
+	}
+	if len(seen) != 200 || seen[0].Component != "db" || seen[0].Level != LevelError {
+		t.Fatalf("Expected the sampler to be consulted with the alert; got %d, %+v", len(seen), seen[0])
+	}
+
+	events := tr.Events()
+	// rates multiply: half of half are reported
+	if n := len(events); n == 0 || n > 100 {
+		t.Errorf("Expected about a quarter of alerts to be reported; got %d of 200", n)
+	}
+	for _, e := range events {
+		if v := e.Tags["sample_rate"]; v != "0.25" {
+			t.Errorf("Expected alerts to be tagged with the effective rate; got %q", v)
+			break
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package alert
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// A Scrubber is applied to the tags and extra of an alert, and optionally to
+// the headers of its request, before the alert leaves the process. It
+// produces the value to report in place of the value provided, e.g., a
+// redacted form of it, or false to omit the field entirely.
+//
+// Scrubbers are applied to the entries of nested maps as well, with the
+// keys of those entries.
+type Scrubber func(key string, value interface{}) (interface{}, bool)
+
+// DefaultScrubber redacts the values of fields commonly used for secrets.
+// Session identifiers are matched by the keys they are usually carried as,
+// so that fields which merely describe a session, such as those attached by
+// Session, are reported.
+var DefaultScrubber = RedactKeys(
+	"authorization",
+	"proxy-authorization",
+	"cookie",
+	"set-cookie",
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"access_token",
+	"refresh_token",
+	"api_key",
+	"apikey",
+	"x-api-key",
+	"session_id",
+	"sessionid",
+	"session_token",
+	"session_key",
+)
+
+// RedactKeys produces a Scrubber which redacts the values of fields with any
+// of the specified keys, which are matched case-insensitively.
+func RedactKeys(keys ...string) Scrubber {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+	return func(key string, value interface{}) (interface{}, bool) {
+		if _, ok := set[strings.ToLower(key)]; ok {
+			return redacted, true
+		}
+		return value, true
+	}
+}
+
+// scrubFields produces a copy of the fields with the scrubber applied. The
+// fields provided, and any maps nested within them, are not modified.
+func scrubFields[M ~map[string]interface{}](s Scrubber, m M) M {
+	if m == nil {
+		return nil
+	}
+	res := make(M, len(m))
+	for k, v := range m {
+		v, ok := s(k, v)
+		if !ok {
+			continue
+		}
+		switch c := v.(type) {
+		case map[string]interface{}:
+			v = scrubFields(s, c)
+		case Tags:
+			v = scrubFields(s, c)
+		case []map[string]interface{}:
+			n := make([]map[string]interface{}, len(c))
+			for i, e := range c {
+				n[i] = scrubFields(s, e)
+			}
+			v = n
+		case map[string]string:
+			n := make(map[string]string, len(c))
+			for k, v := range c {
+				if v, ok := s(k, v); ok {
+					n[k] = fmt.Sprint(v)
+				}
+			}
+			v = n
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// scrubRequest produces a shallow copy of the request with the scrubber
+// applied to its headers.
+func scrubRequest(s Scrubber, req *http.Request) *http.Request {
+	dup := *req
+	dup.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		if c, ok := s(k, strings.Join(v, ", ")); ok {
+			dup.Header.Set(k, fmt.Sprint(c))
+		}
+	}
+	return &dup
+}
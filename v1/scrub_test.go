@@ -0,0 +1,86 @@
+package alert
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRedactKeys(t *testing.T) {
+	s := RedactKeys("Password", "token")
+	for _, e := range []struct {
+		key    string
+		expect interface{}
+	}{
+		{"password", redacted},
+		{"PASSWORD", redacted},
+		{"Token", redacted},
+		{"user", "u1"},
+		{"password_hint", "u1"},
+	} {
+		v, ok := s(e.key, "u1")
+		if !ok || v != e.expect {
+			t.Errorf("%s: Expected %v; got %v (%v)", e.key, e.expect, v, ok)
+		}
+	}
+}
+
+func TestScrubFields(t *testing.T) {
+	s := func(key string, value interface{}) (interface{}, bool) {
+		if key == "email" {
+			return nil, false
+		}
+		return DefaultScrubber(key, value)
+	}
+	fields := map[string]interface{}{
+		"user":  "u1",
+		"email": "u1@example.com",
+		"auth":  map[string]interface{}{"token": "xyz", "scheme": "bearer"},
+		"calls": []map[string]interface{}{{"api_key": "abc"}},
+		"hdrs":  map[string]string{"Authorization": "Bearer xyz"},
+	}
+	expect := map[string]interface{}{
+		"user":  "u1",
+		"auth":  map[string]interface{}{"token": redacted, "scheme": "bearer"},
+		"calls": []map[string]interface{}{{"api_key": redacted}},
+		"hdrs":  map[string]string{"Authorization": redacted},
+	}
+	if v := scrubFields(s, fields); !reflect.DeepEqual(v, expect) {
+		t.Errorf("Expected %v; got %v", expect, v)
+	}
+	if v := fields["auth"].(map[string]interface{})["token"]; v != "xyz" || fields["email"] == nil {
+		t.Error("Expected the fields provided not to be modified")
+	}
+}
+
+func TestScrubber(t *testing.T) {
+	log, recs := newLogger()
+	a, tr := newAlerter(t, Config{
+		Verbose: Bool(true),
+		Logger:  log,
+		Scrubber: func(key string, value interface{}) (interface{}, bool) {
+			if key == "email" {
+				return nil, false
+			}
+			return DefaultScrubber(key, value)
+		},
+	})
+	tags := Tags{"tenant": "acme", "email": "u1@example.com"}
+	extra := map[string]interface{}{"password": "hunter2", "order": 1}
+	a.Error(errors.New("Failed"), WithTags(tags), WithExtra(extra))
+
+	event := tr.Event(t)
+	if _, ok := event.Tags["email"]; ok || event.Tags["tenant"] != "acme" {
+		t.Errorf("Expected fields the scrubber drops to be omitted; got %v", event.Tags)
+	}
+	if v := event.Extra["password"]; v != redacted || event.Extra["order"] != 1 {
+		t.Errorf("Expected fields the scrubber replaces to be redacted; got %v", event.Extra)
+	}
+	if tags["email"] != "u1@example.com" || extra["password"] != "hunter2" {
+		t.Error("Expected the caller's fields not to be modified")
+	}
+	rec := recs.Record(t)
+	if _, ok := rec["email"]; ok || rec["password"] != redacted {
+		t.Errorf("Expected the logged alert to be scrubbed as well; got %v", rec)
+	}
+}
@@ -0,0 +1,43 @@
+package alert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Sentinel describes how errors matching a sentinel error value, as
+// determined by errors.Is, are reported. Many sentinels are signals used for
+// control flow rather than failures, and should not be reported as errors.
+type Sentinel struct {
+	Err   error
+	Level sentry.Level // the level matching errors are reported at
+	// Ignore discards matching errors entirely; they are neither reported to
+	// Sentry nor logged.
+	Ignore bool
+}
+
+// DefaultSentinels describes how common error values from the standard
+// library are reported. It is used when Config.Sentinels is nil.
+var DefaultSentinels = []Sentinel{
+	{Err: http.ErrServerClosed, Ignore: true},
+	{Err: http.ErrAbortHandler, Ignore: true},
+	{Err: context.Canceled, Level: sentry.LevelInfo},
+	{Err: context.DeadlineExceeded, Level: sentry.LevelWarning},
+	{Err: sql.ErrNoRows, Level: sentry.LevelWarning},
+	{Err: net.ErrClosed, Level: sentry.LevelWarning},
+}
+
+// matchSentinel produces the first sentinel the error matches, if any.
+func matchSentinel(sentinels []Sentinel, err error) (Sentinel, bool) {
+	for _, e := range sentinels {
+		if errors.Is(err, e.Err) {
+			return e, true
+		}
+	}
+	return Sentinel{}, false
+}
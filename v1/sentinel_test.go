@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestDefaultSentinels(t *testing.T) {
+	tests := []struct {
+		err   error
+		level sentry.Level
+	}{
+		{fmt.Errorf("Could not load: %w", sql.ErrNoRows), sentry.LevelWarning},
+		{fmt.Errorf("Request abandoned: %w", context.Canceled), sentry.LevelInfo},
+		{context.DeadlineExceeded, sentry.LevelWarning},
+	}
+	for _, e := range tests {
+		a, tr := newAlerter(t, Config{})
+		a.Error(e.err)
+		if v := tr.Event(t).Level; v != e.level {
+			t.Errorf("Expected %v to be reported at %s; got %s", e.err, e.level, v)
+		}
+	}
+}
+
+func TestIgnoredSentinels(t *testing.T) {
+	log, recs := newLogger()
+	a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log})
+	a.Error(fmt.Errorf("Shutting down: %w", http.ErrServerClosed))
+
+	if n := len(tr.Events()); n != 0 {
+		t.Errorf("Expected the error to be ignored; got %d events", n)
+	}
+	if n := len(recs.Records(t)); n != 0 {
+		t.Errorf("Expected the error not to be logged; got %d records", n)
+	}
+}
+
+func TestSentinelOverrides(t *testing.T) {
+	errNotFound := errors.New("not found")
+	a, tr := newAlerter(t, Config{Sentinels: append([]Sentinel{
+		{Err: sql.ErrNoRows, Level: sentry.LevelError},
+		{Err: errNotFound, Level: sentry.LevelInfo},
+	}, DefaultSentinels...)})
+	a.Error(sql.ErrNoRows)
+	a.Error(fmt.Errorf("User: %w", errNotFound))
+	a.Error(errors.New("Query failed"), WithLevel(sentry.LevelFatal))
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected three events; got %d", len(events))
+	}
+	for i, want := range []sentry.Level{sentry.LevelError, sentry.LevelInfo, sentry.LevelFatal} {
+		if events[i].Level != want {
+			t.Errorf("Expected event %d at %s; got %s", i, want, events[i].Level)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package alert
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Session accumulates context over the life of a unit of work, such as a
+// batch job or a worker task, which is attached to every alert reported via
+// the session: its tags, breadcrumbs, and counters. When the session ends it
+// reports a single message which summarizes it. Alerts reported via a
+// session are tagged with its name as "session", and its state is attached
+// as the extra "session".
+//
+//	s := a.Begin("reindex", alert.WithTags(alert.Tags{"index": name}))
+//	defer s.End()
+//	for _, e := range docs {
+//		if err := index(e); err != nil {
+//			s.Error(err)
+//			continue
+//		}
+//		s.Count("indexed", 1)
+//	}
+//
+// A session may be used from multiple goroutines.
+type Session struct {
+	alerter *Alerter
+	name    string
+	opts    []Option
+	started time.Time
+
+	mu       sync.Mutex
+	tags     Tags
+	crumbs   breadcrumbs
+	counters map[string]int64
+	errors   int
+	ended    bool
+}
+
+// Begin begins a session with the specified name. The options apply to every
+// alert reported via the session, and to its summary, before those provided
+// by the caller.
+func (a *Alerter) Begin(name string, opts ...Option) *Session {
+	tags := make(Tags)
+	merge(tags, newContext(opts).Tags)
+	return &Session{
+		alerter:  a,
+		name:     name,
+		opts:     opts,
+		started:  a.now(),
+		tags:     tags,
+		counters: make(map[string]int64),
+	}
+}
+
+// Tag adds a tag to the alerts reported via the session from now on.
+func (s *Session) Tag(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags[key] = value
+}
+
+// Breadcrumb records a breadcrumb which is attached to every alert reported
+// via the session from now on.
+func (s *Session) Breadcrumb(category, message string, data map[string]interface{}) {
+	s.crumbs.Add(sentry.Breadcrumb{Category: category, Message: message, Data: data, Timestamp: s.alerter.now()})
+}
+
+// Count adds delta to the named counter, which is reported with alerts as
+// part of the "session" extra.
+func (s *Session) Count(name string, delta int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[name] += delta
+}
+
+// Error reports an error with the context accumulated by the session.
+func (s *Session) Error(err error, opts ...Option) *sentry.EventID {
+	s.mu.Lock()
+	s.errors++
+	s.mu.Unlock()
+	return s.alerter.report(err, s.options(opts)...)
+}
+
+// Report reports an error at the specified level with the context
+// accumulated by the session.
+func (s *Session) Report(lvl Level, err error, opts ...Option) *sentry.EventID {
+	return s.Error(err, append([]Option{WithLevel(lvl)}, opts...)...)
+}
+
+// End ends the session and reports a message which summarizes it: its
+// duration, counters, and the number of errors reported via it. The summary
+// is reported at the info level, or the warning level if any errors were
+// reported. Ending a session more than once has no effect.
+func (s *Session) End(opts ...Option) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	n := s.errors
+	s.mu.Unlock()
+
+	lvl := LevelInfo
+	if n > 0 {
+		lvl = LevelWarning
+	}
+	msg := fmt.Sprintf("Task %s ended with %d errors", s.name, n)
+	opts = append([]Option{WithLevel(lvl), WithDuration(s.alerter.now().Sub(s.started))}, opts...)
+	s.alerter.report(message(msg), s.options(opts)...)
+}
+
+// options produces the options an alert is reported via the session with:
+// those of the session, then the caller's, beneath which the accumulated
+// context is merged.
+func (s *Session) options(opts []Option) []Option {
+	s.mu.Lock()
+	tags := maps.Clone(s.tags)
+	tags["session"] = s.name
+	extra := map[string]interface{}{
+		"session": map[string]interface{}{
+			"name":     s.name,
+			"started":  s.started.Format(time.RFC3339),
+			"errors":   s.errors,
+			"counters": maps.Clone(s.counters),
+		},
+	}
+	s.mu.Unlock()
+
+	s.crumbs.Lock()
+	crumbs := append([]sentry.Breadcrumb(nil), s.crumbs.crumbs...)
+	s.crumbs.Unlock()
+
+	res := make([]Option, 0, len(s.opts)+len(opts)+1)
+	res = append(res, s.opts...)
+	res = append(res, opts...)
+	return append(res, func(c Context) Context {
+		maps.Copy(tags, c.Tags)
+		maps.Copy(extra, c.Extra)
+		c.Tags, c.Extra = tags, extra
+		c.Breadcrumbs = append(crumbs, c.Breadcrumbs...)
+		return c
+	})
+}
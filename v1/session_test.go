@@ -0,0 +1,96 @@
+package alert
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	c, b := newClock(), &backend{}
+	a, tr := newAlerter(t, Config{Clock: c.Now, Backends: []Backend{b}})
+
+	s := a.Begin("reindex", WithTags(Tags{"index": "users"}))
+	s.Count("indexed", 2)
+	s.Breadcrumb("index", "Indexed batch", map[string]interface{}{"batch": 1})
+	s.Tag("shard", 3)
+	s.Error(errors.New("Could not index document"), WithTags(Tags{"doc": "d1"}))
+	s.Count("indexed", 5)
+	c.Advance(time.Minute)
+	s.End()
+	s.End()
+
+	events := b.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected the error and the summary; got %d alerts", len(events))
+	}
+	e := events[0]
+	for k, want := range map[string]string{"session": "reindex", "index": "users", "shard": "3", "doc": "d1"} {
+		if v := e.Tags[k]; v != want {
+			t.Errorf("Expected tag %s=%s; got %q", k, want, v)
+		}
+	}
+	state, _ := e.Extra["session"].(map[string]interface{})
+	if state["name"] != "reindex" || state["errors"] != 1 {
+		t.Errorf("Expected the state of the session to be attached, unscrubbed; got %v", e.Extra["session"])
+	}
+	if v, _ := state["counters"].(map[string]int64); v["indexed"] != 2 {
+		t.Errorf("Expected the counters as they were when the alert was raised; got %v", state["counters"])
+	}
+	crumbs := tr.Events()[0].Breadcrumbs
+	if len(crumbs) != 1 || crumbs[0].Message != "Indexed batch" {
+		t.Errorf("Expected the breadcrumbs of the session to be attached; got %v", crumbs)
+	}
+
+	summary := events[1]
+	if summary.Level != LevelWarning || summary.Message != "Task reindex ended with 1 errors" {
+		t.Errorf("Expected a warning summarizing the session; got %s %q", summary.Level, summary.Message)
+	}
+	state, _ = summary.Extra["session"].(map[string]interface{})
+	if v, _ := state["counters"].(map[string]int64); v["indexed"] != 7 {
+		t.Errorf("Expected the summary to report the final counters; got %v", state["counters"])
+	}
+	if v := summary.Tags["duration_ms"]; v != "60000" {
+		t.Errorf("Expected the summary to report the duration; got %v", summary.Tags)
+	}
+}
+
+func TestSessionClean(t *testing.T) {
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	s := a.Begin("export")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Count("rows", 10)
+		}()
+	}
+	wg.Wait()
+	s.End()
+
+	events := b.Events()
+	if len(events) != 1 || events[0].Level != LevelInfo {
+		t.Fatalf("Expected an informational summary of a session without errors; got %v", events)
+	}
+	state, _ := events[0].Extra["session"].(map[string]interface{})
+	if v, _ := state["counters"].(map[string]int64); v["rows"] != 100 {
+		t.Errorf("Expected the counters of every goroutine; got %v", state["counters"])
+	}
+}
+
+func TestDefaultScrubberSession(t *testing.T) {
+	for key, want := range map[string]bool{"session": false, "session_id": true, "SessionID": true, "session_token": true} {
+		v, _ := DefaultScrubber(key, "abc")
+		if (v == redacted) != want {
+			t.Errorf("%s: Expected redacted %v; got %v", key, want, v)
+		}
+	}
+}
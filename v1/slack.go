@@ -0,0 +1,130 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bww/go-ident/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+// The maximum time spent posting an alert to Slack.
+const slackTimeout = 10 * time.Second
+
+// The maximum number of alerts posted to Slack concurrently. Alerts raised
+// while this many are outstanding are not posted.
+const maxSlackPosts = 8
+
+var ErrSlackBusy = errors.New("Too many outstanding Slack posts")
+
+// SlackMessage is an alert as it is posted to Slack.
+type SlackMessage struct {
+	Channel ident.Ident // the channel of the alert; see WithChannel
+	Level   sentry.Level
+	Text    string
+}
+
+// Slack posts alerts to Slack. It is implemented by SlackWebhook and may be
+// replaced in tests.
+//
+// Deprecated: See Config.Slack.
+type Slack interface {
+	PostSlack(cxt context.Context, msg SlackMessage) error
+}
+
+// SlackWebhook posts alerts to Slack via incoming webhooks. The webhook for
+// the channel of a message is used if there is one, and otherwise URL.
+//
+// Deprecated: See Config.Slack; slack.Config.Webhooks selects a webhook per
+// channel in the same manner.
+type SlackWebhook struct {
+	URL      string
+	Channels map[ident.Ident]string
+	Client   *http.Client // defaults to http.DefaultClient
+}
+
+func (s SlackWebhook) PostSlack(cxt context.Context, msg SlackMessage) error {
+	url := s.URL
+	if u, ok := s.Channels[msg.Channel]; ok {
+		url = u
+	}
+	if url == "" {
+		return fmt.Errorf("No Slack webhook for channel: %v", msg.Channel)
+	}
+	data, err := json.Marshal(map[string]string{"text": msg.Text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(cxt, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		return fmt.Errorf("Slack webhook responded with status: %s", rsp.Status)
+	}
+	return nil
+}
+
+// slackText formats a compact description of an alert.
+func slackText(lvl sentry.Level, title string, tags Tags) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "*[%s]* %s", strings.ToUpper(string(lvl)), title)
+	var attrs []string
+	for _, k := range []string{"ref", "component", "host"} {
+		if v, ok := tags[k]; ok && fmt.Sprint(v) != "" {
+			attrs = append(attrs, fmt.Sprintf("%s: `%v`", k, v))
+		}
+	}
+	if len(attrs) > 0 {
+		b.WriteString("\n")
+		b.WriteString(strings.Join(attrs, " · "))
+	}
+	return b.String()
+}
+
+// postSlack posts an alert to Slack in the background, abandoning it if the
+// context the alert was raised in ends first. Posting is best effort:
+// failures are reported to the error handler and nothing else.
+func (a *Alerter) postSlack(parent context.Context, msg SlackMessage) {
+	select {
+	case a.slackPosts <- struct{}{}:
+	default:
+		a.notify(ErrSlackBusy)
+		a.countDeliveryError("slack", ErrSlackBusy)
+		return
+	}
+	go func() {
+		defer func() { <-a.slackPosts }()
+		cxt, cancel := context.WithTimeout(parent, slackTimeout)
+		defer cancel()
+		if err := a.slack.PostSlack(cxt, msg); err != nil {
+			a.notify(fmt.Errorf("Could not post to Slack: %w", err))
+			a.countDeliveryError("slack", err)
+		}
+	}()
+}
+
+// errorTitle produces the title of an error, if it has one, and otherwise its
+// message.
+func errorTitle(err error) string {
+	if c, ok := err.(interface{ Title() string }); ok && c.Title() != "" {
+		return c.Title()
+	}
+	return err.Error()
+}
@@ -0,0 +1,255 @@
+// Package slack provides an alert backend which posts alerts to Slack, either
+// via an incoming webhook or via the Slack API.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/bww/go-ident/v1"
+)
+
+// The default maximum time spent posting an alert.
+const defaultTimeout = 10 * time.Second
+
+// The endpoint alerts are posted to when a token is configured.
+const postMessageURL = "https://slack.com/api/chat.postMessage"
+
+var ErrNotConfigured = errors.New("Slack backend requires a webhook URL or a token")
+
+// Config configures a Slack backend. Either a webhook or Token is required;
+// if both are provided, the token is used.
+type Config struct {
+	WebhookURL string // an incoming webhook
+	Token      string // a bot token, used to post via chat.postMessage
+	// Webhooks overrides WebhookURL for alerts raised by alerters with the
+	// corresponding channel, for workspaces with an incoming webhook per
+	// channel; see alert.Config.Channel.
+	Webhooks map[ident.Ident]string
+
+	// Channel is the channel alerts are posted to. Channels overrides it for
+	// alerts raised by alerters with the corresponding channel; see
+	// alert.Config.Channel. Incoming webhooks post to the channel they were
+	// created for, which a channel provided here may not override.
+	Channel  string
+	Channels map[ident.Ident]string
+
+	Username  string
+	IconEmoji string
+
+	// MinLevel is the least severe level of the alerts that are posted; by
+	// default, all alerts are posted.
+	MinLevel alert.Level
+	// Format formats the message which is posted for an alert; by default,
+	// the message is formatted by Format.
+	Format func(e *alert.Event) Message
+
+	Timeout time.Duration // defaults to 10 seconds
+	Client  *http.Client  // defaults to http.DefaultClient
+}
+
+// Backend posts alerts to Slack. Alerts are posted on the goroutine which
+// delivers them, so alerters using this backend should generally deliver
+// asynchronously; see alert.Config.Async.
+type Backend struct {
+	url       string
+	webhooks  map[ident.Ident]string
+	token     string
+	channel   string
+	channels  map[ident.Ident]string
+	username  string
+	iconEmoji string
+	minLevel  alert.Level
+	format    func(e *alert.Event) Message
+	timeout   time.Duration
+	client    *http.Client
+}
+
+var _ alert.Backend = (*Backend)(nil)
+
+func New(conf Config) (*Backend, error) {
+	b := &Backend{
+		url:       conf.WebhookURL,
+		webhooks:  conf.Webhooks,
+		token:     conf.Token,
+		channel:   conf.Channel,
+		channels:  conf.Channels,
+		username:  conf.Username,
+		iconEmoji: conf.IconEmoji,
+		minLevel:  conf.MinLevel,
+		format:    conf.Format,
+		timeout:   conf.Timeout,
+		client:    conf.Client,
+	}
+	if b.token != "" {
+		b.url = postMessageURL
+		b.webhooks = nil
+	}
+	if b.url == "" && len(b.webhooks) == 0 {
+		return nil, ErrNotConfigured
+	}
+	if b.format == nil {
+		b.format = Format
+	}
+	if b.timeout <= 0 {
+		b.timeout = defaultTimeout
+	}
+	if b.client == nil {
+		b.client = http.DefaultClient
+	}
+	return b, nil
+}
+
+func (b *Backend) Capture(e *alert.Event) error {
+	if b.minLevel != "" && !e.AtLeast(b.minLevel) {
+		return alert.ErrSkipped
+	}
+
+	msg := b.format(e)
+	if msg.Channel == "" {
+		msg.Channel = b.channel
+		if c, ok := b.channels[e.Channel]; ok {
+			msg.Channel = c
+		}
+	}
+	if msg.Username == "" {
+		msg.Username = b.username
+	}
+	if msg.IconEmoji == "" {
+		msg.IconEmoji = b.iconEmoji
+	}
+	if b.token != "" && msg.Channel == "" {
+		return fmt.Errorf("No Slack channel for alert channel: %v", e.Channel)
+	}
+	url := b.url
+	if u, ok := b.webhooks[e.Channel]; ok {
+		url = u
+	}
+	if url == "" {
+		return fmt.Errorf("No Slack webhook for alert channel: %v", e.Channel)
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	cxt, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(cxt, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	rsp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Could not post to Slack: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		return fmt.Errorf("Slack responded with status: %s", rsp.Status)
+	}
+	if b.token != "" {
+		// the API responds with success and describes failures in the body
+		var res struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(rsp.Body).Decode(&res); err != nil {
+			return fmt.Errorf("Could not decode Slack response: %w", err)
+		}
+		if !res.OK {
+			return fmt.Errorf("Slack rejected message: %s", res.Error)
+		}
+	}
+	return nil
+}
+
+// Message is a message as it is posted to Slack.
+type Message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Username    string       `json:"username,omitempty"`
+	IconEmoji   string       `json:"icon_emoji,omitempty"`
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+type Attachment struct {
+	Color  string  `json:"color,omitempty"`
+	Title  string  `json:"title,omitempty"`
+	Text   string  `json:"text,omitempty"`
+	Fields []Field `json:"fields,omitempty"`
+	Footer string  `json:"footer,omitempty"`
+	Ts     int64   `json:"ts,omitempty"`
+}
+
+type Field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// Format formats an alert as a message which summarizes it, followed by an
+// attachment, colored by level, which describes the error chain and lists the
+// tags of the alert.
+func Format(e *alert.Event) Message {
+	att := Attachment{
+		Color:  levelColor(e.Level),
+		Title:  e.Ref,
+		Text:   chainText(e),
+		Footer: e.Component,
+		Ts:     e.Time.Unix(),
+	}
+	keys := make([]string, 0, len(e.Tags))
+	for k := range e.Tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		att.Fields = append(att.Fields, Field{Title: k, Value: e.Tags[k], Short: true})
+	}
+	return Message{
+		Text:        fmt.Sprintf("*[%s]* %s", strings.ToUpper(string(e.Level)), e.Message),
+		Attachments: []Attachment{att},
+	}
+}
+
+// chainText describes the error chain of an alert, outermost first.
+func chainText(e *alert.Event) string {
+	b := &strings.Builder{}
+	for i := len(e.Exception) - 1; i >= 0; i-- {
+		x := e.Exception[i]
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "`%s` %s", x.Type, x.Value)
+	}
+	return b.String()
+}
+
+func levelColor(lvl alert.Level) string {
+	switch lvl {
+	case alert.LevelFatal:
+		return "#7b0000"
+	case alert.LevelError:
+		return "danger"
+	case alert.LevelWarning:
+		return "warning"
+	case alert.LevelInfo:
+		return "good"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,216 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+	"github.com/bww/go-ident/v1"
+	"github.com/getsentry/sentry-go"
+)
+
+// post is a message as it was posted to the server.
+type post struct {
+	Path string
+	Auth string
+	Msg  Message
+}
+
+// server records the messages posted to it and responds with body, if any.
+type server struct {
+	sync.Mutex
+	posts []post
+	body  string
+}
+
+func (s *server) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+	var msg Message
+	if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+		http.Error(rsp, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.posts = append(s.posts, post{Path: req.URL.Path, Auth: req.Header.Get("Authorization"), Msg: msg})
+	if s.body != "" {
+		rsp.Write([]byte(s.body))
+	}
+}
+
+func (s *server) Posts() []post {
+	s.Lock()
+	defer s.Unlock()
+	return append([]post(nil), s.posts...)
+}
+
+// redirect sends every request to the server, so that the Slack API can be
+// stood in for.
+type redirect struct {
+	url *url.URL
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = r.url.Scheme, r.url.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newServer(t *testing.T, body string) (*server, *httptest.Server) {
+	s := &server{body: body}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func newEvent(lvl alert.Level) *alert.Event {
+	return &alert.Event{
+		Time:    time.Unix(1700000000, 0),
+		Level:   lvl,
+		Message: "Could not connect",
+		Ref:     "db:connect",
+		Tags:    map[string]string{"region": "us-east-1", "host": "db1"},
+		Exception: []sentry.Exception{
+			{Type: "*net.OpError", Value: "connection refused"},
+			{Type: "*errors.errorString", Value: "Could not connect"},
+		},
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	s, srv := newServer(t, "")
+	b, err := New(Config{WebhookURL: srv.URL + "/hook", Username: "alerts"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Capture(newEvent(alert.LevelError)); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := s.Posts()
+	if len(posts) != 1 {
+		t.Fatalf("Expected 1 post; got %d", len(posts))
+	}
+	p := posts[0]
+	if p.Path != "/hook" || p.Auth != "" {
+		t.Errorf("Expected an unauthenticated post to the webhook; got %s (%q)", p.Path, p.Auth)
+	}
+	if v := p.Msg.Text; v != "*[ERROR]* Could not connect" {
+		t.Errorf("Unexpected text: %q", v)
+	}
+	if v := p.Msg.Username; v != "alerts" {
+		t.Errorf("Expected the configured username; got %q", v)
+	}
+	if len(p.Msg.Attachments) != 1 {
+		t.Fatalf("Expected 1 attachment; got %d", len(p.Msg.Attachments))
+	}
+	att := p.Msg.Attachments[0]
+	if att.Color != "danger" || att.Title != "db:connect" || att.Ts != 1700000000 {
+		t.Errorf("Unexpected attachment: %+v", att)
+	}
+	if v := att.Text; v != "`*errors.errorString` Could not connect\n`*net.OpError` connection refused" {
+		t.Errorf("Expected the error chain, outermost first; got %q", v)
+	}
+	if len(att.Fields) != 2 || att.Fields[0].Title != "host" || att.Fields[1].Title != "region" {
+		t.Errorf("Expected the tags as fields, in order; got %+v", att.Fields)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	s, srv := newServer(t, "")
+	db := ident.New()
+	b, err := New(Config{Webhooks: map[ident.Ident]string{db: srv.URL + "/db"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEvent(alert.LevelError)
+	if err := b.Capture(e); err == nil {
+		t.Error("Expected an error for a channel without a webhook")
+	}
+	e.Channel = db
+	if err := b.Capture(e); err != nil {
+		t.Fatal(err)
+	}
+	if posts := s.Posts(); len(posts) != 1 || posts[0].Path != "/db" {
+		t.Errorf("Expected the alert to be posted to the webhook for its channel; got %+v", posts)
+	}
+}
+
+func TestToken(t *testing.T) {
+	s, srv := newServer(t, `{"ok":true}`)
+	u, _ := url.Parse(srv.URL)
+	db := ident.New()
+	b, err := New(Config{
+		Token:    "xoxb-token",
+		Channel:  "#alerts",
+		Channels: map[ident.Ident]string{db: "#db-alerts"},
+		Client:   &http.Client{Transport: redirect{u}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newEvent(alert.LevelWarning)
+	if err := b.Capture(e); err != nil {
+		t.Fatal(err)
+	}
+	e.Channel = db
+	if err := b.Capture(e); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := s.Posts()
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 posts; got %d", len(posts))
+	}
+	for i, want := range []string{"#alerts", "#db-alerts"} {
+		p := posts[i]
+		if p.Path != "/api/chat.postMessage" || p.Auth != "Bearer xoxb-token" {
+			t.Errorf("Expected post %d to be made via the API with the token; got %s (%q)", i, p.Path, p.Auth)
+		}
+		if p.Msg.Channel != want {
+			t.Errorf("Expected post %d to channel %s; got %q", i, want, p.Msg.Channel)
+		}
+	}
+}
+
+func TestTokenRejected(t *testing.T) {
+	_, srv := newServer(t, `{"ok":false,"error":"channel_not_found"}`)
+	u, _ := url.Parse(srv.URL)
+	b, err := New(Config{Token: "xoxb-token", Channel: "#gone", Client: &http.Client{Transport: redirect{u}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Capture(newEvent(alert.LevelError)); err == nil || err.Error() != "Slack rejected message: channel_not_found" {
+		t.Errorf("Expected the message to be rejected; got %v", err)
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+	s, srv := newServer(t, "")
+	b, err := New(Config{WebhookURL: srv.URL, MinLevel: alert.LevelError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Capture(newEvent(alert.LevelWarning)); !errors.Is(err, alert.ErrSkipped) {
+		t.Errorf("Expected an alert below the minimum level to be skipped; got %v", err)
+	}
+	if err := b.Capture(newEvent(alert.LevelFatal)); err != nil {
+		t.Fatal(err)
+	}
+	if posts := s.Posts(); len(posts) != 1 {
+		t.Errorf("Expected only the fatal alert to be posted; got %d", len(posts))
+	}
+}
+
+func TestNotConfigured(t *testing.T) {
+	if _, err := New(Config{}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Expected %v; got %v", ErrNotConfigured, err)
+	}
+}
@@ -0,0 +1,153 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bww/go-ident/v1"
+)
+
+// slackRecorder records the messages posted to it, failing to post them
+// with err, if set, and waiting for release, if set, before it does.
+type slackRecorder struct {
+	posts   chan SlackMessage
+	release chan struct{}
+	err     error
+}
+
+func newSlackRecorder() *slackRecorder {
+	return &slackRecorder{posts: make(chan SlackMessage, 100)}
+}
+
+func (s *slackRecorder) PostSlack(cxt context.Context, msg SlackMessage) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.posts <- msg
+	return s.err
+}
+
+// Post waits for a message to be posted.
+func (s *slackRecorder) Post(tb testing.TB) SlackMessage {
+	tb.Helper()
+	select {
+	case msg := <-s.posts:
+		return msg
+	case <-time.After(5 * time.Second):
+		tb.Fatal("Timed out waiting for a Slack post")
+		return SlackMessage{}
+	}
+}
+
+func TestSlack(t *testing.T) {
+	s, channel := newSlackRecorder(), ident.New()
+	a, tr := newAlerter(t, Config{Slack: s, Channel: channel, Component: "billing", Hostname: "web1"})
+	a.Error(errors.New("Could not charge card"), WithRef("charge"))
+
+	msg := s.Post(t)
+	if msg.Channel != channel || msg.Level != LevelError {
+		t.Errorf("Expected the message for the channel of the alerter at error; got %v, %s", msg.Channel, msg.Level)
+	}
+	if want := "*[ERROR]* Could not charge card\nref: `charge` · component: `billing` · host: `web1`"; msg.Text != want {
+		t.Errorf("Expected the text %q; got %q", want, msg.Text)
+	}
+	if n := len(tr.Events()); n != 1 {
+		t.Errorf("Expected the alert to be reported to Sentry as well; got %d events", n)
+	}
+}
+
+func TestSlackFailure(t *testing.T) {
+	s := newSlackRecorder()
+	s.err = errors.New("Unavailable")
+	errs := make(chan error, 10)
+	a, tr := newAlerter(t, Config{Slack: s, OnError: func(err error) { errs <- err }})
+	a.Error(errors.New("Failed"))
+
+	s.Post(t)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, s.err) {
+			t.Errorf("Expected the failure to be reported; got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the failure to be reported")
+	}
+	if n := len(tr.Events()); n != 1 {
+		t.Errorf("Expected the alert to be reported to Sentry regardless; got %d events", n)
+	}
+}
+
+func TestSlackNonBlocking(t *testing.T) {
+	s := newSlackRecorder()
+	s.release = make(chan struct{})
+	defer close(s.release)
+	var busy int
+	var mu sync.Mutex
+	a, tr := newAlerter(t, Config{Slack: s, OnError: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if errors.Is(err, ErrSlackBusy) {
+			busy++
+		}
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < maxSlackPosts+2; i++ {
+			a.Error(errors.New("Failed"))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a slow Slack not to stall the caller")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if busy != 2 {
+		t.Errorf("Expected alerts beyond the outstanding posts not to be posted; got %d", busy)
+	}
+	if n := len(tr.Events()); n != maxSlackPosts+2 {
+		t.Errorf("Expected every alert to be reported to Sentry; got %d events", n)
+	}
+}
+
+func TestSlackWebhook(t *testing.T) {
+	var mu sync.Mutex
+	posts := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		var body map[string]string
+		json.NewDecoder(req.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		posts[req.URL.Path] = body["text"]
+	}))
+	defer srv.Close()
+
+	db := ident.New()
+	s := SlackWebhook{URL: srv.URL + "/default", Channels: map[ident.Ident]string{db: srv.URL + "/db"}}
+	if err := s.PostSlack(context.Background(), SlackMessage{Text: "Failed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.PostSlack(context.Background(), SlackMessage{Channel: db, Text: "Slow query"}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	if posts["/default"] != "Failed" || posts["/db"] != "Slow query" {
+		t.Errorf("Expected messages to be posted to the webhook for their channel; got %v", posts)
+	}
+	mu.Unlock()
+
+	s.URL = ""
+	if err := s.PostSlack(context.Background(), SlackMessage{Text: "Failed"}); err == nil || !strings.Contains(err.Error(), "No Slack webhook") {
+		t.Errorf("Expected an error for a channel without a webhook; got %v", err)
+	}
+}
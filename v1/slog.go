@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"context"
+	"log/slog"
+)
+
+type alertLogKey struct{}
+
+// alertLogContext is the context the alerter logs alerts in, which marks
+// its records so that a SlogHandler does not report them again.
+var alertLogContext = context.WithValue(context.Background(), alertLogKey{}, struct{}{})
+
+// SlogHandler is a slog.Handler which reports the records it handles at or
+// above a threshold level as alerts before passing them on to the handler
+// it wraps, so that code which already logs errors via slog is alerted on
+// without changing it:
+//
+//	slog.SetDefault(slog.New(alert.NewSlogHandler(a, slog.LevelError, slog.Default().Handler())))
+//
+// The message of a record is the message of the alert. An attribute whose
+// value is an error is reported as the cause; attributes whose values are
+// strings, numbers, or booleans become tags and the others become extra,
+// with grouped keys qualified by their groups. The alert is reported at the
+// level of the record in the context it was logged in.
+//
+// Records the alerter logs itself are not reported, but they are passed on,
+// so alerts raised this way are logged twice if the alerter is verbose and
+// logs to the same handler.
+type SlogHandler struct {
+	alerter *Alerter
+	min     slog.Level
+	next    slog.Handler
+	attrs   []slog.Attr
+	prefix  string
+}
+
+// NewSlogHandler creates a SlogHandler which reports records at or above the
+// specified level via the alerter and wraps the specified handler.
+func NewSlogHandler(a *Alerter, min slog.Level, next slog.Handler) *SlogHandler {
+	return &SlogHandler{alerter: a, min: min, next: next}
+}
+
+func (h *SlogHandler) Enabled(cxt context.Context, lvl slog.Level) bool {
+	return lvl >= h.min || h.next.Enabled(cxt, lvl)
+}
+
+func (h *SlogHandler) Handle(cxt context.Context, rec slog.Record) error {
+	if rec.Level >= h.min && cxt.Value(alertLogKey{}) == nil {
+		h.report(cxt, rec)
+	}
+	if !h.next.Enabled(cxt, rec.Level) {
+		return nil
+	}
+	return h.next.Handle(cxt, rec)
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	d := *h
+	d.next = h.next.WithAttrs(attrs)
+	d.attrs = append(append([]slog.Attr(nil), h.attrs...), qualify(h.prefix, attrs)...)
+	return &d
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	d := *h
+	d.next = h.next.WithGroup(name)
+	d.prefix = h.prefix + name + "."
+	return &d
+}
+
+// report reports a record as an alert.
+func (h *SlogHandler) report(cxt context.Context, rec slog.Record) {
+	attrs := append([]slog.Attr(nil), h.attrs...)
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, qualify(h.prefix, []slog.Attr{a})...)
+		return true
+	})
+
+	var cause error
+	tags := make(Tags)
+	extra := make(map[string]interface{})
+	for _, a := range attrs {
+		switch a.Value.Kind() {
+		case slog.KindString, slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindBool:
+			tags[a.Key] = a.Value.Any()
+		default:
+			if e, ok := a.Value.Any().(error); ok && cause == nil {
+				cause = e
+			} else {
+				extra[a.Key] = a.Value.Any()
+			}
+		}
+	}
+
+	var err error = message(rec.Message)
+	if cause != nil {
+		err = &logError{msg: rec.Message, err: cause}
+	}
+	h.alerter.report(err,
+		WithLevel(sentryLevel(rec.Level)),
+		WithLogLevel(rec.Level),
+		WithContext(cxt),
+		WithTags(tags),
+		WithExtra(extra),
+	)
+}
+
+// logError is the error reported for a log record which carries an error.
+// Its title is the message of the record.
+type logError struct {
+	msg string
+	err error
+}
+
+func (e *logError) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *logError) Title() string {
+	return e.msg
+}
+
+func (e *logError) Unwrap() error {
+	return e.err
+}
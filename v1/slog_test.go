@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// newSlogged produces a logger which reports records at or above the level
+// via an alerter delivering to the backend, and logs to the returned logs.
+func newSlogged(t *testing.T, min slog.Level) (*slog.Logger, *backend, *logs) {
+	t.Helper()
+	b := &backend{}
+	a, err := New(Config{Backends: []Backend{b}, Verbose: Bool(false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	next, recs := newLogger()
+	return slog.New(NewSlogHandler(a, min, next.Handler())), b, recs
+}
+
+func TestSlogHandlerThreshold(t *testing.T) {
+	log, b, recs := newSlogged(t, slog.LevelWarn)
+	log.Debug("Polling")
+	log.Info("Started")
+	log.Warn("Slow")
+	log.Error("Disk full")
+
+	events := b.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected only records at or above the threshold to be reported; got %d", len(events))
+	}
+	if e := events[0]; e.Level != LevelWarning || e.Message != "Slow" {
+		t.Errorf("Expected a warning; got %s %q", e.Level, e.Message)
+	}
+	if e := events[1]; e.Level != LevelError || e.Message != "Disk full" {
+		t.Errorf("Expected an error; got %s %q", e.Level, e.Message)
+	}
+	if n := len(recs.Records(t)); n != 4 {
+		t.Errorf("Expected every record to be passed on; got %d", n)
+	}
+}
+
+func TestSlogHandlerAttrs(t *testing.T) {
+	log, b, recs := newSlogged(t, slog.LevelError)
+	cause := errors.New("connection refused")
+	log.Error("Could not connect",
+		slog.String("host", "db1"),
+		slog.Int("attempt", 3),
+		slog.Bool("retry", false),
+		slog.Any("err", cause),
+		slog.Any("ports", []int{5432, 5433}),
+	)
+
+	events := b.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected one alert; got %d", len(events))
+	}
+	e := events[0]
+	if !errors.Is(e.Err, cause) {
+		t.Errorf("Expected an error attribute to be reported as the cause; got %v", e.Err)
+	}
+	if v := e.Err.Error(); v != "Could not connect: connection refused" {
+		t.Errorf("Unexpected error: %q", v)
+	}
+	for k, want := range map[string]string{"host": "db1", "attempt": "3", "retry": "false"} {
+		if v := e.Tags[k]; v != want {
+			t.Errorf("Expected tag %s=%s; got %q", k, want, v)
+		}
+	}
+	if _, ok := e.Tags["err"]; ok {
+		t.Error("Expected the cause not to be a tag")
+	}
+	if v, ok := e.Extra["ports"].([]int); !ok || len(v) != 2 {
+		t.Errorf("Expected other attributes to be extra; got %v", e.Extra["ports"])
+	}
+	if v := recs.Record(t)["host"]; v != "db1" {
+		t.Errorf("Expected the attributes to be passed on; got %v", v)
+	}
+}
+
+func TestSlogHandlerWith(t *testing.T) {
+	log, b, recs := newSlogged(t, slog.LevelError)
+	log.With("request", "r1").WithGroup("db").With("pool", "primary").Error("Query failed", "table", "users")
+
+	events := b.Events()
+	if len(events) != 1 {
+		t.Fatalf("Expected one alert; got %d", len(events))
+	}
+	for k, want := range map[string]string{"request": "r1", "db.pool": "primary", "db.table": "users"} {
+		if v := events[0].Tags[k]; v != want {
+			t.Errorf("Expected tag %s=%s; got %q", k, want, v)
+		}
+	}
+	db, _ := recs.Record(t)["db"].(map[string]interface{})
+	if db["pool"] != "primary" || db["table"] != "users" {
+		t.Errorf("Expected grouped attributes to be passed on in their group; got %v", db)
+	}
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	a, _ := newAlerter(t, Config{})
+	h := NewSlogHandler(a, slog.LevelWarn, slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))
+	for lvl, want := range map[slog.Level]bool{slog.LevelInfo: false, slog.LevelWarn: true, slog.LevelError: true} {
+		if v := h.Enabled(context.Background(), lvl); v != want {
+			t.Errorf("Expected %v enabled: %v; got %v", lvl, want, v)
+		}
+	}
+}
+
+func TestSlogHandlerOwnRecords(t *testing.T) {
+	b := &backend{}
+	next, recs := newLogger()
+	h := NewSlogHandler(nil, slog.LevelError, next.Handler())
+	a, err := New(Config{Backends: []Backend{b}, Verbose: Bool(true), Logger: slog.New(h)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	h.alerter = a
+
+	a.Error(errors.New("Failed"))
+	if n := len(b.Events()); n != 1 {
+		t.Errorf("Expected the alerter's own records not to be reported again; got %d alerts", n)
+	}
+	if n := len(recs.Records(t)); n != 1 {
+		t.Errorf("Expected the alerter's own records to be passed on; got %d", n)
+	}
+}
@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"errors"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// The maximum time CaptureSync waits for an event to be delivered.
+const syncTimeout = 10 * time.Second
+
+var (
+	ErrNotCaptured     = errors.New("Event was not captured")
+	ErrDeliveryTimeout = errors.New("Timed out delivering event")
+)
+
+// CaptureSync reports an error at the specified level and waits until it has
+// been delivered. This is intended for the small number of errors which must
+// not be lost; most errors should be reported via Error, which does not wait.
+//
+// The event is delivered on the caller's goroutine, bypassing the async queue
+// if there is one, and only the clients which captured it are flushed; note
+// that flushing a client also waits for any events it buffered before this
+// one. The identifier of the event is returned if any client or backend
+// captured it. If a backend failed to deliver it, that failure is returned,
+// as a DeliveryError, and if a client could not deliver it in time,
+// ErrDeliveryTimeout is returned; failures are not retried. If nothing
+// captured the event, e.g., because no client is configured or the error was
+// suppressed, ErrNotCaptured is returned.
+//
+// CaptureSync waits for the sync timeout or until the deadline provided via
+// WithDeadline, if any, whichever is sooner.
+func (a *Alerter) CaptureSync(lvl sentry.Level, err error, opts ...Option) (*sentry.EventID, error) {
+	opts = append([]Option{WithLevel(lvl)}, opts...)
+
+	timeout := syncTimeout
+	if d := newContext(opts).Deadline; !d.IsZero() {
+		timeout = min(timeout, time.Until(d))
+	}
+	if timeout <= 0 {
+		return nil, ErrDeliveryTimeout
+	}
+	deadline := time.Now().Add(timeout)
+
+	d := &delivery{}
+	a.report(err, append(opts, withDelivery(d))...)
+	errs := make([]error, 0, len(d.failed)+1)
+	for _, e := range d.failed {
+		errs = append(errs, e)
+	}
+	if d.id == nil && len(errs) == 0 {
+		return nil, ErrNotCaptured
+	}
+	if !flushClients(d.clients, time.Until(deadline)) {
+		errs = append(errs, ErrDeliveryTimeout)
+	}
+	return d.id, errors.Join(errs...)
+}
+
+// delivery describes the delivery of an event: its identifier, if it was
+// captured, the clients which captured it, and the failures of the backends
+// which did not deliver it.
+type delivery struct {
+	id      *sentry.EventID
+	clients []Client
+	failed  []*DeliveryError
+}
+
+// outcome produces the outcome of an alert which was delivered: it was sent
+// if anything captured it, dropped if a backend failed to deliver it, and
+// otherwise ignored, since every destination filtered it out.
+func (d delivery) outcome() Outcome {
+	switch {
+	case d.id != nil:
+		return OutcomeSent
+	case len(d.failed) > 0:
+		return OutcomeDropped
+	default:
+		return OutcomeIgnored
+	}
+}
+
+// withDelivery directs the alert to be delivered on the caller's goroutine,
+// recording the result in d, rather than queued or retried.
+func withDelivery(d *delivery) Option {
+	return func(c Context) Context {
+		c.delivery = d
+		return c
+	}
+}
@@ -0,0 +1,126 @@
+package alert
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestCaptureSync(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	id, err := a.CaptureSync(sentry.LevelError, errors.New("Failed"))
+	if err != nil {
+		t.Fatalf("Expected the event to be delivered; got %v", err)
+	}
+	if id == nil || *id != tr.Event(t).EventID {
+		t.Errorf("Expected the identifier of the event delivered; got %v", id)
+	}
+	if tr.flushes != 1 {
+		t.Errorf("Expected the client to be flushed; got %d flushes", tr.flushes)
+	}
+}
+
+func TestCaptureSyncTransportFailure(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	tr.stalled = true
+	if _, err := a.CaptureSync(sentry.LevelError, errors.New("Failed")); !errors.Is(err, ErrDeliveryTimeout) {
+		t.Errorf("Expected ErrDeliveryTimeout when the client cannot deliver the event; got %v", err)
+	}
+}
+
+func TestCaptureSyncBackendFailure(t *testing.T) {
+	failed := errors.New("Connection refused")
+	b := &backend{err: failed}
+	var deadLetters int
+	a, err := New(Config{Backends: []Backend{b}, OnDeliveryFailure: func(*Event, *DeliveryError) { deadLetters++ }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	_, err = a.CaptureSync(sentry.LevelError, errors.New("Failed"))
+	var derr *DeliveryError
+	if !errors.As(err, &derr) || derr.Backend != b || !errors.Is(err, failed) {
+		t.Errorf("Expected the failure of the backend; got %v", err)
+	}
+	if deadLetters != 1 {
+		t.Errorf("Expected the failure to be handed to OnDeliveryFailure; got %d", deadLetters)
+	}
+}
+
+func TestCaptureSyncNotCaptured(t *testing.T) {
+	a, _ := newAlerter(t, Config{Ignore: []Ignore{IgnoreIs(errIgnored)}})
+	if _, err := a.CaptureSync(sentry.LevelError, errIgnored); !errors.Is(err, ErrNotCaptured) {
+		t.Errorf("Expected ErrNotCaptured for an ignored error; got %v", err)
+	}
+}
+
+func TestCaptureSyncBypassesQueue(t *testing.T) {
+	a, tr := newAlerter(t, Config{Async: Async{Buffer: 1}})
+	a.async.Stop() // nothing queued would be delivered
+
+	if _, err := a.CaptureSync(sentry.LevelError, errors.New("Failed")); err != nil {
+		t.Fatalf("Expected the event to be delivered; got %v", err)
+	}
+	if n := len(tr.Events()); n != 1 {
+		t.Errorf("Expected the event to be delivered directly; got %d events", n)
+	}
+}
+
+var errIgnored = errors.New("ignored")
+
+// slowClient is a client which does not finish delivering its first event,
+// and so takes as long as it is allowed to flush it.
+type slowClient struct {
+	timeout time.Duration
+}
+
+func (c *slowClient) CaptureEvent(event *sentry.Event, hint *sentry.EventHint, scope sentry.EventModifier) *sentry.EventID {
+	id := sentry.EventID("0123456789abcdef0123456789abcdef")
+	return &id
+}
+
+func (c *slowClient) Flush(timeout time.Duration) bool {
+	if c.timeout != 0 {
+		return true
+	}
+	c.timeout = timeout
+	time.Sleep(timeout)
+	return false
+}
+
+func TestCaptureSyncDeadline(t *testing.T) {
+	c := &slowClient{}
+	a, err := New(Config{Tee: []Client{c}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	start := time.Now()
+	id, err := a.CaptureSync(sentry.LevelError, errors.New("Failed"), WithDeadline(start.Add(50*time.Millisecond)))
+	if !errors.Is(err, ErrDeliveryTimeout) {
+		t.Errorf("Expected ErrDeliveryTimeout; got %v", err)
+	}
+	if id == nil {
+		t.Error("Expected the identifier of the event which was captured but not delivered in time")
+	}
+	if c.timeout <= 0 || c.timeout > 50*time.Millisecond {
+		t.Errorf("Expected the flush to be bounded by the deadline; got %v", c.timeout)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected CaptureSync to return by the deadline; took %v", elapsed)
+	}
+}
+
+func TestCaptureSyncPastDeadline(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	if _, err := a.CaptureSync(sentry.LevelError, errors.New("Failed"), WithDeadline(time.Now().Add(-time.Second))); !errors.Is(err, ErrDeliveryTimeout) {
+		t.Errorf("Expected ErrDeliveryTimeout for a deadline which has passed; got %v", err)
+	}
+	if n := len(tr.Events()); n != 0 {
+		t.Errorf("Expected nothing to be delivered; got %d events", n)
+	}
+}
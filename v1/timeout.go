@@ -0,0 +1,31 @@
+package alert
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// TimeoutError describes an operation that did not complete in time.
+type TimeoutError struct {
+	Operation string
+	Elapsed   time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("Timed out after %v: %s", e.Elapsed, e.Operation)
+}
+
+// Timeout reports that the named operation timed out after the elapsed
+// duration. Timeouts are reported as warnings tagged with the operation and
+// the elapsed time in milliseconds, and all timeouts of the same operation
+// are grouped together regardless of how long they took.
+func (a *Alerter) Timeout(op string, elapsed time.Duration, opts ...Option) {
+	opts = append([]Option{
+		WithLevel(sentry.LevelWarning),
+		WithFingerprint("timeout", op),
+	}, opts...)
+	opts = append(opts, mergeTags(Tags{"operation": op, "timeout_ms": elapsed.Milliseconds()}))
+	a.Error(TimeoutError{Operation: op, Elapsed: elapsed}, opts...)
+}
@@ -0,0 +1,37 @@
+package alert
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestTimeout(t *testing.T) {
+	a, tr := newAlerter(t, Config{})
+	a.Timeout("fetch-invoices", 1500*time.Millisecond)
+	a.Timeout("fetch-invoices", 3*time.Second, WithTags(Tags{"operation": "spoofed", "region": "eu"}))
+
+	events := tr.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected two events; got %d", len(events))
+	}
+	for i, e := range events {
+		if v := e.Tags["operation"]; v != "fetch-invoices" {
+			t.Errorf("Expected event %d to be tagged with the operation; got %q", i, v)
+		}
+		if want := []string{"timeout", "fetch-invoices"}; !slices.Equal(e.Fingerprint, want) {
+			t.Errorf("Expected event %d to be fingerprinted %v regardless of the elapsed time; got %v", i, want, e.Fingerprint)
+		}
+		if e.Level != sentry.LevelWarning {
+			t.Errorf("Expected event %d to be a warning; got %s", i, e.Level)
+		}
+	}
+	if v := events[0].Tags["timeout_ms"]; v != "1500" {
+		t.Errorf("Expected timeout_ms 1500; got %q", v)
+	}
+	if v := events[1].Tags["region"]; v != "eu" {
+		t.Errorf("Expected the tags provided to be merged; got %q", v)
+	}
+}
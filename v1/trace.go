@@ -0,0 +1,93 @@
+package alert
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type contextKey string
+
+// Trace and span identifiers stored in a context.Context under these keys,
+// as strings, are attached to alerts raised in that context when it has no
+// active Sentry span, nor one of the configured Tracer; see WithContext. The
+// trace identifier is tagged as "trace_id".
+const (
+	TraceIDKey = contextKey("trace_id")
+	SpanIDKey  = contextKey("span_id")
+)
+
+// TraceParent identifies the span that work reporting an alert descends
+// from, such as the request that enqueued a background job.
+type TraceParent struct {
+	TraceID string
+	SpanID  string
+}
+
+// traceContext produces the Sentry trace context which links an event to the
+// parent span. The event is given a span of its own under the parent.
+func (p TraceParent) traceContext() sentry.Context {
+	return sentry.Context{
+		"trace_id":       p.TraceID,
+		"span_id":        newSpanID(),
+		"parent_span_id": p.SpanID,
+	}
+}
+
+func newSpanID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// spanContext produces the Sentry trace context which links an event to the
+// active span, typically that of a transaction, in which it was raised.
+func spanContext(span *sentry.Span) sentry.Context {
+	c := sentry.Context{
+		"trace_id": span.TraceID.String(),
+		"span_id":  span.SpanID.String(),
+	}
+	if span.ParentSpanID != (sentry.SpanID{}) {
+		c["parent_span_id"] = span.ParentSpanID.String()
+	}
+	if span.Op != "" {
+		c["op"] = span.Op
+	}
+	return c
+}
+
+// Tracer integrates an alerter with a tracing system other than Sentry, such
+// as OpenTelemetry, which this package does not depend on. An adapter for
+// OpenTelemetry might look like:
+//
+//	type otelTracer struct{}
+//
+//	func (otelTracer) SpanFromContext(cxt context.Context) (alert.TraceParent, bool) {
+//		sc := trace.SpanContextFromContext(cxt)
+//		return alert.TraceParent{TraceID: sc.TraceID().String(), SpanID: sc.SpanID().String()}, sc.IsValid()
+//	}
+//
+//	func (otelTracer) RecordError(cxt context.Context, err error) {
+//		span := trace.SpanFromContext(cxt)
+//		span.RecordError(err)
+//		span.SetStatus(codes.Error, err.Error())
+//	}
+type Tracer interface {
+	// SpanFromContext produces the identifiers of the span active in the
+	// context, if there is one.
+	SpanFromContext(cxt context.Context) (TraceParent, bool)
+	// RecordError records an error reported in the context on the span
+	// active in it, if there is one.
+	RecordError(cxt context.Context, err error)
+}
+
+// traceFromContext produces the span of the configured tracer which is
+// active in the context, if any.
+func (a *Alerter) traceFromContext(cxt context.Context) (TraceParent, bool) {
+	if a.tracer == nil {
+		return TraceParent{}, false
+	}
+	return a.tracer.SpanFromContext(cxt)
+}
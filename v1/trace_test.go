@@ -0,0 +1,73 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestWithParentTrace(t *testing.T) {
+	const traceID, spanID = "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Job failed"), WithParentTrace(traceID, spanID))
+
+	trace := tr.Event(t).Contexts["trace"]
+	if v := trace["trace_id"]; v != traceID {
+		t.Errorf("Expected the trace of the parent %s; got %v", traceID, v)
+	}
+	if v := trace["parent_span_id"]; v != spanID {
+		t.Errorf("Expected the parent span %s; got %v", spanID, v)
+	}
+	if v, _ := trace["span_id"].(string); len(v) != 16 || v == spanID {
+		t.Errorf("Expected the event to have a span of its own; got %q", v)
+	}
+}
+
+func TestActiveTransaction(t *testing.T) {
+	c, _ := newClient(t)
+	cxt := sentry.SetHubOnContext(context.Background(), sentry.NewHub(c, sentry.NewScope()))
+	txn := sentry.StartTransaction(cxt, "sync", sentry.WithOpName("job"))
+	defer txn.Finish()
+	span := txn.StartChild("db.query")
+	defer span.Finish()
+
+	a, tr := newAlerter(t, Config{})
+	a.Error(errors.New("Job failed"), WithSpan(txn))
+	a.Error(errors.New("Query failed"), WithContext(span.Context()))
+	a.Error(errors.New("Query failed"), WithContext(span.Context()), WithParentTrace("0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331"))
+
+	events := tr.Events()
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events; got %d", len(events))
+	}
+	expect := []sentry.Context{
+		{"trace_id": txn.TraceID.String(), "span_id": txn.SpanID.String(), "op": "job"},
+		{"trace_id": txn.TraceID.String(), "span_id": span.SpanID.String(), "parent_span_id": txn.SpanID.String(), "op": "db.query"},
+	}
+	for i, e := range expect {
+		if v := events[i].Contexts["trace"]; !reflect.DeepEqual(v, e) {
+			t.Errorf("Expected event %d to carry the trace context of the active span %v; got %v", i, e, v)
+		}
+	}
+	if v := events[2].Contexts["trace"]["trace_id"]; v != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("Expected a parent trace to take precedence over the active span; got %v", v)
+	}
+}
+
+func TestContextTraceID(t *testing.T) {
+	log, recs := newLogger()
+	a, tr := newAlerter(t, Config{Verbose: Bool(true), Logger: log})
+	cxt := context.WithValue(context.WithValue(context.Background(), TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736"), SpanIDKey, "00f067aa0ba902b7")
+	a.Error(errors.New("Failed"), WithContext(cxt))
+
+	if v := tr.Event(t).Tags["trace_id"]; v != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the trace of the context to be tagged; got %q", v)
+	}
+	rec := recs.Record(t)
+	if rec["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || rec["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("Expected the trace and span of the context to be logged; got %v", rec)
+	}
+}
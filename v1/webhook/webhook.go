@@ -0,0 +1,268 @@
+// Package webhook provides an alert backend which posts alerts, encoded as
+// JSON, to arbitrary HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+)
+
+// The header which carries the signature of a request, when a secret is
+// configured.
+const SignatureHeader = "X-Alert-Signature"
+
+const defaultTimeout = 10 * time.Second
+
+// The maximum number of alerts for which the endpoints which have yet to
+// accept them are remembered, so that retries post only to those.
+const maxPending = 1000
+
+var ErrNoURLs = errors.New("Webhook backend requires at least one URL")
+
+// Config configures a webhook backend.
+type Config struct {
+	URLs    []string    // the endpoints every alert is posted to
+	Headers http.Header // additional headers set on every request
+	// Secret, if provided, is used to sign requests: the header
+	// X-Alert-Signature carries "sha256=" followed by the hex-encoded
+	// HMAC-SHA256 of the request body.
+	Secret []byte
+	// MinLevel is the least severe level of the alerts that are posted; by
+	// default, all alerts are posted.
+	MinLevel alert.Level
+
+	Timeout time.Duration // the maximum time spent on each request; defaults to 10 seconds
+	Client  *http.Client  // defaults to http.DefaultClient
+}
+
+// Backend posts alerts to webhooks. Alerts are posted on the goroutine which
+// delivers them, so alerters using this backend should generally deliver
+// asynchronously; see alert.Config.Async. Failed deliveries are retried by
+// the alerter, as configured by alert.Async.Retries, and a retry posts the
+// alert only to the endpoints which have not yet accepted it.
+type Backend struct {
+	urls     []string
+	headers  http.Header
+	secret   []byte
+	minLevel alert.Level
+	timeout  time.Duration
+	client   *http.Client
+
+	mu      sync.Mutex
+	pending map[*alert.Event][]string // the endpoints which failed to accept an alert
+	order   []*alert.Event            // the alerts in pending, oldest first
+}
+
+var _ alert.Backend = (*Backend)(nil)
+
+func New(conf Config) (*Backend, error) {
+	if len(conf.URLs) == 0 {
+		return nil, ErrNoURLs
+	}
+	b := &Backend{
+		urls:     conf.URLs,
+		headers:  conf.Headers,
+		secret:   conf.Secret,
+		minLevel: conf.MinLevel,
+		timeout:  conf.Timeout,
+		client:   conf.Client,
+		pending:  make(map[*alert.Event][]string),
+	}
+	if b.timeout <= 0 {
+		b.timeout = defaultTimeout
+	}
+	if b.client == nil {
+		b.client = http.DefaultClient
+	}
+	return b, nil
+}
+
+// Payload is an alert as it is posted to a webhook.
+type Payload struct {
+	ID          string                 `json:"id"`
+	Time        time.Time              `json:"time"`
+	Level       string                 `json:"level"`
+	Message     string                 `json:"message"`
+	Ref         string                 `json:"ref,omitempty"`
+	Component   string                 `json:"component,omitempty"`
+	Priority    string                 `json:"priority,omitempty"`
+	Channel     string                 `json:"channel,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	Fingerprint []string               `json:"fingerprint,omitempty"`
+	Exception   []Exception            `json:"exception,omitempty"`
+	Request     *Request               `json:"request,omitempty"`
+	Attachments []Attachment           `json:"attachments,omitempty"`
+}
+
+// Attachment describes a payload attached to an alert. The payload itself
+// is not posted.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int    `json:"size"`
+}
+
+// Exception describes an error in the chain of an alert, innermost first.
+type Exception struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Request describes the request an alert was raised for. Only its path is
+// included, since its query may carry credentials.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// NewPayload produces the payload which describes an alert.
+func NewPayload(e *alert.Event) Payload {
+	p := Payload{
+		ID:          e.ID,
+		Time:        e.Time,
+		Level:       string(e.Level),
+		Message:     e.Message,
+		Ref:         e.Ref,
+		Component:   e.Component,
+		Priority:    string(e.Priority),
+		Environment: e.Environment,
+		Release:     e.Release,
+		Tags:        e.Tags,
+		Extra:       encodable(e.Extra),
+		Fingerprint: e.Fingerprint,
+	}
+	if !e.Channel.IsZero() {
+		p.Channel = e.Channel.String()
+	}
+	for _, x := range e.Exception {
+		p.Exception = append(p.Exception, Exception{Type: x.Type, Value: x.Value})
+	}
+	for _, x := range e.Attachments {
+		p.Attachments = append(p.Attachments, Attachment{Name: x.Filename, ContentType: x.ContentType, Size: len(x.Payload)})
+	}
+	if r := e.Request; r != nil {
+		p.Request = &Request{Method: r.Method, Path: r.URL.Path}
+	}
+	return p
+}
+
+// encodable produces a copy of the extra values in which those which cannot
+// be encoded as JSON are replaced with their string representation.
+func encodable(extra map[string]interface{}) map[string]interface{} {
+	if len(extra) == 0 {
+		return nil
+	}
+	res := make(map[string]interface{}, len(extra))
+	for k, v := range extra {
+		if _, err := json.Marshal(v); err != nil {
+			res[k] = fmt.Sprint(v)
+		} else {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func (b *Backend) Capture(e *alert.Event) error {
+	if b.minLevel != "" && !e.AtLeast(b.minLevel) {
+		return alert.ErrSkipped
+	}
+	data, err := json.Marshal(NewPayload(e))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	var failed []string
+	for _, u := range b.remaining(e) {
+		if err := b.post(u, data); err != nil {
+			errs = append(errs, err)
+			failed = append(failed, u)
+		}
+	}
+	b.setRemaining(e, failed)
+	return errors.Join(errs...)
+}
+
+// remaining produces the endpoints which have yet to accept an alert, which
+// are all of them unless delivery of the alert is being retried.
+func (b *Backend) remaining(e *alert.Event) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if u, ok := b.pending[e]; ok {
+		return u
+	}
+	return b.urls
+}
+
+// setRemaining records the endpoints which have yet to accept an alert. The
+// oldest alerts are forgotten beyond maxPending, e.g., those which are never
+// retried.
+func (b *Backend) setRemaining(e *alert.Event, urls []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(urls) == 0 {
+		if _, ok := b.pending[e]; ok {
+			delete(b.pending, e)
+			b.order = slices.DeleteFunc(b.order, func(x *alert.Event) bool { return x == e })
+		}
+		return
+	}
+	if _, ok := b.pending[e]; !ok {
+		b.order = append(b.order, e)
+	}
+	b.pending[e] = urls
+	for len(b.order) > maxPending {
+		delete(b.pending, b.order[0])
+		b.order = b.order[1:]
+	}
+}
+
+// post posts the data to the endpoint once.
+func (b *Backend) post(url string, data []byte) error {
+	cxt, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(cxt, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range b.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(b.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(b.secret, data))
+	}
+
+	rsp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Could not post to webhook: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode/100 != 2 {
+		return fmt.Errorf("Webhook responded with status: %s", rsp.Status)
+	}
+	return nil
+}
+
+// Sign produces the signature of a request body, as it is provided in the
+// X-Alert-Signature header, so that receivers can verify requests.
+func Sign(secret, data []byte) string {
+	m := hmac.New(sha256.New, secret)
+	m.Write(data)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
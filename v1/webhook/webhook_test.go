@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bww/go-alert/v1"
+)
+
+// server records the requests posted to it, failing the first fail of them.
+type server struct {
+	sync.Mutex
+	fail     int
+	bodies   [][]byte
+	requests []*http.Request
+}
+
+func (s *server) ServeHTTP(rsp http.ResponseWriter, req *http.Request) {
+	data, _ := io.ReadAll(req.Body)
+	s.Lock()
+	defer s.Unlock()
+	s.bodies = append(s.bodies, data)
+	s.requests = append(s.requests, req)
+	if len(s.requests) <= s.fail {
+		rsp.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+func (s *server) Count() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.requests)
+}
+
+func newServer(t *testing.T, fail int) (*server, string) {
+	s := &server{fail: fail}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv.URL
+}
+
+func TestPost(t *testing.T) {
+	s, u := newServer(t, 0)
+	b, err := New(Config{URLs: []string{u}, Secret: []byte("secret"), Headers: http.Header{"X-Team": {"billing"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &alert.Event{ID: "abc", Level: alert.LevelError, Message: "Failed", Ref: "job", Tags: map[string]string{"host": "db1"}}
+	if err := b.Capture(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Count() != 1 {
+		t.Fatalf("Expected 1 request; got %d", s.Count())
+	}
+	req, data := s.requests[0], s.bodies[0]
+	if v := req.Header.Get("Content-Type"); v != "application/json" {
+		t.Errorf("Unexpected content type: %q", v)
+	}
+	if v := req.Header.Get("X-Team"); v != "billing" {
+		t.Errorf("Expected the configured headers to be set; got %q", v)
+	}
+	if v, want := req.Header.Get(SignatureHeader), Sign([]byte("secret"), data); v != want {
+		t.Errorf("Expected the signature %q; got %q", want, v)
+	}
+	var p Payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "abc" || p.Level != "error" || p.Message != "Failed" || p.Ref != "job" || p.Tags["host"] != "db1" {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+
+	b, err = New(Config{URLs: []string{u}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Capture(e); err != nil {
+		t.Fatal(err)
+	}
+	if v := s.requests[1].Header.Get(SignatureHeader); v != "" {
+		t.Errorf("Expected requests not to be signed without a secret; got %q", v)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac secret
+	if v, want := Sign([]byte("secret"), []byte("{}")), "sha256=77325902caca812dc259733aacd046b73817372c777b8d95b402647474516e13"; v != want {
+		t.Errorf("Expected the signature %q; got %q", want, v)
+	}
+	if Sign([]byte("secret"), []byte("{}")) == Sign([]byte("other"), []byte("{}")) {
+		t.Error("Expected signatures to depend on the secret")
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+	s, u := newServer(t, 0)
+	b, err := New(Config{URLs: []string{u}, MinLevel: alert.LevelError})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Capture(&alert.Event{Level: alert.LevelWarning}); !errors.Is(err, alert.ErrSkipped) {
+		t.Errorf("Expected an alert below the minimum level to be skipped; got %v", err)
+	}
+	if err := b.Capture(&alert.Event{Level: alert.LevelError}); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Count(); n != 1 {
+		t.Errorf("Expected only the error to be posted; got %d requests", n)
+	}
+}
+
+func TestRetryFailed(t *testing.T) {
+	ok, okURL := newServer(t, 0)
+	failing, failingURL := newServer(t, 1)
+	b, err := New(Config{URLs: []string{okURL, failingURL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &alert.Event{Level: alert.LevelError, Message: "Failed"}
+	if err := b.Capture(e); err == nil {
+		t.Fatal("Expected delivery to fail")
+	}
+	if ok.Count() != 1 || failing.Count() != 1 {
+		t.Fatalf("Expected a single attempt per endpoint; got %d, %d", ok.Count(), failing.Count())
+	}
+
+	// the alert is retried, and posted only to the endpoint which failed
+	if err := b.Capture(e); err != nil {
+		t.Fatal(err)
+	}
+	if ok.Count() != 1 || failing.Count() != 2 {
+		t.Errorf("Expected only the failed endpoint to be retried; got %d, %d", ok.Count(), failing.Count())
+	}
+
+	// once delivered, the alert is forgotten
+	if err := b.Capture(e); err != nil {
+		t.Fatal(err)
+	}
+	if ok.Count() != 2 || failing.Count() != 3 || len(b.pending) != 0 {
+		t.Errorf("Expected an alert which was delivered to be posted to every endpoint again; got %d, %d", ok.Count(), failing.Count())
+	}
+}
+
+func TestRetryAsync(t *testing.T) {
+	ok, okURL := newServer(t, 0)
+	failing, failingURL := newServer(t, 2)
+	b, err := New(Config{URLs: []string{okURL, failingURL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deadLetters int
+	a, err := alert.New(alert.Config{
+		Backends:          []alert.Backend{b},
+		Async:             alert.Async{Buffer: 10, Retries: 3, Backoff: time.Millisecond},
+		OnDeliveryFailure: func(*alert.Event, *alert.DeliveryError) { deadLetters++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.Error(errors.New("Failed"))
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ok.Count() != 1 || failing.Count() != 3 {
+		t.Errorf("Expected the alerter to retry only the failed endpoint; got %d, %d", ok.Count(), failing.Count())
+	}
+	if deadLetters != 0 {
+		t.Errorf("Expected the alert to be delivered; got %d dead letters", deadLetters)
+	}
+}
+
+func TestPending(t *testing.T) {
+	_, u := newServer(t, maxPending+10)
+	b, err := New(Config{URLs: []string{u}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := &alert.Event{Level: alert.LevelError}
+	b.Capture(first)
+	for i := 0; i < maxPending; i++ {
+		b.Capture(&alert.Event{Level: alert.LevelError})
+	}
+	if len(b.pending) != maxPending || len(b.order) != maxPending {
+		t.Errorf("Expected at most %d alerts to be remembered; got %d", maxPending, len(b.pending))
+	}
+	if _, ok := b.pending[first]; ok {
+		t.Error("Expected the oldest alert to be forgotten")
+	}
+}
@@ -0,0 +1,106 @@
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// writerSink is a Client which writes each event it captures to a writer as
+// a single line of JSON.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WriterSink creates a Client which writes each event it captures to the
+// writer as a single line of JSON, which is convenient for pipelines that
+// ingest structured output, e.g., from stdout. Configure it as a tee client.
+// Writes are serialized, so the sink may be shared.
+func WriterSink(w io.Writer) Client {
+	return &writerSink{w: w}
+}
+
+// writerRecord is the form in which events are written.
+type writerRecord struct {
+	ID        sentry.EventID         `json:"id"`
+	Time      time.Time              `json:"time"`
+	Level     sentry.Level           `json:"level"`
+	Message   string                 `json:"message"`
+	Ref       string                 `json:"ref,omitempty"`
+	Tags      map[string]string      `json:"tags,omitempty"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+	Exception []writerException      `json:"exception,omitempty"`
+}
+
+type writerException struct {
+	Type  string   `json:"type"`
+	Value string   `json:"value"`
+	Stack []string `json:"stack,omitempty"`
+}
+
+func (s *writerSink) CaptureEvent(event *sentry.Event, hint *sentry.EventHint, scope sentry.EventModifier) *sentry.EventID {
+	if scope != nil {
+		event = scope.ApplyToEvent(event, hint)
+		if event == nil {
+			return nil
+		}
+	}
+	if event.EventID == "" {
+		event.EventID = newEventID()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	rec := writerRecord{
+		ID:      event.EventID,
+		Time:    event.Timestamp,
+		Level:   event.Level,
+		Message: event.Message,
+		Ref:     event.Tags["ref"],
+		Tags:    event.Tags,
+		Extra:   event.Extra,
+	}
+	for _, e := range event.Exception {
+		x := writerException{Type: e.Type, Value: e.Value}
+		if e.Stacktrace != nil {
+			for _, f := range e.Stacktrace.Frames {
+				x.Stack = append(x.Stack, fmt.Sprintf("%s %s:%d", f.Function, f.AbsPath, f.Lineno))
+			}
+		}
+		rec.Exception = append(rec.Exception, x)
+	}
+	if rec.Message == "" && len(event.Exception) > 0 {
+		rec.Message = event.Exception[len(event.Exception)-1].Value
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return nil
+	}
+	id := event.EventID
+	return &id
+}
+
+// Flush returns immediately; events are written as they are captured.
+func (s *writerSink) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func newEventID() sentry.EventID {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return sentry.EventID(hex.EncodeToString(b))
+}
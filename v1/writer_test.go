@@ -0,0 +1,57 @@
+package alert
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	errutil "github.com/bww/go-util/v1/errors"
+)
+
+func TestWriterSink(t *testing.T) {
+	const n = 20
+	buf := &bytes.Buffer{}
+	a, _ := newAlerter(t, Config{Tee: []Client{WriterSink(buf)}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.Error(errutil.Stacktrace(fmt.Errorf("Failed %d", i)), WithRef(fmt.Sprint(i)), WithExtra(map[string]interface{}{"attempt": i}))
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	scan := bufio.NewScanner(buf)
+	for scan.Scan() {
+		line := scan.Bytes()
+		if !json.Valid(line) {
+			t.Fatalf("Expected each line to be valid JSON; got %s", line)
+		}
+		var rec writerRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Level != "error" || rec.ID == "" || rec.Time.IsZero() {
+			t.Errorf("Expected the level, identifier, and time of the alert; got %s", line)
+		}
+		if want := "Failed " + rec.Ref; rec.Message != want || rec.Tags["ref"] != rec.Ref {
+			t.Errorf("Expected the message %q and ref %q; got %s", want, rec.Ref, line)
+		}
+		if v := rec.Extra["attempt"]; fmt.Sprint(v) != rec.Ref {
+			t.Errorf("Expected the extra of the alert; got %v", rec.Extra)
+		}
+		if len(rec.Exception) == 0 || len(rec.Exception[len(rec.Exception)-1].Stack) == 0 {
+			t.Errorf("Expected the stack of the alert; got %s", line)
+		}
+		seen[rec.Ref] = true
+	}
+	if len(seen) != n {
+		t.Errorf("Expected %d distinct alerts to be written; got %d", n, len(seen))
+	}
+}